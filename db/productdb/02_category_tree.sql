@@ -0,0 +1,8 @@
+\connect product_service;
+
+-- cây danh mục lưu dạng adjacency list, danh mục gốc có parent_id NULL.
+-- Ứng dụng chặn vòng lặp khi đổi danh mục cha, truy vấn con cháu dùng WITH RECURSIVE.
+ALTER TABLE categories
+    ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES categories(id);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
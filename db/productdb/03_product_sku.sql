@@ -0,0 +1,6 @@
+\connect product_service;
+
+-- mã sản phẩm ở hệ thống nguồn, là khóa upsert khi import hàng loạt.
+-- Sản phẩm tạo qua API có thể để NULL, UNIQUE cho phép nhiều giá trị NULL.
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS sku TEXT UNIQUE;
@@ -0,0 +1,6 @@
+\connect user_service;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+-- Tài khoản tạo trước khi có xác minh email được coi là đã xác minh
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
@@ -0,0 +1,7 @@
+\connect user_service;
+
+-- mfa_secret là TOTP secret (mã hóa nếu có security.mfa.encryption_key),
+-- mfa_recovery_codes là mảng JSON các hash sha256 của mã khôi phục chưa dùng
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_secret TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_enabled_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_recovery_codes TEXT;
@@ -0,0 +1,44 @@
+\connect user_service;
+
+-- vai trò và quyền được quản lý bằng bảng thay vì CHECK cố định trên users.role
+CREATE TABLE IF NOT EXISTS roles (
+    name        TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name        TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role       TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('user', 'Người dùng'),
+    ('admin', 'Quản trị viên'),
+    ('moderator', 'Kiểm duyệt đánh giá')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:read', 'Xem danh sách và phiên đăng nhập của user'),
+    ('users:write', 'Khóa, mở khóa, khôi phục, xóa user và thu hồi phiên'),
+    ('users:role:write', 'Thay đổi vai trò của user'),
+    ('ratings:moderate', 'Xóa đánh giá vi phạm của user khác'),
+    ('products:write', 'Thêm, sửa, xóa sản phẩm')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('moderator', 'ratings:moderate')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_role;
+ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name);
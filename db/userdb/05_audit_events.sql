@@ -0,0 +1,40 @@
+\connect user_service;
+
+-- nhật ký kiểm toán chỉ được ghi thêm, không có khóa ngoại tới users
+-- để giữ lại lịch sử sau khi user bị xóa vĩnh viễn
+CREATE TABLE IF NOT EXISTS audit_events (
+    id         UUID PRIMARY KEY,
+    actor_id   UUID,
+    target_id  UUID,
+    action     VARCHAR(64) NOT NULL,
+    before     JSONB,
+    after      JSONB,
+    ip         TEXT,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- phân trang theo con trỏ (created_at, id) giảm dần
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at_id ON audit_events (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events (actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target_id ON audit_events (target_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events (action, created_at DESC);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_events_append_only ON audit_events;
+CREATE TRIGGER trg_audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+INSERT INTO permissions (name, description) VALUES
+    ('audit:read', 'Xem nhật ký kiểm toán')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'audit:read')
+ON CONFLICT DO NOTHING;
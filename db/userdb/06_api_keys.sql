@@ -0,0 +1,18 @@
+\connect user_service;
+
+-- key_hash là sha256 (hex) của khóa, khóa gốc chỉ hiển thị một lần khi tạo.
+-- scopes là mảng JSON các permission được giao cho khóa
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           UUID PRIMARY KEY,
+    user_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         VARCHAR(100) NOT NULL,
+    prefix       VARCHAR(16) NOT NULL,
+    key_hash     CHAR(64) NOT NULL UNIQUE,
+    scopes       JSONB NOT NULL DEFAULT '[]',
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id, created_at DESC);
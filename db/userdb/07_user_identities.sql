@@ -0,0 +1,14 @@
+\connect user_service;
+
+-- liên kết tài khoản với danh tính ở OIDC provider, subject là claim "sub" của id_token
+CREATE TABLE IF NOT EXISTS user_identities (
+    id         UUID PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider   VARCHAR(50) NOT NULL,
+    subject    VARCHAR(255) NOT NULL,
+    email      VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
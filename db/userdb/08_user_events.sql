@@ -0,0 +1,15 @@
+\connect user_service;
+
+-- sự kiện vòng đời user cho WatchUserEvents, ghi cùng transaction với thay đổi ở bảng users.
+-- Không có khóa ngoại tới users để giữ sự kiện xóa vĩnh viễn.
+CREATE TABLE IF NOT EXISTS user_events (
+    seq        BIGSERIAL PRIMARY KEY,
+    user_id    UUID NOT NULL,
+    type       VARCHAR(32) NOT NULL,
+    name       TEXT,
+    role       VARCHAR(20),
+    purged     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_events_user_id ON user_events (user_id, seq);
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"productservice/internal"
+	"productservice/internal/dto"
+	"syscall"
+)
+
+// runImport import file sản phẩm đồng bộ, in job (gồm báo cáo lỗi từng dòng) ra stdout dạng JSON.
+// Trả về 1 khi import lỗi hoặc có dòng không import được.
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "csv hoặc ndjson, mặc định theo đuôi file")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: productservice import [-format csv|ndjson] <file|->")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	source := flags.Arg(0)
+	var input io.Reader = os.Stdin
+	request := &dto.ImportRequest{Format: dto.ImportFormat(*format), Source: source}
+	if source != "-" {
+		file, err := os.Open(source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open %s: %v\n", source, err)
+			return 1
+		}
+		defer file.Close()
+		if info, err := file.Stat(); err == nil {
+			request.Size = info.Size()
+		}
+		input = file
+	}
+
+	importer, cleanup, err := internal.NewImporter()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize importer: %v\n", err)
+		return 1
+	}
+	defer cleanup()
+
+	// Ctrl+C dừng import, các lô đã ghi vẫn giữ nguyên, chạy lại file sẽ tiếp tục đúng nhờ upsert theo SKU
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	job, errJob := importer.NewJob(ctx, request)
+	if errJob != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create import job: %v\n", errJob.Err)
+		return 1
+	}
+	runErr := importer.Run(ctx, job, input)
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(job); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+	if runErr != nil || job.Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -4,10 +4,16 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"productservice/internal"
 )
 
 func main() {
+	// productservice import [-format csv|ndjson] <file>
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
+
 	port := flag.Int("port", 8010, "Server port")
 	flag.Parse()
 
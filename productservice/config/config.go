@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/spf13/viper"
@@ -14,8 +15,49 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	Redis    RedisConfig
+	Auth     AuthConfig
+	Import   ImportConfig
 }
 
+// ImportConfig cấu hình import sản phẩm hàng loạt từ CSV/NDJSON
+type ImportConfig struct {
+	// MaxUploadSize là kích thước tối đa (byte) của request import, các route khác giữ giới hạn mặc định
+	MaxUploadSize int `mapstructure:"max_upload_size"`
+	// BatchSize là số dòng upsert trong một câu lệnh, cũng là chu kỳ cập nhật tiến độ của job
+	BatchSize int `mapstructure:"batch_size"`
+	// MaxReportedErrors giới hạn số lỗi theo dòng giữ trong báo cáo, các lỗi sau chỉ được đếm
+	MaxReportedErrors int `mapstructure:"max_reported_errors"`
+	// JobTTL là thời gian giữ trạng thái job trong redis
+	JobTTL time.Duration `mapstructure:"job_ttl"`
+}
+
+type AuthConfig struct {
+	// JWKSURL trỏ tới /.well-known/jwks.json của userservice, để trống thì luôn xác thực qua gRPC
+	JWKSURL             string        `mapstructure:"jwks_url"`
+	JWKSRefreshInterval time.Duration `mapstructure:"jwks_refresh_interval"`
+	// RevocationCheck hỏi userservice xem token đã bị thu hồi chưa với mọi request, kể cả GET
+	RevocationCheck bool `mapstructure:"revocation_check"`
+	// CacheTTL là thời gian tối đa giữ kết quả Authenticate, bằng 0 thì tắt cache
+	CacheTTL        time.Duration `mapstructure:"cache_ttl"`
+	CacheMaxEntries int           `mapstructure:"cache_max_entries"`
+	// CacheRedis dùng redis làm tầng cache thứ hai, chia sẻ giữa các instance
+	CacheRedis bool `mapstructure:"cache_redis"`
+	// RequireVerifiedEmailForRating chỉ cho tài khoản đã xác minh email đánh giá sản phẩm
+	RequireVerifiedEmailForRating bool `mapstructure:"require_verified_email_for_rating"`
+	// RequireMFAForAdmin chặn admin chưa xác thực MFA dùng các quyền quản trị, phải khớp với security.mfa.require_for_admin của userservice
+	RequireMFAForAdmin bool `mapstructure:"require_mfa_for_admin"`
+	// DegradedMode quyết định cách xử lý khi userservice không phản hồi:
+	// "off" trả về 503, "cached_read_only" cho request chỉ đọc dùng kết quả Authenticate đã cache kể cả khi đã quá cache_ttl
+	DegradedMode string `mapstructure:"degraded_mode"`
+	// DegradedMaxStale là thời gian tối đa sau cache_ttl mà kết quả cache còn được dùng ở chế độ degraded
+	DegradedMaxStale time.Duration `mapstructure:"degraded_max_stale"`
+}
+
+const (
+	DegradedModeOff            = "off"
+	DegradedModeCachedReadOnly = "cached_read_only"
+)
+
 type RedisConfig struct {
 	Addr     string `mapstructure:"addr"`
 	Password string `mapstructure:"pass"`
@@ -24,8 +66,10 @@ type RedisConfig struct {
 
 type ServerConfig struct {
 	HTTPPort string `mapstructure:"http_port"`
-	Grpc     GRPC   `mapstructure:"grpc"`
-	Env      string `mapstructure:"env"`
+	// MetricsAddr là địa chỉ listener nội bộ cho /debug/vars, để trống thì tắt
+	MetricsAddr string `mapstructure:"metrics_addr"`
+	Grpc        GRPC   `mapstructure:"grpc"`
+	Env         string `mapstructure:"env"`
 }
 
 type GRPC struct {
@@ -35,6 +79,42 @@ type GRPC struct {
 type AuthGrpc struct {
 	Port string `mapstructure:"port"`
 	Host string `mapstructure:"host"`
+	// ServiceToken phải khớp một token trong security.service_tokens của userservice, dùng cho GetUserById/BatchGetUsers
+	ServiceToken string `mapstructure:"service_token"`
+	// Addresses là danh sách host:port của các instance userservice, có thì dùng thay cho Host/Port và chia tải round robin
+	Addresses []string `mapstructure:"addresses"`
+	// Timeout là deadline của mỗi lần gọi unary, tính cả các lần retry
+	Timeout time.Duration   `mapstructure:"timeout"`
+	Retry   RetryConfig     `mapstructure:"retry"`
+	Breaker BreakerConfig   `mapstructure:"breaker"`
+	TLS     ClientTLSConfig `mapstructure:"tls"`
+}
+
+// ClientTLSConfig bật TLS khi có CAFile, thêm CertFile/KeyFile thì gửi chứng chỉ client (mTLS)
+type ClientTLSConfig struct {
+	// CAFile là CA ký chứng chỉ của userservice
+	CAFile   string `mapstructure:"ca_file"`
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ServerName phải khớp DNS SAN trong chứng chỉ của userservice, để trống thì dùng Host
+	ServerName string `mapstructure:"server_name"`
+	// ReloadInterval là chu kỳ kiểm tra file chứng chỉ để nạp lại khi được xoay vòng
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+}
+
+// RetryConfig là retry policy của gRPC, chỉ retry khi nhận UNAVAILABLE
+type RetryConfig struct {
+	// MaxAttempts tính cả lần gọi đầu tiên, nhỏ hơn 2 thì không retry
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+type BreakerConfig struct {
+	// FailureThreshold là số lần lỗi liên tiếp để mở mạch, bằng 0 thì tắt circuit breaker
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// OpenTimeout là thời gian mạch mở trước khi cho một request thử lại
+	OpenTimeout time.Duration `mapstructure:"open_timeout"`
 }
 
 type DatabaseConfig struct {
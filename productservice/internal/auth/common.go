@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// Claims khớp với claims do userservice (pkg/jwtMg) phát hành
+type Claims struct {
+	UserID        string   `json:"user_id"`
+	Email         string   `json:"email"`
+	Name          string   `json:"name"`
+	Role          string   `json:"role"`
+	EmailVerified bool     `json:"email_verified"`
+	Permissions   []string `json:"perms"`
+	MFA           bool     `json:"mfa"`
+	TokenType     string   `json:"token_type"`
+	jwt.RegisteredClaims
+}
+
+const RoleAdmin = "admin"
+
+// quyền do userservice cấp theo vai trò (bảng role_permissions)
+const (
+	PermRatingsModerate = "ratings:moderate"
+	PermProductsWrite   = "products:write"
+)
+
+// HasPermission kiểm tra quyền trong danh sách permissions của token
+func HasPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	tokenTypeAccess = "access"
+	tokenIssuer     = "user-service"
+)
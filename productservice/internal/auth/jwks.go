@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"productservice/config"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// minRefreshGap giới hạn số lần tải lại JWKS khi gặp kid lạ
+const minRefreshGap = 30 * time.Second
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type publicKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// KeySet giữ bản sao public key của userservice và định kỳ tải lại
+type KeySet struct {
+	url        string
+	httpClient *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]publicKey
+	lastRefresh time.Time
+	refreshing  bool
+}
+
+func NewKeySet(cfg *config.Config) (*KeySet, func(), error) {
+	ks := &KeySet{
+		url:        cfg.Auth.JWKSURL,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		keys:       map[string]publicKey{},
+	}
+
+	if ks.url == "" {
+		log.Info("JWKS url is empty, tokens are verified through gRPC only")
+		return ks, func() {}, nil
+	}
+
+	// userservice có thể chưa sẵn sàng lúc khởi động, lỗi chỉ được log và thử lại ở lần refresh sau
+	if err := ks.Refresh(context.Background()); err != nil {
+		log.Errorf("failed to load JWKS from %s: %v", ks.url, err)
+	}
+
+	interval := cfg.Auth.JWKSRefreshInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := ks.Refresh(context.Background()); err != nil {
+					log.Errorf("failed to refresh JWKS: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	cleanup := func() {
+		close(done)
+	}
+	return ks, cleanup, nil
+}
+
+// Get trả về public key theo kid
+func (ks *KeySet) Get(kid string) (crypto.PublicKey, string, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	k, ok := ks.keys[kid]
+	return k.key, k.alg, ok
+}
+
+// RefreshAsync tải lại JWKS ở nền, bỏ qua nếu vừa tải gần đây hoặc đang tải
+func (ks *KeySet) RefreshAsync() {
+	if ks.url == "" {
+		return
+	}
+
+	ks.mu.Lock()
+	if ks.refreshing || time.Since(ks.lastRefresh) < minRefreshGap {
+		ks.mu.Unlock()
+		return
+	}
+	ks.refreshing = true
+	ks.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := ks.Refresh(ctx); err != nil {
+			log.Errorf("failed to refresh JWKS: %v", err)
+		}
+	}()
+}
+
+func (ks *KeySet) Refresh(ctx context.Context) error {
+	defer func() {
+		ks.mu.Lock()
+		ks.refreshing = false
+		ks.lastRefresh = time.Now()
+		ks.mu.Unlock()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := ks.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var set jwks
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		parsed, err := parseJWK(k)
+		if err != nil {
+			log.Errorf("skip JWK %s: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = parsed
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+
+	log.Infof("Loaded %d JWKS keys", len(keys))
+	return nil
+}
+
+func parseJWK(k jwk) (publicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return publicKey{}, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return publicKey{}, err
+		}
+		return publicKey{
+			alg: "RS256",
+			key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())},
+		}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return publicKey{}, errors.New("unsupported curve " + k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return publicKey{}, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return publicKey{}, errors.New("invalid Ed25519 key size")
+		}
+		return publicKey{alg: "EdDSA", key: ed25519.PublicKey(x)}, nil
+	default:
+		return publicKey{}, errors.New("unsupported key type " + k.Kty)
+	}
+}
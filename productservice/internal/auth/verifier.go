@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Verifier xác thực access token tại chỗ bằng public key của userservice
+type Verifier struct {
+	keys *KeySet
+}
+
+func NewVerifier(keys *KeySet) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+// Verify trả về ErrUnknownKey khi token không có kid hoặc kid chưa có trong JWKS,
+// khi đó caller cần hỏi lại userservice qua gRPC.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, v.keyFunc,
+		jwt.WithAudience(tokenTypeAccess),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, ErrUnknownKey) {
+			return nil, ErrUnknownKey
+		}
+		return nil, ErrInvalidToken
+	}
+
+	if claims.TokenType != tokenTypeAccess || claims.UserID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	// token HS256 không có kid, chỉ userservice mới xác thực được
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKey
+	}
+
+	key, alg, ok := v.keys.Get(kid)
+	if !ok {
+		v.keys.RefreshAsync()
+		return nil, ErrUnknownKey
+	}
+	// thuật toán phải khớp với loại khóa để tránh algorithm confusion
+	if token.Method.Alg() != alg {
+		return nil, ErrInvalidToken
+	}
+	return key, nil
+}
+
+// ParseUnverified đọc claims mà không kiểm tra chữ ký.
+// Chỉ dùng sau khi userservice đã xác nhận token hợp lệ qua gRPC.
+func ParseUnverified(token string) (*Claims, error) {
+	claims := &Claims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
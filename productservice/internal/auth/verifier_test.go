@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"productservice/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeySet(t *testing.T, kid string, pub ed25519.PublicKey) *KeySet {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jwks{Keys: []jwk{{
+			Kty: "OKP",
+			Kid: kid,
+			Alg: "EdDSA",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}}})
+	}))
+	t.Cleanup(server.Close)
+
+	ks, cleanup, err := NewKeySet(&config.Config{Auth: config.AuthConfig{JWKSURL: server.URL, JWKSRefreshInterval: time.Hour}})
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+	return ks
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, tokenType string) string {
+	claims := &Claims{
+		UserID:      uuid.New().String(),
+		Name:        "John Doe",
+		Role:        "moderator",
+		Permissions: []string{PermRatingsModerate},
+		TokenType:   tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Audience:  jwt.ClaimStrings{tokenType},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	verifier := NewVerifier(newTestKeySet(t, "key-1", pub))
+
+	tests := []struct {
+		name        string
+		token       string
+		expectedErr error
+	}{
+		{
+			name:  "Success - Known Key",
+			token: signToken(t, jwt.SigningMethodEdDSA, priv, "key-1", tokenTypeAccess),
+		},
+		{
+			name:        "Fallback - Unknown Kid",
+			token:       signToken(t, jwt.SigningMethodEdDSA, priv, "key-2", tokenTypeAccess),
+			expectedErr: ErrUnknownKey,
+		},
+		{
+			name:        "Fallback - HS256 Without Kid",
+			token:       signToken(t, jwt.SigningMethodHS256, []byte("secret"), "", tokenTypeAccess),
+			expectedErr: ErrUnknownKey,
+		},
+		{
+			name:        "Error - Wrong Signature",
+			token:       signToken(t, jwt.SigningMethodEdDSA, otherPriv, "key-1", tokenTypeAccess),
+			expectedErr: ErrInvalidToken,
+		},
+		{
+			name:        "Error - Algorithm Mismatch",
+			token:       signToken(t, jwt.SigningMethodHS256, []byte("secret"), "key-1", tokenTypeAccess),
+			expectedErr: ErrInvalidToken,
+		},
+		{
+			name:        "Error - Refresh Token",
+			token:       signToken(t, jwt.SigningMethodEdDSA, priv, "key-1", "refresh"),
+			expectedErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token)
+			if tt.expectedErr != nil {
+				assert.Nil(t, claims)
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "John Doe", claims.Name)
+				assert.True(t, HasPermission(claims.Permissions, PermRatingsModerate))
+				assert.False(t, HasPermission(claims.Permissions, PermProductsWrite))
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutJWKS(t *testing.T) {
+	ks, cleanup, err := NewKeySet(&config.Config{})
+	require.NoError(t, err)
+	defer cleanup()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	claims, err := NewVerifier(ks).Verify(signToken(t, jwt.SigningMethodEdDSA, priv, "key-1", tokenTypeAccess))
+	assert.Nil(t, claims)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
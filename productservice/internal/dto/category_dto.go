@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// CategoryRequest là dữ liệu khi tạo hoặc sửa danh mục, ParentID nil là danh mục gốc
+type CategoryRequest struct {
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+}
+
+// CategoryNode là một nút của cây danh mục.
+// ProductCount gồm sản phẩm của cả danh mục con cháu, DirectProductCount chỉ tính sản phẩm gắn trực tiếp.
+type CategoryNode struct {
+	ID                 uuid.UUID       `json:"id"`
+	Name               string          `json:"name"`
+	Description        *string         `json:"description"`
+	ParentID           *uuid.UUID      `json:"parent_id"`
+	ProductCount       int64           `json:"product_count"`
+	DirectProductCount int64           `json:"direct_product_count"`
+	Children           []*CategoryNode `json:"children"`
+}
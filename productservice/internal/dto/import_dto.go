@@ -0,0 +1,59 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ImportFormat string
+
+var (
+	ImportFormat_CSV    ImportFormat = "csv"
+	ImportFormat_NDJSON ImportFormat = "ndjson"
+)
+
+type ImportJobStatus string
+
+var (
+	ImportJob_Pending   ImportJobStatus = "pending"
+	ImportJob_Running   ImportJobStatus = "running"
+	ImportJob_Completed ImportJobStatus = "completed"
+	ImportJob_Failed    ImportJobStatus = "failed"
+)
+
+// ImportRequest mô tả file được import, Format rỗng thì suy ra từ đuôi của Source
+type ImportRequest struct {
+	Format ImportFormat
+	Source string
+	Size   int64
+}
+
+// ImportRowError là một dòng bị bỏ qua, Row là số dòng trong file (tính cả dòng header của CSV)
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	SKU   string `json:"sku,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportJob là trạng thái và báo cáo của một lần import.
+// Created/Updated đếm theo SKU đã có trong DB hay chưa nên chạy lại cùng file chỉ làm tăng Updated.
+type ImportJob struct {
+	ID              uuid.UUID        `json:"id"`
+	Status          ImportJobStatus  `json:"status"`
+	Format          ImportFormat     `json:"format"`
+	Source          string           `json:"source"`
+	TotalBytes      int64            `json:"total_bytes"`
+	ReadBytes       int64            `json:"read_bytes"`
+	Progress        float64          `json:"progress"`
+	ProcessedRows   int              `json:"processed_rows"`
+	Created         int              `json:"created"`
+	Updated         int              `json:"updated"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated"`
+	Error           string           `json:"error,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	StartedAt       *time.Time       `json:"started_at,omitempty"`
+	FinishedAt      *time.Time       `json:"finished_at,omitempty"`
+}
@@ -28,3 +28,21 @@ var (
 	Relation_Similar RelationType = "similar"
 	Relation_Related RelationType = "related"
 )
+
+// ProductRequest là dữ liệu đầy đủ của sản phẩm khi tạo mới (POST) hoặc thay thế (PUT).
+// Description rỗng hoặc không gửi thì xóa mô tả.
+type ProductRequest struct {
+	Name        string    `json:"name"`
+	Description *string   `json:"description"`
+	Price       float64   `json:"price"`
+	CategoryID  uuid.UUID `json:"category_id"`
+}
+
+// PatchProductRequest chỉ cập nhật các trường được gửi lên (PATCH).
+// Description là chuỗi rỗng thì xóa mô tả.
+type PatchProductRequest struct {
+	Name        *string    `json:"name"`
+	Description *string    `json:"description"`
+	Price       *float64   `json:"price"`
+	CategoryID  *uuid.UUID `json:"category_id"`
+}
@@ -78,6 +78,17 @@ type RateResponse struct {
 	UpdateAt time.Time     `json:"updateAt,omitempty"`
 }
 
+// RateOfProduct là một đánh giá trong danh sách đánh giá của sản phẩm
+type RateOfProduct struct {
+	Id        uuid.UUID  `json:"id"`
+	ProductId uuid.UUID  `json:"product_id"`
+	User      UserOfRate `json:"user"`
+	Star      int        `json:"stars"`
+	Comment   string     `json:"comment"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
 type UserOfRate struct {
 	Id   uuid.UUID `json:"id"`
 	Name string    `json:"name"`
@@ -0,0 +1,448 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"productservice/config"
+	"productservice/internal/grpc/pb/userservicepb"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// revocationChannel phải trùng với cache.RevocationChannel của userservice
+	revocationChannel = "auth:revocations"
+
+	baseAuthCacheToken = "auth_cache:token:"
+	baseAuthCacheUser  = "auth_cache:user:"
+
+	// authLoadTimeout giới hạn một lần gọi Authenticate dùng chung cho nhiều request
+	authLoadTimeout = 5 * time.Second
+)
+
+type authLoader func(ctx context.Context, token string) (*userservicepb.AuthResponse, error)
+
+type cacheEntry struct {
+	key       string
+	resp      *userservicepb.AuthResponse
+	expiresAt time.Time
+	// staleUntil >= expiresAt, sau expiresAt entry chỉ còn dùng được qua Stale
+	staleUntil time.Time
+}
+
+type cachedAuth struct {
+	UserID        string   `json:"user_id"`
+	Role          string   `json:"role"`
+	Permissions   []string `json:"permissions,omitempty"`
+	Name          string   `json:"name,omitempty"`
+	EmailVerified bool     `json:"email_verified,omitempty"`
+	MFA           bool     `json:"mfa,omitempty"`
+}
+
+type revocationEvent struct {
+	UserID string `json:"user_id"`
+	JTI    string `json:"jti,omitempty"`
+}
+
+// AuthCacheStats là số liệu của cache, được publish qua expvar với tên "auth_cache"
+type AuthCacheStats struct {
+	Entries       int   `json:"entries"`
+	MemoryHits    int64 `json:"memory_hits"`
+	StaleHits     int64 `json:"stale_hits"`
+	RedisHits     int64 `json:"redis_hits"`
+	Misses        int64 `json:"misses"`
+	Shared        int64 `json:"shared"`
+	Invalidations int64 `json:"invalidations"`
+	Evictions     int64 `json:"evictions"`
+}
+
+// AuthCache lưu kết quả Authenticate hợp lệ theo hash của token.
+// Mỗi entry sống tối đa ttl và không quá thời điểm hết hạn của token.
+// Chỉ cache kết quả hợp lệ, token bị từ chối luôn được hỏi lại userservice.
+type AuthCache struct {
+	ttl        time.Duration
+	maxStale   time.Duration // chỉ khác 0 khi bật auth.degraded_mode
+	maxEntries int
+	rd         *redis.Client // nil nếu không dùng tầng redis
+	group      singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru xếp entry theo lần dùng gần nhất ở đầu, khi đầy thì bỏ entry ở cuối
+	lru       *list.List
+	byUser    map[string]map[string]struct{}
+	revokedAt map[string]time.Time
+
+	memoryHits    atomic.Int64
+	staleHits     atomic.Int64
+	redisHits     atomic.Int64
+	misses        atomic.Int64
+	shared        atomic.Int64
+	invalidations atomic.Int64
+	evictions     atomic.Int64
+
+	done chan struct{}
+}
+
+// NewAuthCache trả về nil khi auth.cache_ttl không được cấu hình.
+// Cache lắng nghe sự kiện thu hồi token của userservice qua redis pub/sub.
+func NewAuthCache(cfg *config.Config, rd *redis.Client) (*AuthCache, func()) {
+	if cfg.Auth.CacheTTL <= 0 {
+		log.Info("Authenticate cache is disabled")
+		return nil, func() {}
+	}
+
+	var tier *redis.Client
+	if cfg.Auth.CacheRedis {
+		tier = rd
+	}
+	c := newAuthCache(cfg.Auth.CacheTTL, cfg.Auth.CacheMaxEntries, tier)
+	if cfg.Auth.DegradedMode == config.DegradedModeCachedReadOnly {
+		c.maxStale = cfg.Auth.DegradedMaxStale
+	}
+
+	pubsub := rd.Subscribe(context.Background(), revocationChannel)
+	go c.listen(pubsub.Channel())
+	go c.janitor()
+
+	if expvar.Get("auth_cache") == nil {
+		expvar.Publish("auth_cache", expvar.Func(func() any { return c.Stats() }))
+	}
+
+	cleanup := func() {
+		if err := pubsub.Close(); err != nil {
+			log.Errorf("failed to close revocation subscription: %v", err)
+		}
+		close(c.done)
+	}
+	return c, cleanup
+}
+
+func newAuthCache(ttl time.Duration, maxEntries int, rd *redis.Client) *AuthCache {
+	return &AuthCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		rd:         rd,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		byUser:     make(map[string]map[string]struct{}),
+		revokedAt:  make(map[string]time.Time),
+		done:       make(chan struct{}),
+	}
+}
+
+// Fetch trả về kết quả đã cache hoặc gọi load, token có thể là access token hoặc API key.
+// Các request đồng thời với cùng token chỉ tạo ra một lần gọi load.
+func (c *AuthCache) Fetch(ctx context.Context, token string, load authLoader) (*userservicepb.AuthResponse, error) {
+	key := hashToken(token)
+	if resp, ok := c.getMemory(key); ok {
+		c.memoryHits.Add(1)
+		return resp, nil
+	}
+
+	v, err, shared := c.group.Do(key, func() (interface{}, error) {
+		start := time.Now()
+		expiresAt, staleUntil := c.expiresAt(token), c.staleUntil(token)
+
+		if resp, ok := c.getRedis(ctx, key); ok {
+			c.redisHits.Add(1)
+			c.setMemory(key, resp, expiresAt, staleUntil, start)
+			return resp, nil
+		}
+		c.misses.Add(1)
+
+		// request đầu tiên bị hủy không được làm hỏng kết quả của các request đang chờ
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), authLoadTimeout)
+		defer cancel()
+
+		resp, err := load(loadCtx, token)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Valid {
+			c.setMemory(key, resp, expiresAt, staleUntil, start)
+			c.setRedis(ctx, key, resp, expiresAt, start)
+		}
+		return resp, nil
+	})
+	if shared {
+		c.shared.Add(1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v.(*userservicepb.AuthResponse), nil
+}
+
+// Stale trả về kết quả đã cache kể cả khi đã quá ttl, tối đa auth.degraded_max_stale và không quá hạn của token.
+// Chỉ dùng khi userservice không phản hồi. Token đã bị thu hồi thì không còn trong cache.
+func (c *AuthCache) Stale(token string) (*userservicepb.AuthResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[hashToken(token)]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !time.Now().Before(entry.staleUntil) {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	c.staleHits.Add(1)
+	return entry.resp, true
+}
+
+// InvalidateUser xóa mọi kết quả đã cache của user.
+// Sự kiện thu hồi không mang hash của token nên cả phiên lẫn toàn bộ phiên đều bị xóa theo user.
+func (c *AuthCache) InvalidateUser(ctx context.Context, userID string) {
+	c.invalidations.Add(1)
+
+	c.mu.Lock()
+	for key := range c.byUser[userID] {
+		if elem, ok := c.entries[key]; ok {
+			c.removeLocked(elem)
+		}
+	}
+	c.revokedAt[userID] = time.Now()
+	c.mu.Unlock()
+
+	if c.rd == nil {
+		return
+	}
+
+	userKey := baseAuthCacheUser + userID
+	hashes, err := c.rd.SMembers(ctx, userKey).Result()
+	if err != nil {
+		log.Errorf("failed to load cached tokens of user %s: %v", userID, err)
+		return
+	}
+	keys := []string{userKey}
+	for _, hash := range hashes {
+		keys = append(keys, baseAuthCacheToken+hash)
+	}
+	if err := c.rd.Del(ctx, keys...).Err(); err != nil {
+		log.Errorf("failed to invalidate cached tokens of user %s: %v", userID, err)
+	}
+}
+
+func (c *AuthCache) Stats() AuthCacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return AuthCacheStats{
+		Entries:       entries,
+		MemoryHits:    c.memoryHits.Load(),
+		StaleHits:     c.staleHits.Load(),
+		RedisHits:     c.redisHits.Load(),
+		Misses:        c.misses.Load(),
+		Shared:        c.shared.Load(),
+		Invalidations: c.invalidations.Load(),
+		Evictions:     c.evictions.Load(),
+	}
+}
+
+func (c *AuthCache) getMemory(key string) (*userservicepb.AuthResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.resp, true
+}
+
+func (c *AuthCache) setMemory(key string, resp *userservicepb.AuthResponse, expiresAt, staleUntil time.Time, start time.Time) {
+	if !time.Now().Before(expiresAt) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// kết quả lấy về trước khi user bị thu hồi token thì không được lưu lại
+	if at, ok := c.revokedAt[resp.UserId]; ok && !start.After(at) {
+		return
+	}
+
+	entry := &cacheEntry{key: key, resp: resp, expiresAt: expiresAt, staleUntil: staleUntil}
+	if elem, ok := c.entries[key]; ok {
+		c.removeLocked(elem)
+	}
+	// cache đầy thì bỏ entry lâu không dùng nhất thay vì ngừng cache token mới
+	for c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.removeLocked(c.lru.Back())
+		c.evictions.Add(1)
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	if c.byUser[resp.UserId] == nil {
+		c.byUser[resp.UserId] = make(map[string]struct{})
+	}
+	c.byUser[resp.UserId][key] = struct{}{}
+}
+
+func (c *AuthCache) getRedis(ctx context.Context, key string) (*userservicepb.AuthResponse, bool) {
+	if c.rd == nil {
+		return nil, false
+	}
+
+	data, err := c.rd.Get(ctx, baseAuthCacheToken+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Errorf("failed to read auth cache: %v", err)
+		}
+		return nil, false
+	}
+
+	var cached cachedAuth
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false
+	}
+	return &userservicepb.AuthResponse{
+		Valid:         true,
+		UserId:        cached.UserID,
+		Role:          cached.Role,
+		Permissions:   cached.Permissions,
+		Name:          cached.Name,
+		EmailVerified: cached.EmailVerified,
+		Mfa:           cached.MFA,
+	}, true
+}
+
+func (c *AuthCache) setRedis(ctx context.Context, key string, resp *userservicepb.AuthResponse, expiresAt time.Time, start time.Time) {
+	if c.rd == nil {
+		return
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	at, revoked := c.revokedAt[resp.UserId]
+	c.mu.Unlock()
+	if revoked && !start.After(at) {
+		return
+	}
+
+	data, err := json.Marshal(cachedAuth{
+		UserID:        resp.UserId,
+		Role:          resp.Role,
+		Permissions:   resp.Permissions,
+		Name:          resp.Name,
+		EmailVerified: resp.EmailVerified,
+		MFA:           resp.Mfa,
+	})
+	if err != nil {
+		return
+	}
+
+	userKey := baseAuthCacheUser + resp.UserId
+	pipe := c.rd.Pipeline()
+	pipe.Set(ctx, baseAuthCacheToken+key, data, ttl)
+	pipe.SAdd(ctx, userKey, key)
+	pipe.Expire(ctx, userKey, c.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Errorf("failed to write auth cache: %v", err)
+	}
+}
+
+func (c *AuthCache) expiresAt(token string) time.Time {
+	return boundByToken(token, time.Now().Add(c.ttl))
+}
+
+func (c *AuthCache) staleUntil(token string) time.Time {
+	return boundByToken(token, time.Now().Add(c.ttl+c.maxStale))
+}
+
+// boundByToken lấy hạn của token mà không xác thực chữ ký, userservice vẫn là nơi quyết định token hợp lệ
+func boundByToken(token string, expiresAt time.Time) time.Time {
+	claims := &jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil || claims.ExpiresAt == nil {
+		return expiresAt
+	}
+	if claims.ExpiresAt.Time.Before(expiresAt) {
+		return claims.ExpiresAt.Time
+	}
+	return expiresAt
+}
+
+func (c *AuthCache) listen(messages <-chan *redis.Message) {
+	for msg := range messages {
+		var event revocationEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil || event.UserID == "" {
+			log.Warnf("invalid revocation event: %s", msg.Payload)
+			continue
+		}
+		c.InvalidateUser(context.Background(), event.UserID)
+	}
+}
+
+func (c *AuthCache) janitor() {
+	ticker := time.NewTicker(c.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case now := <-ticker.C:
+			c.evictExpired(now)
+		}
+	}
+}
+
+func (c *AuthCache) evictExpired(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, elem := range c.entries {
+		if now.Before(elem.Value.(*cacheEntry).staleUntil) {
+			continue
+		}
+		c.removeLocked(elem)
+	}
+
+	// sau khoảng này mọi lần gọi bắt đầu trước khi thu hồi đều đã kết thúc
+	for userID, at := range c.revokedAt {
+		if now.Sub(at) > c.ttl+authLoadTimeout {
+			delete(c.revokedAt, userID)
+		}
+	}
+}
+
+// removeLocked xóa entry khỏi mọi chỉ mục, c.mu phải đang được giữ
+func (c *AuthCache) removeLocked(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	if keys := c.byUser[entry.resp.UserId]; keys != nil {
+		delete(keys, entry.key)
+		if len(keys) == 0 {
+			delete(c.byUser, entry.resp.UserId)
+		}
+	}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"productservice/internal/grpc/pb/userservicepb"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestToken(t *testing.T, expiresIn time.Duration) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		ID:        time.Now().String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	return token
+}
+
+func countingLoader(calls *atomic.Int64, resp *userservicepb.AuthResponse, err error) authLoader {
+	return func(ctx context.Context, token string) (*userservicepb.AuthResponse, error) {
+		calls.Add(1)
+		return resp, err
+	}
+}
+
+func TestAuthCacheFetch(t *testing.T) {
+	valid := &userservicepb.AuthResponse{Valid: true, UserId: "user-1", Role: "user"}
+
+	t.Run("Success - Hit After Miss", func(t *testing.T) {
+		c := newAuthCache(time.Minute, 0, nil)
+		token := newTestToken(t, time.Hour)
+		var calls atomic.Int64
+
+		for i := 0; i < 3; i++ {
+			resp, err := c.Fetch(context.Background(), token, countingLoader(&calls, valid, nil))
+			assert.NoError(t, err)
+			assert.Equal(t, "user-1", resp.UserId)
+		}
+
+		assert.Equal(t, int64(1), calls.Load())
+		stats := c.Stats()
+		assert.Equal(t, int64(1), stats.Misses)
+		assert.Equal(t, int64(2), stats.MemoryHits)
+	})
+
+	t.Run("Success - Concurrent Lookups Collapsed", func(t *testing.T) {
+		c := newAuthCache(time.Minute, 0, nil)
+		token := newTestToken(t, time.Hour)
+		var calls atomic.Int64
+		release := make(chan struct{})
+		load := func(ctx context.Context, token string) (*userservicepb.AuthResponse, error) {
+			calls.Add(1)
+			<-release
+			return valid, nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.Fetch(context.Background(), token, load)
+				assert.NoError(t, err)
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int64(1), calls.Load())
+	})
+
+	t.Run("Success - Invalid Result Not Cached", func(t *testing.T) {
+		c := newAuthCache(time.Minute, 0, nil)
+		token := newTestToken(t, time.Hour)
+		var calls atomic.Int64
+		invalid := &userservicepb.AuthResponse{Valid: false}
+
+		c.Fetch(context.Background(), token, countingLoader(&calls, invalid, nil))
+		c.Fetch(context.Background(), token, countingLoader(&calls, invalid, nil))
+
+		assert.Equal(t, int64(2), calls.Load())
+	})
+
+	t.Run("Error - Load Fails", func(t *testing.T) {
+		c := newAuthCache(time.Minute, 0, nil)
+		token := newTestToken(t, time.Hour)
+		var calls atomic.Int64
+
+		resp, err := c.Fetch(context.Background(), token, countingLoader(&calls, nil, errors.New("unavailable")))
+		assert.Nil(t, resp)
+		assert.Error(t, err)
+
+		c.Fetch(context.Background(), token, countingLoader(&calls, valid, nil))
+		assert.Equal(t, int64(2), calls.Load())
+	})
+
+	t.Run("Success - Bounded By Token Expiry", func(t *testing.T) {
+		c := newAuthCache(time.Minute, 0, nil)
+		token := newTestToken(t, time.Second)
+
+		assert.WithinDuration(t, time.Now().Add(time.Second), c.expiresAt(token), time.Second)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), c.expiresAt("not-a-jwt"), time.Second)
+	})
+}
+
+func TestAuthCacheInvalidateUser(t *testing.T) {
+	c := newAuthCache(time.Minute, 0, nil)
+	token := newTestToken(t, time.Hour)
+	valid := &userservicepb.AuthResponse{Valid: true, UserId: "user-1", Role: "user"}
+	var calls atomic.Int64
+
+	c.Fetch(context.Background(), token, countingLoader(&calls, valid, nil))
+	c.InvalidateUser(context.Background(), "user-1")
+	assert.Equal(t, 0, c.Stats().Entries)
+
+	c.Fetch(context.Background(), token, countingLoader(&calls, valid, nil))
+	assert.Equal(t, int64(2), calls.Load())
+	assert.Equal(t, int64(1), c.Stats().Invalidations)
+}
+
+func TestAuthCacheEviction(t *testing.T) {
+	c := newAuthCache(time.Minute, 2, nil)
+	valid := &userservicepb.AuthResponse{Valid: true, UserId: "user-1", Role: "user"}
+	first, second, third := newTestToken(t, time.Hour), newTestToken(t, time.Hour), newTestToken(t, time.Hour)
+	var calls atomic.Int64
+
+	c.Fetch(context.Background(), first, countingLoader(&calls, valid, nil))
+	c.Fetch(context.Background(), second, countingLoader(&calls, valid, nil))
+	// first được dùng lại nên second là entry lâu không dùng nhất
+	c.Fetch(context.Background(), first, countingLoader(&calls, valid, nil))
+	c.Fetch(context.Background(), third, countingLoader(&calls, valid, nil))
+
+	stats := c.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, int64(1), stats.Evictions)
+
+	c.Fetch(context.Background(), first, countingLoader(&calls, valid, nil))
+	c.Fetch(context.Background(), third, countingLoader(&calls, valid, nil))
+	assert.Equal(t, int64(3), calls.Load())
+
+	c.Fetch(context.Background(), second, countingLoader(&calls, valid, nil))
+	assert.Equal(t, int64(4), calls.Load())
+}
+
+func TestAuthCacheStale(t *testing.T) {
+	valid := &userservicepb.AuthResponse{Valid: true, UserId: "user-1", Role: "user"}
+	unavailable := errors.New("unavailable")
+
+	t.Run("Success - Served After TTL", func(t *testing.T) {
+		c := newAuthCache(10*time.Millisecond, 0, nil)
+		c.maxStale = time.Minute
+		token := newTestToken(t, time.Hour)
+		var calls atomic.Int64
+		c.Fetch(context.Background(), token, countingLoader(&calls, valid, nil))
+		time.Sleep(20 * time.Millisecond)
+
+		_, err := c.Fetch(context.Background(), token, countingLoader(&calls, nil, unavailable))
+		assert.ErrorIs(t, err, unavailable)
+
+		resp, ok := c.Stale(token)
+		require.True(t, ok)
+		assert.Equal(t, "user-1", resp.UserId)
+		assert.Equal(t, int64(1), c.Stats().StaleHits)
+	})
+
+	t.Run("Error - Disabled Without Max Stale", func(t *testing.T) {
+		c := newAuthCache(10*time.Millisecond, 0, nil)
+		token := newTestToken(t, time.Hour)
+		var calls atomic.Int64
+		c.Fetch(context.Background(), token, countingLoader(&calls, valid, nil))
+		time.Sleep(20 * time.Millisecond)
+
+		_, ok := c.Stale(token)
+		assert.False(t, ok)
+	})
+
+	t.Run("Error - Revoked User", func(t *testing.T) {
+		c := newAuthCache(time.Minute, 0, nil)
+		c.maxStale = time.Minute
+		token := newTestToken(t, time.Hour)
+		var calls atomic.Int64
+		c.Fetch(context.Background(), token, countingLoader(&calls, valid, nil))
+		c.InvalidateUser(context.Background(), "user-1")
+
+		_, ok := c.Stale(token)
+		assert.False(t, ok)
+	})
+
+	t.Run("Success - Bounded By Token Expiry", func(t *testing.T) {
+		c := newAuthCache(time.Minute, 0, nil)
+		c.maxStale = time.Hour
+		token := newTestToken(t, 2*time.Minute)
+
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), c.staleUntil(token), time.Second)
+	})
+}
@@ -2,36 +2,72 @@ package client
 
 import (
 	"context"
+	"productservice/config"
 	"productservice/internal/grpc/pb/userservicepb"
 
 	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// maxBatchUsers khớp với giới hạn số id của BatchGetUsers ở userservice
+const maxBatchUsers = 100
+
 type AuthClient struct {
-	client userservicepb.UserServiceClient
+	client       userservicepb.UserServiceClient
+	cache        *AuthCache
+	serviceToken string
 }
 
-func NewAuthClient(conn *grpc.ClientConn) *AuthClient {
+func NewAuthClient(conn *grpc.ClientConn, cache *AuthCache, cfg *config.Config) *AuthClient {
 	return &AuthClient{
-		client: userservicepb.NewUserServiceClient(conn),
+		client:       userservicepb.NewUserServiceClient(conn),
+		cache:        cache,
+		serviceToken: cfg.Server.Grpc.Auth.ServiceToken,
 	}
 }
 
 func (a *AuthClient) Authenticate(ctx context.Context, token string) (*userservicepb.AuthResponse, error) {
-	request := &userservicepb.AuthRequest{
-		Token: token,
+	if a.cache == nil {
+		return a.authenticate(ctx, token)
+	}
+	return a.cache.Fetch(ctx, token, a.authenticate)
+}
+
+// AuthenticateAPIKey xác thực API key (scheme "ApiKey"), chỉ userservice kiểm tra được nên luôn qua gRPC
+func (a *AuthClient) AuthenticateAPIKey(ctx context.Context, apiKey string) (*userservicepb.AuthResponse, error) {
+	if a.cache == nil {
+		return a.authenticateAPIKey(ctx, apiKey)
+	}
+	return a.cache.Fetch(ctx, apiKey, a.authenticateAPIKey)
+}
+
+// CachedIdentity trả về kết quả xác thực cũ của token hoặc API key cho chế độ degraded,
+// false nếu tắt cache hoặc không còn entry nào dùng được
+func (a *AuthClient) CachedIdentity(token string) (*userservicepb.AuthResponse, bool) {
+	if a.cache == nil {
+		return nil, false
 	}
+	return a.cache.Stale(token)
+}
+
+func (a *AuthClient) authenticate(ctx context.Context, token string) (*userservicepb.AuthResponse, error) {
+	return a.call(ctx, &userservicepb.AuthRequest{Token: token})
+}
 
+func (a *AuthClient) authenticateAPIKey(ctx context.Context, apiKey string) (*userservicepb.AuthResponse, error) {
+	return a.call(ctx, &userservicepb.AuthRequest{ApiKey: apiKey})
+}
+
+func (a *AuthClient) call(ctx context.Context, request *userservicepb.AuthRequest) (*userservicepb.AuthResponse, error) {
 	resp, err := a.client.Authenticate(ctx, request)
 	if err != nil {
 		log.Error(err)
 		return nil, err
 	}
 	return resp, nil
-
 }
 
 func (a *AuthClient) GetCurrentUserInfo(ctx context.Context, token string) (*userservicepb.UserResponse, error) {
@@ -51,3 +87,51 @@ func (a *AuthClient) GetCurrentUserInfo(ctx context.Context, token string) (*use
 
 	return response, nil
 }
+
+// GetUserById lấy thông tin công khai của một user bằng service token, không cần token của user
+func (a *AuthClient) GetUserById(ctx context.Context, id uuid.UUID) (*userservicepb.PublicUser, error) {
+	response, err := a.client.GetUserById(a.serviceContext(ctx), &userservicepb.GetUserByIdRequest{Id: id.String()})
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	return response, nil
+}
+
+// BatchGetUsers trả về tên theo id, id không tồn tại thì không có trong map.
+// Mỗi lần gọi tối đa maxBatchUsers id nên một trang đánh giá chỉ tốn một round trip.
+func (a *AuthClient) BatchGetUsers(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	names := make(map[uuid.UUID]string, len(ids))
+	ctx = a.serviceContext(ctx)
+	for start := 0; start < len(ids); start += maxBatchUsers {
+		end := min(start+maxBatchUsers, len(ids))
+		request := &userservicepb.BatchGetUsersRequest{Ids: make([]string, 0, end-start)}
+		for _, id := range ids[start:end] {
+			request.Ids = append(request.Ids, id.String())
+		}
+
+		response, err := a.client.BatchGetUsers(ctx, request)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		for _, user := range response.GetUsers() {
+			if id, err := uuid.Parse(user.GetId()); err == nil {
+				names[id] = user.GetName()
+			}
+		}
+	}
+	return names, nil
+}
+
+func (a *AuthClient) serviceContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "x-service-token", a.serviceToken)
+}
+
+// WatchUserEvents mở stream sự kiện vòng đời user, afterSeq là seq cuối cùng đã xử lý
+func (a *AuthClient) WatchUserEvents(ctx context.Context, afterSeq int64, fromNow bool) (grpc.ServerStreamingClient[userservicepb.UserEvent], error) {
+	return a.client.WatchUserEvents(a.serviceContext(ctx), &userservicepb.WatchUserEventsRequest{
+		AfterSeq: afterSeq,
+		FromNow:  fromNow,
+	})
+}
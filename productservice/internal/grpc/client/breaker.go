@@ -0,0 +1,206 @@
+package client
+
+import (
+	"context"
+	"expvar"
+	"productservice/config"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultBreakerOpenTimeout = 10 * time.Second
+
+// ErrCircuitOpen được trả về ngay, không gọi userservice, khi mạch đang mở
+var ErrCircuitOpen = status.Error(codes.Unavailable, "userservice circuit breaker is open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreakerStats được publish qua expvar với tên "auth_breaker"
+type CircuitBreakerStats struct {
+	State               string `json:"state"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Opens               int64  `json:"opens"`
+	Rejected            int64  `json:"rejected"`
+	Successes           int64  `json:"successes"`
+	Failures            int64  `json:"failures"`
+}
+
+// CircuitBreaker mở mạch sau threshold lần gọi lỗi liên tiếp (UNAVAILABLE hoặc hết deadline).
+// Khi mạch mở mọi lần gọi unary thất bại ngay với ErrCircuitOpen, hết openTimeout thì cho
+// đúng một lần gọi thử: thành công thì đóng mạch, lỗi thì mở lại.
+// Lỗi nghiệp vụ như UNAUTHENTICATED hay NOT_FOUND nghĩa là userservice vẫn hoạt động.
+type CircuitBreaker struct {
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	opens     int64
+	rejected  int64
+	successes int64
+	total     int64
+}
+
+// NewCircuitBreaker trả về nil khi server.grpc.auth.breaker.failure_threshold bằng 0
+func NewCircuitBreaker(cfg *config.Config) *CircuitBreaker {
+	breakerCfg := cfg.Server.Grpc.Auth.Breaker
+	if breakerCfg.FailureThreshold <= 0 {
+		log.Info("userservice circuit breaker is disabled")
+		return nil
+	}
+
+	b := newCircuitBreaker(breakerCfg.FailureThreshold, breakerCfg.OpenTimeout)
+	if expvar.Get("auth_breaker") == nil {
+		expvar.Publish("auth_breaker", expvar.Func(func() any { return b.Stats() }))
+	}
+	return b
+}
+
+func newCircuitBreaker(threshold int, openTimeout time.Duration) *CircuitBreaker {
+	if openTimeout <= 0 {
+		openTimeout = defaultBreakerOpenTimeout
+	}
+	return &CircuitBreaker{threshold: threshold, openTimeout: openTimeout, now: time.Now}
+}
+
+// UnaryClientInterceptor phải nằm ngoài interceptor đặt deadline để phân biệt
+// request bị client hủy với userservice không phản hồi
+func (b *CircuitBreaker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !b.allow() {
+			return ErrCircuitOpen
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err != nil && ctx.Err() != nil {
+			// request bị hủy từ phía gọi, không nói lên gì về userservice
+			b.release()
+			return err
+		}
+		b.record(IsUnavailable(err))
+		return err
+	}
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *CircuitBreaker) Stats() CircuitBreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return CircuitBreakerStats{
+		State:               b.state.String(),
+		ConsecutiveFailures: b.failures,
+		Opens:               b.opens,
+		Rejected:            b.rejected,
+		Successes:           b.successes,
+		Failures:            b.total,
+	}
+}
+
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			b.rejected++
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			b.rejected++
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *CircuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.successes++
+		b.failures = 0
+		if b.state != BreakerClosed {
+			log.Info("userservice circuit breaker closed")
+		}
+		b.state = BreakerClosed
+		return
+	}
+
+	b.total++
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		if b.state != BreakerOpen {
+			b.opens++
+			log.Warnf("userservice circuit breaker opened after %d consecutive failures", b.failures)
+		}
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// release trả lại lượt thử ở trạng thái half-open khi không biết kết quả
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// IsUnavailable cho biết lỗi đến từ việc không gọi được userservice chứ không phải từ kết quả xác thực
+func IsUnavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// CallTimeout đặt deadline cho mỗi lần gọi unary, deadline sẵn có của ctx sớm hơn thì giữ nguyên
+func CallTimeout(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
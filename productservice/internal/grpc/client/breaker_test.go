@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invokeWith gọi interceptor của breaker với invoker trả về err
+func invokeWith(ctx context.Context, b *CircuitBreaker, err error) (bool, error) {
+	called := false
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		called = true
+		return err
+	}
+	err = b.UnaryClientInterceptor()(ctx, "/user.UserService/Authenticate", nil, nil, nil, invoker)
+	return called, err
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	now := time.Now()
+	newBreaker := func() *CircuitBreaker {
+		b := newCircuitBreaker(3, 10*time.Second)
+		b.now = func() time.Time { return now }
+		return b
+	}
+
+	t.Run("Success - Opens After Consecutive Failures", func(t *testing.T) {
+		b := newBreaker()
+		for i := 0; i < 3; i++ {
+			invokeWith(context.Background(), b, unavailable)
+		}
+		assert.Equal(t, BreakerOpen, b.State())
+
+		called, err := invokeWith(context.Background(), b, nil)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.False(t, called)
+		assert.True(t, IsUnavailable(err))
+
+		stats := b.Stats()
+		assert.Equal(t, int64(1), stats.Opens)
+		assert.Equal(t, int64(1), stats.Rejected)
+		assert.Equal(t, "open", stats.State)
+	})
+
+	t.Run("Success - Business Errors Keep Circuit Closed", func(t *testing.T) {
+		b := newBreaker()
+		invokeWith(context.Background(), b, unavailable)
+		invokeWith(context.Background(), b, unavailable)
+		invokeWith(context.Background(), b, status.Error(codes.Unauthenticated, "invalid token"))
+		invokeWith(context.Background(), b, unavailable)
+
+		assert.Equal(t, BreakerClosed, b.State())
+		assert.Equal(t, 1, b.Stats().ConsecutiveFailures)
+	})
+
+	t.Run("Success - Canceled Calls Not Counted", func(t *testing.T) {
+		b := newBreaker()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		for i := 0; i < 5; i++ {
+			invokeWith(ctx, b, status.Error(codes.Canceled, "context canceled"))
+			invokeWith(ctx, b, status.Error(codes.DeadlineExceeded, "deadline exceeded"))
+		}
+		assert.Equal(t, BreakerClosed, b.State())
+	})
+
+	t.Run("Success - Half Open Probe", func(t *testing.T) {
+		b := newBreaker()
+		for i := 0; i < 3; i++ {
+			invokeWith(context.Background(), b, unavailable)
+		}
+		now = now.Add(10 * time.Second)
+
+		// lượt thử đầu tiên lỗi thì mở lại mạch
+		called, _ := invokeWith(context.Background(), b, unavailable)
+		assert.True(t, called)
+		assert.Equal(t, BreakerOpen, b.State())
+
+		now = now.Add(10 * time.Second)
+		assert.True(t, b.allow())
+		assert.Equal(t, BreakerHalfOpen, b.State())
+		// mỗi lúc chỉ có một lượt thử
+		assert.False(t, b.allow())
+		b.record(false)
+		assert.Equal(t, BreakerClosed, b.State())
+		assert.Equal(t, int64(2), b.Stats().Opens)
+	})
+
+	t.Run("Success - Released Probe", func(t *testing.T) {
+		b := newBreaker()
+		for i := 0; i < 3; i++ {
+			invokeWith(context.Background(), b, unavailable)
+		}
+		now = now.Add(10 * time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		invokeWith(ctx, b, errors.New("context canceled"))
+
+		assert.True(t, b.allow())
+	})
+}
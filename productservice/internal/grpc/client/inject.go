@@ -2,4 +2,4 @@ package client
 
 import "github.com/google/wire"
 
-var Set = wire.NewSet(NewAuthClient)
+var Set = wire.NewSet(NewAuthCache, NewAuthClient, NewCircuitBreaker)
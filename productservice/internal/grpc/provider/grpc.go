@@ -1,32 +1,79 @@
 package provider
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"productservice/config"
+	"productservice/internal/grpc/client"
+	"productservice/internal/grpc/pb/userservicepb"
+	"productservice/internal/grpc/tlsreload"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
 )
 
-func ProvideGRPCConnection(cfg *config.Config) (*grpc.ClientConn, func(), error) {
+const defaultCallTimeout = 2 * time.Second
+
+func ProvideGRPCConnection(cfg *config.Config, breaker *client.CircuitBreaker) (*grpc.ClientConn, func(), error) {
+	authCfg := cfg.Server.Grpc.Auth
+
+	serviceConfig, err := buildServiceConfig(authCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timeout := authCfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultCallTimeout
+	}
+	// breaker nằm ngoài để thấy lỗi của cả chuỗi retry, deadline tính cho mọi lần retry
+	var interceptors []grpc.UnaryClientInterceptor
+	if breaker != nil {
+		interceptors = append(interceptors, breaker.UnaryClientInterceptor())
+	}
+	interceptors = append(interceptors, client.CallTimeout(timeout))
+
+	creds, err := transportCredentials(authCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithChainUnaryInterceptor(interceptors...),
+	}
+
 	// Lấy địa chỉ từ config
-	authServiceAddr := cfg.Server.Grpc.Auth.Host + ":" + cfg.Server.Grpc.Auth.Port
-	if authServiceAddr == "" {
-		authServiceAddr = "localhost:9005"
+	target := authCfg.Host + ":" + authCfg.Port
+	if len(authCfg.Addresses) > 0 {
+		// danh sách tĩnh nhiều instance, instance lỗi bị bỏ qua tới khi kết nối lại được
+		r := manual.NewBuilderWithScheme("userservice")
+		state := resolver.State{}
+		for _, addr := range authCfg.Addresses {
+			state.Addresses = append(state.Addresses, resolver.Address{Addr: addr})
+		}
+		r.InitialState(state)
+		opts = append(opts, grpc.WithResolvers(r))
+		target = r.Scheme() + ":///userservice"
+	} else if target == ":" {
+		target = "localhost:9005"
 	}
 
-	log.Printf("Connecting to Product Service at: %s", authServiceAddr)
+	log.Printf("Connecting to User Service at: %s %v", target, authCfg.Addresses)
 
-	conn, err := grpc.NewClient(
-		authServiceAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
-		log.Printf("Failed to connect to Product Service: %v", err)
+		log.Printf("Failed to connect to User Service: %v", err)
 		return nil, nil, err
 	}
 
-	log.Printf("Successfully connected to Product Service")
+	log.Printf("Successfully connected to User Service")
 
 	// Cleanup function
 	cleanup := func() {
@@ -39,3 +86,95 @@ func ProvideGRPCConnection(cfg *config.Config) (*grpc.ClientConn, func(), error)
 
 	return conn, cleanup, nil
 }
+
+// transportCredentials dùng TLS khi có tls.ca_file, gửi thêm chứng chỉ client khi có tls.cert_file (mTLS)
+func transportCredentials(authCfg config.AuthGrpc) (credentials.TransportCredentials, error) {
+	tlsCfg := authCfg.TLS
+	if tlsCfg.CAFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	reloader, err := tlsreload.New(tlsreload.Files{
+		CertFile: tlsCfg.CertFile,
+		KeyFile:  tlsCfg.KeyFile,
+		CAFile:   tlsCfg.CAFile,
+	}, tlsCfg.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+	serverName := tlsCfg.ServerName
+	if serverName == "" {
+		serverName = authCfg.Host
+	}
+	log.Printf("gRPC TLS enabled for User Service %s (mTLS: %t)", serverName, tlsCfg.CertFile != "")
+	return credentials.NewTLS(reloader.ClientConfig(serverName)), nil
+}
+
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig"`
+	MethodConfig        []methodConfig        `json:"methodConfig"`
+	RetryThrottling     *retryThrottling      `json:"retryThrottling,omitempty"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type methodName struct {
+	Service string `json:"service"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+// retryThrottling ngừng retry khi phần lớn lần gọi đều lỗi, tránh dồn thêm tải lên userservice đang quá tải
+type retryThrottling struct {
+	MaxTokens  int     `json:"maxTokens"`
+	TokenRatio float64 `json:"tokenRatio"`
+}
+
+// buildServiceConfig tạo service config cho UserService. gRPC chọn thời gian chờ giữa các lần
+// retry ngẫu nhiên trong khoảng [0, backoff) nên các instance không retry cùng lúc.
+// Chỉ retry UNAVAILABLE vì khi đó request chưa tới được userservice.
+func buildServiceConfig(authCfg config.AuthGrpc) (string, error) {
+	sc := serviceConfig{
+		LoadBalancingConfig: []map[string]struct{}{{"round_robin": {}}},
+		MethodConfig: []methodConfig{{
+			Name: []methodName{{Service: userservicepb.UserService_ServiceDesc.ServiceName}},
+		}},
+	}
+
+	retry := authCfg.Retry
+	if retry.MaxAttempts >= 2 {
+		initialBackoff := retry.InitialBackoff
+		if initialBackoff <= 0 {
+			initialBackoff = 100 * time.Millisecond
+		}
+		maxBackoff := max(retry.MaxBackoff, initialBackoff)
+		sc.MethodConfig[0].RetryPolicy = &retryPolicy{
+			MaxAttempts:          retry.MaxAttempts,
+			InitialBackoff:       durationString(initialBackoff),
+			MaxBackoff:           durationString(maxBackoff),
+			BackoffMultiplier:    2,
+			RetryableStatusCodes: []string{"UNAVAILABLE"},
+		}
+		sc.RetryThrottling = &retryThrottling{MaxTokens: 10, TokenRatio: 0.1}
+	}
+
+	data, err := json.Marshal(sc)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// durationString theo định dạng của google.protobuf.Duration trong JSON, vd "0.1s"
+func durationString(d time.Duration) string {
+	return fmt.Sprintf("%gs", d.Seconds())
+}
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"productservice/config"
+	"productservice/internal/grpc/client"
+	"productservice/internal/grpc/pb/userservicepb"
+	"productservice/internal/grpc/tlsreload"
+	"productservice/internal/grpc/tlsreload/tlstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// flakyUserService trả về UNAVAILABLE cho failures lần gọi đầu tiên
+type flakyUserService struct {
+	userservicepb.UnimplementedUserServiceServer
+	failures int64
+	code     codes.Code
+	calls    atomic.Int64
+}
+
+func (f *flakyUserService) Authenticate(ctx context.Context, request *userservicepb.AuthRequest) (*userservicepb.AuthResponse, error) {
+	if f.calls.Add(1) <= f.failures {
+		return nil, status.Error(f.code, "flaky")
+	}
+	return &userservicepb.AuthResponse{Valid: true, UserId: "user-1"}, nil
+}
+
+func newTestConn(t *testing.T, users *flakyUserService, authCfg config.AuthGrpc) userservicepb.UserServiceClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	userservicepb.RegisterUserServiceServer(server, users)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	serviceConfig, err := buildServiceConfig(authCfg)
+	require.NoError(t, err)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithChainUnaryInterceptor(client.CallTimeout(time.Second)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return userservicepb.NewUserServiceClient(conn)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	retry := config.AuthGrpc{Retry: config.RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}}
+
+	t.Run("Success - Retries Unavailable", func(t *testing.T) {
+		users := &flakyUserService{failures: 2, code: codes.Unavailable}
+		userClient := newTestConn(t, users, retry)
+
+		resp, err := userClient.Authenticate(context.Background(), &userservicepb.AuthRequest{Token: "token"})
+
+		require.NoError(t, err)
+		assert.True(t, resp.Valid)
+		assert.Equal(t, int64(3), users.calls.Load())
+	})
+
+	t.Run("Error - Bounded Attempts", func(t *testing.T) {
+		users := &flakyUserService{failures: 10, code: codes.Unavailable}
+		userClient := newTestConn(t, users, retry)
+
+		_, err := userClient.Authenticate(context.Background(), &userservicepb.AuthRequest{Token: "token"})
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, int64(3), users.calls.Load())
+	})
+
+	t.Run("Error - Other Codes Not Retried", func(t *testing.T) {
+		users := &flakyUserService{failures: 1, code: codes.Internal}
+		userClient := newTestConn(t, users, retry)
+
+		_, err := userClient.Authenticate(context.Background(), &userservicepb.AuthRequest{Token: "token"})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, int64(1), users.calls.Load())
+	})
+
+	t.Run("Error - Retry Disabled", func(t *testing.T) {
+		users := &flakyUserService{failures: 1, code: codes.Unavailable}
+		userClient := newTestConn(t, users, config.AuthGrpc{})
+
+		_, err := userClient.Authenticate(context.Background(), &userservicepb.AuthRequest{Token: "token"})
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, int64(1), users.calls.Load())
+	})
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca, err := tlstest.NewCA("agris-test-ca")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	caFile, err := ca.WriteFile(dir, "ca")
+	require.NoError(t, err)
+	issue := func(name string) (string, string) {
+		cert, err := ca.Issue(name)
+		require.NoError(t, err)
+		certFile, keyFile, err := cert.WriteFiles(dir, name)
+		require.NoError(t, err)
+		return certFile, keyFile
+	}
+
+	// userservice yêu cầu chứng chỉ client do cùng CA ký
+	serverCert, serverKey := issue("user-service")
+	serverTLS, err := tlsreload.New(tlsreload.Files{CertFile: serverCert, KeyFile: serverKey, CAFile: caFile}, time.Minute)
+	require.NoError(t, err)
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(serverTLS.ServerConfig())))
+	userservicepb.RegisterUserServiceServer(server, &flakyUserService{})
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	dial := func(t *testing.T, tlsCfg config.ClientTLSConfig) error {
+		creds, err := transportCredentials(config.AuthGrpc{Host: "user-service", TLS: tlsCfg})
+		require.NoError(t, err)
+		conn, err := grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(creds),
+		)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = userservicepb.NewUserServiceClient(conn).Authenticate(context.Background(), &userservicepb.AuthRequest{Token: "token"})
+		return err
+	}
+	clientCert, clientKey := issue("productservice")
+
+	t.Run("Success - Client Certificate", func(t *testing.T) {
+		err := dial(t, config.ClientTLSConfig{CAFile: caFile, CertFile: clientCert, KeyFile: clientKey})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Error - Missing Client Certificate", func(t *testing.T) {
+		err := dial(t, config.ClientTLSConfig{CAFile: caFile})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("Error - Server Name Mismatch", func(t *testing.T) {
+		err := dial(t, config.ClientTLSConfig{CAFile: caFile, CertFile: clientCert, KeyFile: clientKey, ServerName: "other-service"})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"productservice/internal/dto"
+	"productservice/internal/service"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CategoryHandler struct {
+	categoryService service.CategoryService
+}
+
+func NewCategoryHandler(categoryService service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService}
+}
+
+func (h *CategoryHandler) GetTree(ctx *fiber.Ctx) error {
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	tree, errTree := h.categoryService.GetTree(ct)
+	if errTree != nil {
+		return ctx.Status(errTree.Status).JSON(fiber.Map{
+			"error": errTree.Err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": tree,
+	})
+}
+
+func (h *CategoryHandler) GetCategoryById(ctx *fiber.Ctx) error {
+	categoryUuid, err := uuid.Parse(ctx.Params("categoryId"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	category, errCategory := h.categoryService.GetCategoryById(ct, categoryUuid)
+	if errCategory != nil {
+		return ctx.Status(errCategory.Status).JSON(fiber.Map{
+			"error": errCategory.Err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(category)
+}
+
+func (h *CategoryHandler) CreateCategory(ctx *fiber.Ctx) error {
+	var request dto.CategoryRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	category, errCategory := h.categoryService.CreateCategory(ct, &request)
+	if errCategory != nil {
+		return ctx.Status(errCategory.Status).JSON(fiber.Map{
+			"error": errCategory.Err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(category)
+}
+
+func (h *CategoryHandler) UpdateCategory(ctx *fiber.Ctx) error {
+	categoryUuid, err := uuid.Parse(ctx.Params("categoryId"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	var request dto.CategoryRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	category, errCategory := h.categoryService.UpdateCategory(ct, categoryUuid, &request)
+	if errCategory != nil {
+		return ctx.Status(errCategory.Status).JSON(fiber.Map{
+			"error": errCategory.Err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(category)
+}
+
+// DeleteCategory nhận query reassign_to để chuyển sản phẩm của danh mục bị xóa sang danh mục khác
+func (h *CategoryHandler) DeleteCategory(ctx *fiber.Ctx) error {
+	categoryUuid, err := uuid.Parse(ctx.Params("categoryId"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	var reassignTo *uuid.UUID
+	if value := ctx.Query("reassign_to"); value != "" {
+		target, err := uuid.Parse(value)
+		if err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"error": ErrInvalidData.Error(),
+			})
+		}
+		reassignTo = &target
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if errCategory := h.categoryService.DeleteCategory(ct, categoryUuid, reassignTo); errCategory != nil {
+		return ctx.Status(errCategory.Status).JSON(fiber.Map{
+			"error": errCategory.Err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusNoContent).JSON(fiber.Map{})
+}
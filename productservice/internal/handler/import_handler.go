@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"productservice/internal/dto"
+	"productservice/internal/service"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ImportHandler struct {
+	importer *service.ProductImporter
+}
+
+func NewImportHandler(importer *service.ProductImporter) *ImportHandler {
+	return &ImportHandler{importer: importer}
+}
+
+// ImportProducts nhận file ở field "file" của multipart form, format lấy từ query format hoặc đuôi file.
+// Trả về 202 cùng job, client hỏi tiến độ qua GetImportJob.
+func (h *ImportHandler) ImportProducts(ctx *fiber.Ctx) error {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+	defer file.Close()
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 30*time.Second)
+	defer cancel()
+
+	job, errJob := h.importer.Start(ct, file, &dto.ImportRequest{
+		Format: dto.ImportFormat(ctx.Query("format")),
+		Source: fileHeader.Filename,
+	})
+	if errJob != nil {
+		return ctx.Status(errJob.Status).JSON(fiber.Map{
+			"error": errJob.Err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(job)
+}
+
+func (h *ImportHandler) GetImportJob(ctx *fiber.Ctx) error {
+	jobUuid, err := uuid.Parse(ctx.Params("jobId"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	job, errJob := h.importer.GetJob(ct, jobUuid)
+	if errJob != nil {
+		return ctx.Status(errJob.Status).JSON(fiber.Map{
+			"error": errJob.Err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(job)
+}
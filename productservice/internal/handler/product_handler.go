@@ -150,3 +150,121 @@ func (h *ProductHandler) GetListProduct(ctx *fiber.Ctx) error {
 
 	return ctx.Status(fiber.StatusOK).JSON(response)
 }
+
+func (h *ProductHandler) CreateProduct(ctx *fiber.Ctx) error {
+	var request dto.ProductRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	product, errProduct := h.productService.CreateProduct(ct, &request)
+	if errProduct != nil {
+		return ctx.Status(errProduct.Status).JSON(fiber.Map{
+			"error": errProduct.Err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(product)
+}
+
+func (h *ProductHandler) ReplaceProduct(ctx *fiber.Ctx) error {
+	productUuid, err := uuid.Parse(ctx.Params("productId"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	var request dto.ProductRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	product, errProduct := h.productService.ReplaceProduct(ct, productUuid, &request)
+	if errProduct != nil {
+		return ctx.Status(errProduct.Status).JSON(fiber.Map{
+			"error": errProduct.Err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(product)
+}
+
+func (h *ProductHandler) PatchProduct(ctx *fiber.Ctx) error {
+	productUuid, err := uuid.Parse(ctx.Params("productId"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	var request dto.PatchProductRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	product, errProduct := h.productService.PatchProduct(ct, productUuid, &request)
+	if errProduct != nil {
+		return ctx.Status(errProduct.Status).JSON(fiber.Map{
+			"error": errProduct.Err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(product)
+}
+
+func (h *ProductHandler) DeleteProduct(ctx *fiber.Ctx) error {
+	productUuid, err := uuid.Parse(ctx.Params("productId"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if errProduct := h.productService.DeleteProduct(ct, productUuid); errProduct != nil {
+		return ctx.Status(errProduct.Status).JSON(fiber.Map{
+			"error": errProduct.Err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusNoContent).JSON(fiber.Map{})
+}
+
+func (h *ProductHandler) RestoreProduct(ctx *fiber.Ctx) error {
+	productUuid, err := uuid.Parse(ctx.Params("productId"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData.Error(),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	product, errProduct := h.productService.RestoreProduct(ct, productUuid)
+	if errProduct != nil {
+		return ctx.Status(errProduct.Status).JSON(fiber.Map{
+			"error": errProduct.Err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(product)
+}
@@ -125,7 +125,7 @@ func (h *RateHandler) DeleteRateProduct(ctx *fiber.Ctx) error {
 
 	err := h.rateService.DeleteRatingProduct(ct, ratingUuid)
 	if err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		return ctx.Status(err.Status).JSON(fiber.Map{
 			"error": err.Err.Error(),
 		})
 	}
@@ -0,0 +1,5 @@
+package metrics
+
+import "github.com/google/wire"
+
+var Set = wire.NewSet(NewServer)
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net"
+	"net/http"
+	"productservice/config"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Server phục vụ GET /debug/vars (cmdline, memstats, auth_cache, auth_breaker) trên listener riêng.
+// Không đi qua app public nên Traefik không chuyển request tới được.
+type Server struct {
+	server *http.Server
+	lis    net.Listener
+}
+
+// NewServer không làm gì khi server.metrics_addr để trống
+func NewServer(cfg *config.Config) (*Server, func(), error) {
+	addr := cfg.Server.MetricsAddr
+	if addr == "" {
+		return nil, func() {}, nil
+	}
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	s := &Server{
+		server: &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second},
+		lis:    lis,
+	}
+
+	go func() {
+		log.Infof("Metrics server listening on %s", lis.Addr())
+		if err := s.server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("Metrics server: %v", err)
+		}
+	}()
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := s.server.Shutdown(ctx); err != nil {
+			log.Errorf("Metrics server forced to shutdown: %v", err)
+		}
+	}
+	return s, cleanup, nil
+}
+
+// Addr là địa chỉ thực tế đang lắng nghe, dùng khi metrics_addr có port 0
+func (s *Server) Addr() net.Addr {
+	return s.lis.Addr()
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"productservice/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsServer(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.MetricsAddr = "127.0.0.1:0"
+	server, cleanup, err := NewServer(cfg)
+	require.NoError(t, err)
+	defer cleanup()
+
+	resp, err := http.Get("http://" + server.Addr().String() + "/debug/vars")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "memstats")
+
+	t.Run("Disabled Without Address", func(t *testing.T) {
+		server, cleanup, err := NewServer(&config.Config{})
+		require.NoError(t, err)
+		cleanup()
+		assert.Nil(t, server)
+	})
+}
@@ -1,17 +1,36 @@
 package middleware
 
 import (
-	"github.com/gofiber/fiber/v2"
+	"context"
+	"errors"
+	"productservice/config"
+	"productservice/internal/auth"
 	"productservice/internal/grpc/client"
+	"productservice/internal/grpc/pb/userservicepb"
 	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
 )
 
 type AuthMiddleware struct {
-	authClient *client.AuthClient
+	authClient          *client.AuthClient
+	verifier            *auth.Verifier
+	revocationCheck     bool
+	requireVerifiedRate bool
+	requireMFAForAdmin  bool
+	degradedReadOnly    bool
 }
 
-func NewAuthMiddleware(authClient *client.AuthClient) *AuthMiddleware {
-	return &AuthMiddleware{authClient: authClient}
+func NewAuthMiddleware(authClient *client.AuthClient, verifier *auth.Verifier, cfg *config.Config) *AuthMiddleware {
+	return &AuthMiddleware{
+		authClient:          authClient,
+		verifier:            verifier,
+		revocationCheck:     cfg.Auth.RevocationCheck,
+		requireVerifiedRate: cfg.Auth.RequireVerifiedEmailForRating,
+		requireMFAForAdmin:  cfg.Auth.RequireMFAForAdmin,
+		degradedReadOnly:    cfg.Auth.DegradedMode == config.DegradedModeCachedReadOnly,
+	}
 }
 
 func (am *AuthMiddleware) Handler() fiber.Handler {
@@ -24,6 +43,9 @@ func (am *AuthMiddleware) Handler() fiber.Handler {
 		}
 
 		parts := strings.Split(authHeader, " ")
+		if len(parts) == 2 && parts[0] == "ApiKey" {
+			return am.authenticateAPIKey(c, parts[1])
+		}
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": ErrTokenInvalid,
@@ -32,17 +54,164 @@ func (am *AuthMiddleware) Handler() fiber.Handler {
 
 		token := parts[1]
 
-		resp, err := am.authClient.Authenticate(c.Context(), token)
-		if err != nil || !resp.Valid {
+		// Xác thực tại chỗ bằng JWKS, chỉ gọi gRPC khi không biết khóa ký
+		claims, err := am.verifier.Verify(token)
+		if errors.Is(err, auth.ErrUnknownKey) {
+			return am.authenticateRemote(c, token)
+		}
+		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": ErrAuth,
 			})
 		}
 
-		c.Locals("userId", resp.UserId)
-		c.Locals("role", resp.Role)
+		// Kiểm tra thu hồi với mọi method. Kết quả Authenticate được cache và bị xóa ngay khi có sự kiện
+		// auth:revocations nên phần lớn request không tốn thêm round trip. userservice không phản hồi thì
+		// xử lý như xác thực qua gRPC (request chỉ đọc dùng kết quả đã cache ở chế độ degraded), không coi token là hợp lệ.
+		if am.revocationCheck {
+			resp, err := am.callUserService(c, token, am.authClient.Authenticate)
+			if err != nil {
+				return am.rejectRemote(c, err)
+			}
+			if !resp.Valid {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": ErrAuth,
+				})
+			}
+		}
+
+		c.Locals("userId", claims.UserID)
+		c.Locals("role", claims.Role)
+		c.Locals("name", claims.Name)
+		c.Locals("emailVerified", claims.EmailVerified)
+		c.Locals("permissions", claims.Permissions)
+		c.Locals("mfa", claims.MFA)
 		c.Locals("token", token)
 
 		return c.Next()
 	}
 }
+
+// RequirePermission yêu cầu token có đủ mọi quyền được liệt kê.
+// Khi auth.require_mfa_for_admin bật, admin chưa xác thực MFA bị từ chối dù token có quyền.
+// Phải đặt sau Handler().
+func (am *AuthMiddleware) RequirePermission(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		mfa, _ := c.Locals("mfa").(bool)
+		if am.requireMFAForAdmin && role == auth.RoleAdmin && !mfa {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": ErrMFARequired,
+			})
+		}
+
+		granted, _ := c.Locals("permissions").([]string)
+		for _, permission := range permissions {
+			if !auth.HasPermission(granted, permission) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": ErrPermission,
+				})
+			}
+		}
+		return c.Next()
+	}
+}
+
+// RequireVerifiedEmailForRating chặn tài khoản chưa xác minh email khi auth.require_verified_email_for_rating bật.
+// Phải đặt sau Handler().
+func (am *AuthMiddleware) RequireVerifiedEmailForRating() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !am.requireVerifiedRate {
+			return c.Next()
+		}
+
+		verified, _ := c.Locals("emailVerified").(bool)
+		if !verified {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": ErrEmailVerify,
+			})
+		}
+		return c.Next()
+	}
+}
+
+func (am *AuthMiddleware) authenticateRemote(c *fiber.Ctx, token string) error {
+	resp, err := am.callUserService(c, token, am.authClient.Authenticate)
+	if err != nil {
+		return am.rejectRemote(c, err)
+	}
+	if !resp.Valid {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": ErrAuth,
+		})
+	}
+
+	c.Locals("userId", resp.UserId)
+	c.Locals("role", resp.Role)
+	c.Locals("permissions", resp.Permissions)
+	c.Locals("mfa", resp.Mfa)
+	c.Locals("token", token)
+
+	// token đã được userservice xác nhận nên có thể đọc thêm các claim khác
+	if claims, err := auth.ParseUnverified(token); err == nil {
+		c.Locals("name", claims.Name)
+		c.Locals("emailVerified", claims.EmailVerified)
+	}
+
+	return c.Next()
+}
+
+// authenticateAPIKey hỏi userservice vì API key không tự mang chữ ký như JWT.
+// Không gắn Locals "token" vì API key không dùng được cho GetCurrentUserInfo.
+func (am *AuthMiddleware) authenticateAPIKey(c *fiber.Ctx, apiKey string) error {
+	resp, err := am.callUserService(c, apiKey, am.authClient.AuthenticateAPIKey)
+	if err != nil {
+		return am.rejectRemote(c, err)
+	}
+	if !resp.Valid {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": ErrAuth,
+		})
+	}
+
+	c.Locals("userId", resp.UserId)
+	c.Locals("role", resp.Role)
+	c.Locals("permissions", resp.Permissions)
+	c.Locals("mfa", resp.Mfa)
+	c.Locals("name", resp.Name)
+	c.Locals("emailVerified", resp.EmailVerified)
+
+	return c.Next()
+}
+
+// callUserService xác thực qua userservice. Khi userservice không phản hồi (kể cả khi circuit breaker đang mở)
+// và auth.degraded_mode là cached_read_only thì request chỉ đọc được dùng kết quả đã cache trước đó.
+func (am *AuthMiddleware) callUserService(c *fiber.Ctx, token string, authenticate func(context.Context, string) (*userservicepb.AuthResponse, error)) (*userservicepb.AuthResponse, error) {
+	resp, err := authenticate(c.Context(), token)
+	if err == nil || !client.IsUnavailable(err) || !am.degradedReadOnly || !isReadOnly(c.Method()) {
+		return resp, err
+	}
+
+	cached, ok := am.authClient.CachedIdentity(token)
+	if !ok {
+		return nil, err
+	}
+	log.Warnf("userservice unavailable, using cached identity for %s %s", c.Method(), c.Path())
+	return cached, nil
+}
+
+// rejectRemote phân biệt userservice không phản hồi (503, client có thể thử lại) với token không hợp lệ (401)
+func (am *AuthMiddleware) rejectRemote(c *fiber.Ctx, err error) error {
+	if client.IsUnavailable(err) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": ErrUnavailable,
+		})
+	}
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error": ErrAuth,
+	})
+}
+
+func isReadOnly(method string) bool {
+	return method == fiber.MethodGet || method == fiber.MethodHead || method == fiber.MethodOptions
+}
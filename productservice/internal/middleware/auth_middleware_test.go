@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"productservice/internal/auth"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPermissionApp(am *AuthMiddleware, role string, mfa bool, permissions []string) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		c.Locals("mfa", mfa)
+		c.Locals("permissions", permissions)
+		return c.Next()
+	})
+	app.Post("/products", am.RequirePermission(auth.PermProductsWrite), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestRequirePermission(t *testing.T) {
+	adminPerms := []string{auth.PermProductsWrite, auth.PermRatingsModerate}
+
+	tests := []struct {
+		name           string
+		requireMFA     bool
+		role           string
+		mfa            bool
+		permissions    []string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "Success - Admin With MFA",
+			requireMFA:     true,
+			role:           auth.RoleAdmin,
+			mfa:            true,
+			permissions:    adminPerms,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Success - MFA Not Required",
+			role:           auth.RoleAdmin,
+			permissions:    adminPerms,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Success - Non Admin Without MFA",
+			requireMFA:     true,
+			role:           "moderator",
+			permissions:    []string{auth.PermProductsWrite},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Error - Admin Without MFA",
+			requireMFA:     true,
+			role:           auth.RoleAdmin,
+			permissions:    adminPerms,
+			expectedStatus: http.StatusForbidden,
+			expectedError:  ErrMFARequired,
+		},
+		{
+			name:           "Error - Missing Permission",
+			role:           "user",
+			expectedStatus: http.StatusForbidden,
+			expectedError:  ErrPermission,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			am := &AuthMiddleware{requireMFAForAdmin: tt.requireMFA}
+			app := newPermissionApp(am, tt.role, tt.mfa, tt.permissions)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/products", nil), -1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.expectedError != "" {
+				assert.Contains(t, readBody(t, resp), tt.expectedError)
+			}
+		})
+	}
+}
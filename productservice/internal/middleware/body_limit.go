@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"productservice/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimitMiddleware giới hạn kích thước request body theo route.
+// Server bật StreamRequestBody nên body lớn hơn fiber.Config.BodyLimit không bị từ chối ngay
+// mà được đọc dần từ kết nối, middleware này chặn chúng trước khi handler đọc body.
+type BodyLimitMiddleware struct {
+	limit       int
+	uploadLimit int
+}
+
+func NewBodyLimitMiddleware(cfg *config.Config) *BodyLimitMiddleware {
+	uploadLimit := cfg.Import.MaxUploadSize
+	if uploadLimit <= 0 {
+		uploadLimit = fiber.DefaultBodyLimit
+	}
+	return &BodyLimitMiddleware{
+		limit:       fiber.DefaultBodyLimit,
+		uploadLimit: uploadLimit,
+	}
+}
+
+// Handler áp giới hạn mặc định cho mọi route, riêng POST tới uploadPaths dùng import.max_upload_size
+func (bl *BodyLimitMiddleware) Handler(uploadPaths ...string) fiber.Handler {
+	uploads := make(map[string]struct{}, len(uploadPaths))
+	for _, path := range uploadPaths {
+		uploads[path] = struct{}{}
+	}
+
+	return func(c *fiber.Ctx) error {
+		limit := bl.limit
+		if _, ok := uploads[c.Path()]; ok && c.Method() == fiber.MethodPost {
+			limit = bl.uploadLimit
+		}
+
+		req := c.Request()
+		length := req.Header.ContentLength()
+		if length > limit {
+			return tooLarge(c)
+		}
+
+		// body chunked không có Content-Length, chỉ đọc tối đa limit byte rồi mới giao cho handler
+		if length < 0 && req.IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": ErrBodyInvalid,
+				})
+			}
+			if len(body) > limit {
+				return tooLarge(c)
+			}
+			req.SetBodyStream(bytes.NewReader(body), len(body))
+		}
+
+		return c.Next()
+	}
+}
+
+// tooLarge đóng kết nối sau khi trả lỗi, phần body chưa đọc không thể dùng lại cho request kế tiếp
+func tooLarge(c *fiber.Ctx) error {
+	c.Context().SetConnectionClose()
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+		"error": ErrBodyTooLarge,
+	})
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"productservice/config"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBodyLimitApp(uploadLimit int) *fiber.App {
+	cfg := &config.Config{Import: config.ImportConfig{MaxUploadSize: uploadLimit}}
+	app := fiber.New(fiber.Config{StreamRequestBody: true, DisablePreParseMultipartForm: true})
+	app.Use(NewBodyLimitMiddleware(cfg).Handler("/imports"))
+	app.Post("/imports", func(c *fiber.Ctx) error {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+		return c.SendString(strconv.FormatInt(fileHeader.Size, 10))
+	})
+	app.Post("/products", func(c *fiber.Ctx) error {
+		return c.SendString(strconv.Itoa(len(c.Body())))
+	})
+	return app
+}
+
+func multipartBody(t *testing.T, size int) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "products.csv")
+	require.NoError(t, err)
+	_, err = part.Write(bytes.Repeat([]byte("a"), size))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestBodyLimit(t *testing.T) {
+	uploadLimit := fiber.DefaultBodyLimit * 2
+	app := newBodyLimitApp(uploadLimit)
+
+	t.Run("Success - Small Body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/products", bytes.NewReader([]byte(`{"name":"x"}`)))
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "12", readBody(t, resp))
+	})
+
+	t.Run("Error - Default Limit Outside Import", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/products", bytes.NewReader(make([]byte, fiber.DefaultBodyLimit+1)))
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+
+	t.Run("Error - Chunked Body Over Default Limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/products", io.MultiReader(bytes.NewReader(make([]byte, fiber.DefaultBodyLimit+1))))
+		req.ContentLength = -1
+		req.TransferEncoding = []string{"chunked"}
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+
+	t.Run("Success - Chunked Small Body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/products", io.MultiReader(bytes.NewReader([]byte(`{"name":"x"}`))))
+		req.ContentLength = -1
+		req.TransferEncoding = []string{"chunked"}
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "12", readBody(t, resp))
+	})
+
+	t.Run("Success - Import Above Default Limit", func(t *testing.T) {
+		size := fiber.DefaultBodyLimit + 1024
+		body, contentType := multipartBody(t, size)
+		req := httptest.NewRequest(http.MethodPost, "/imports", body)
+		req.Header.Set("Content-Type", contentType)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, strconv.Itoa(size), readBody(t, resp))
+	})
+
+	t.Run("Error - Import Above Upload Limit", func(t *testing.T) {
+		body, contentType := multipartBody(t, uploadLimit)
+		req := httptest.NewRequest(http.MethodPost, "/imports", body)
+		req.Header.Set("Content-Type", contentType)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+}
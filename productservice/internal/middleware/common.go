@@ -3,4 +3,10 @@ package middleware
 var (
 	ErrAuth         = "Tài khoản không thể xác thực"
 	ErrTokenInvalid = "Token không đúng mẫu"
+	ErrEmailVerify  = "Email chưa được xác minh"
+	ErrPermission   = "Bạn không có quyền truy cập"
+	ErrMFARequired  = "Tài khoản quản trị cần xác thực hai bước"
+	ErrUnavailable  = "Dịch vụ xác thực tạm thời không khả dụng"
+	ErrBodyTooLarge = "Dữ liệu gửi lên vượt quá kích thước cho phép"
+	ErrBodyInvalid  = "Không đọc được dữ liệu gửi lên"
 )
@@ -2,4 +2,4 @@ package middleware
 
 import "github.com/google/wire"
 
-var Set = wire.NewSet(NewAuthMiddleware, NewMiddleware)
+var Set = wire.NewSet(NewAuthMiddleware, NewBodyLimitMiddleware, NewMiddleware)
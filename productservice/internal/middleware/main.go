@@ -1,9 +1,10 @@
 package middleware
 
 type Middleware struct {
-	Auth *AuthMiddleware
+	Auth      *AuthMiddleware
+	BodyLimit *BodyLimitMiddleware
 }
 
-func NewMiddleware(auth *AuthMiddleware) *Middleware {
-	return &Middleware{Auth: auth}
+func NewMiddleware(auth *AuthMiddleware, bodyLimit *BodyLimitMiddleware) *Middleware {
+	return &Middleware{Auth: auth, BodyLimit: bodyLimit}
 }
@@ -7,11 +7,12 @@ import (
 )
 
 type Category struct {
-	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	Name        string    `gorm:"type:text;not null" json:"name"`
-	Description *string   `gorm:"type:text" json:"description"`
-	CreatedAt   time.Time `gorm:"not null" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"not null" json:"updated_at"`
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Name        string     `gorm:"type:text;not null" json:"name"`
+	Description *string    `gorm:"type:text" json:"description"`
+	ParentID    *uuid.UUID `gorm:"type:uuid" json:"parent_id"`
+	CreatedAt   time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"not null" json:"updated_at"`
 
 	// Relations
 	Products []Product `gorm:"foreignKey:CategoryID" json:"products,omitempty"`
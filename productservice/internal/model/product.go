@@ -10,6 +10,7 @@ type Product struct {
 	ID            uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
 	Name          string         `gorm:"type:text;not null" json:"name"`
 	SearchName    string         `gorm:"type:text;not null" json:"search_name"`
+	SKU           *string        `gorm:"type:text;unique" json:"sku"`
 	Description   *string        `gorm:"type:text" json:"description"`
 	Price         float64        `gorm:"type:numeric(12,2);not null" json:"price"`
 	CategoryID    uuid.UUID      `gorm:"type:uuid;not null" json:"category_id"`
@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"productservice/internal/dto"
+	"productservice/internal/model"
+	"productservice/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// categoryDescendantsSQL trả về id của các danh mục truyền vào cùng mọi danh mục con cháu.
+// Dùng UNION thay vì UNION ALL để truy vấn vẫn dừng nếu dữ liệu lỡ có vòng lặp.
+const categoryDescendantsSQL = `WITH RECURSIVE category_tree AS (
+	SELECT id FROM categories WHERE id IN ?
+	UNION
+	SELECT c.id FROM categories c INNER JOIN category_tree t ON c.parent_id = t.id
+) SELECT id FROM category_tree`
+
+type CategoryRepository interface {
+	GetTree(ctx context.Context) ([]*dto.CategoryNode, *dto.ServiceResponse)
+	GetCategoryById(ctx context.Context, categoryId uuid.UUID) (*model.Category, *dto.ServiceResponse)
+	CreateCategory(ctx context.Context, request *dto.CategoryRequest) (*model.Category, *dto.ServiceResponse)
+	UpdateCategory(ctx context.Context, categoryId uuid.UUID, request *dto.CategoryRequest) (*model.Category, *dto.ServiceResponse)
+	DeleteCategory(ctx context.Context, categoryId uuid.UUID, reassignTo *uuid.UUID) *dto.ServiceResponse
+}
+
+type categoryRepository struct {
+	db *gorm.DB
+	rd *redis.Client
+}
+
+func NewCategoryRepository(db *gorm.DB, rd *redis.Client) CategoryRepository {
+	return &categoryRepository{db: db, rd: rd}
+}
+
+// GetTree trả về các danh mục gốc kèm cây con, sắp xếp theo tên
+func (c *categoryRepository) GetTree(ctx context.Context) ([]*dto.CategoryNode, *dto.ServiceResponse) {
+	query := c.db.WithContext(ctx)
+
+	var categories []model.Category
+	if err := query.Order("name ASC").Find(&categories).Error; err != nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+
+	// Đếm sản phẩm chưa bị xóa gắn trực tiếp với từng danh mục
+	var counts []categoryCount
+	err := query.Model(&model.Product{}).
+		Select("category_id, COUNT(*) AS total").
+		Group("category_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+
+	return buildCategoryTree(categories, counts), nil
+}
+
+type categoryCount struct {
+	CategoryID uuid.UUID
+	Total      int64
+}
+
+func buildCategoryTree(categories []model.Category, counts []categoryCount) []*dto.CategoryNode {
+	nodes := make(map[uuid.UUID]*dto.CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &dto.CategoryNode{
+			ID:          category.ID,
+			Name:        category.Name,
+			Description: category.Description,
+			ParentID:    category.ParentID,
+			Children:    []*dto.CategoryNode{},
+		}
+	}
+	for _, count := range counts {
+		if node, ok := nodes[count.CategoryID]; ok {
+			node.DirectProductCount = count.Total
+		}
+	}
+
+	// categories đã sắp theo tên nên thứ tự con trong mỗi nút cũng theo tên
+	roots := []*dto.CategoryNode{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, ok := nodes[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	for _, root := range roots {
+		sumProductCount(root)
+	}
+	return roots
+}
+
+func sumProductCount(node *dto.CategoryNode) int64 {
+	node.ProductCount = node.DirectProductCount
+	for _, child := range node.Children {
+		node.ProductCount += sumProductCount(child)
+	}
+	return node.ProductCount
+}
+
+func (c *categoryRepository) GetCategoryById(ctx context.Context, categoryId uuid.UUID) (*model.Category, *dto.ServiceResponse) {
+	var category model.Category
+	if err := c.db.WithContext(ctx).Where("id = ?", categoryId).First(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &dto.ServiceResponse{
+				Status: http.StatusNotFound,
+				Err:    ErrNotFound,
+			}
+		}
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+	return &category, nil
+}
+
+func (c *categoryRepository) CreateCategory(ctx context.Context, request *dto.CategoryRequest) (*model.Category, *dto.ServiceResponse) {
+	query := c.db.WithContext(ctx)
+
+	if request.ParentID != nil {
+		if errResp := checkCategory(query, *request.ParentID); errResp != nil {
+			return nil, errResp
+		}
+	}
+
+	category := model.Category{
+		Name:        request.Name,
+		Description: emptyToNil(request.Description),
+		ParentID:    request.ParentID,
+	}
+	if err := query.Create(&category).Error; err != nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+
+	return &category, nil
+}
+
+// UpdateCategory ghi đè tên, mô tả và danh mục cha. Danh mục cha mới không được nằm trong cây con của chính nó.
+func (c *categoryRepository) UpdateCategory(ctx context.Context, categoryId uuid.UUID, request *dto.CategoryRequest) (*model.Category, *dto.ServiceResponse) {
+	query := c.db.WithContext(ctx)
+
+	category, errResp := c.GetCategoryById(ctx, categoryId)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	if request.ParentID != nil {
+		if errResp := checkCategory(query, *request.ParentID); errResp != nil {
+			return nil, errResp
+		}
+
+		var descendants []uuid.UUID
+		if err := query.Raw(categoryDescendantsSQL, []uuid.UUID{categoryId}).Scan(&descendants).Error; err != nil {
+			return nil, &dto.ServiceResponse{
+				Status: http.StatusInternalServerError,
+				Err:    ErrInternalServerError,
+			}
+		}
+		for _, id := range descendants {
+			if id == *request.ParentID {
+				return nil, &dto.ServiceResponse{
+					Status: http.StatusBadRequest,
+					Err:    ErrCategoryCycle,
+				}
+			}
+		}
+	}
+
+	category.Name = request.Name
+	category.Description = emptyToNil(request.Description)
+	category.ParentID = request.ParentID
+	category.UpdatedAt = time.Now()
+
+	err := query.Model(category).
+		Select("name", "description", "parent_id", "updated_at").
+		Updates(category).Error
+	if err != nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+
+	c.invalidateProductsCache(ctx)
+
+	return category, nil
+}
+
+// DeleteCategory xóa danh mục không còn danh mục con. Danh mục còn sản phẩm (kể cả sản phẩm đã xóa mềm)
+// chỉ xóa được khi có reassignTo, sản phẩm được chuyển sang reassignTo trong cùng transaction.
+func (c *categoryRepository) DeleteCategory(ctx context.Context, categoryId uuid.UUID, reassignTo *uuid.UUID) *dto.ServiceResponse {
+	var errResp *dto.ServiceResponse
+	var moved int64
+
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var category model.Category
+		if err := tx.Where("id = ?", categoryId).First(&category).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				errResp = &dto.ServiceResponse{
+					Status: http.StatusNotFound,
+					Err:    ErrNotFound,
+				}
+			}
+			return err
+		}
+
+		var children int64
+		if err := tx.Model(&model.Category{}).Where("parent_id = ?", categoryId).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			errResp = &dto.ServiceResponse{
+				Status: http.StatusConflict,
+				Err:    ErrCategoryHasChildren,
+			}
+			return errResp.Err
+		}
+
+		var products int64
+		if err := tx.Unscoped().Model(&model.Product{}).Where("category_id = ?", categoryId).Count(&products).Error; err != nil {
+			return err
+		}
+		if products > 0 {
+			if reassignTo == nil {
+				errResp = &dto.ServiceResponse{
+					Status: http.StatusConflict,
+					Err:    ErrCategoryHasProducts,
+				}
+				return errResp.Err
+			}
+			if *reassignTo == categoryId {
+				errResp = &dto.ServiceResponse{
+					Status: http.StatusBadRequest,
+					Err:    ErrCategoryCycle,
+				}
+				return errResp.Err
+			}
+			if errResp = checkCategory(tx, *reassignTo); errResp != nil {
+				return errResp.Err
+			}
+
+			result := tx.Unscoped().Model(&model.Product{}).
+				Where("category_id = ?", categoryId).
+				Updates(map[string]any{"category_id": *reassignTo, "updated_at": time.Now()})
+			if result.Error != nil {
+				return result.Error
+			}
+			moved = result.RowsAffected
+		}
+
+		return tx.Delete(&category).Error
+	})
+	if errResp != nil {
+		return errResp
+	}
+	if err != nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+
+	if moved > 0 {
+		log.Infof("Moved %d products from category %s to %s", moved, categoryId, *reassignTo)
+		c.invalidateProductsCache(ctx)
+	}
+	return nil
+}
+
+// invalidateProductsCache xóa cache của mọi sản phẩm vì dữ liệu sản phẩm được cache kèm danh mục
+func (c *categoryRepository) invalidateProductsCache(ctx context.Context) {
+	log.Info("Delete redis...")
+	_ = utils.DeleteCacheByPattern(ctx, c.rd, baseProduct+"*")
+	_ = utils.DeleteCacheByPattern(ctx, c.rd, baseProductSimilar+"*")
+	_ = utils.DeleteCacheByPattern(ctx, c.rd, baseProductRelated+"*")
+}
+
+// checkCategory trả về lỗi 400 khi danh mục không tồn tại
+func checkCategory(query *gorm.DB, categoryId uuid.UUID) *dto.ServiceResponse {
+	var count int64
+	if err := query.Model(&model.Category{}).Where("id = ?", categoryId).Count(&count).Error; err != nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+	if count == 0 {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    ErrCategoryNotFound,
+		}
+	}
+	return nil
+}
+
+func emptyToNil(s *string) *string {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"time"
+
+	"productservice/internal/dto"
+	"productservice/internal/model"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/google/uuid"
+)
+
+// seedCategoryTree tạo cây: Nông sản > (Cà phê > Robusta, Trái cây) và Phân bón, mỗi lá có sản phẩm
+func (suite *ProductRepositoryTestSuite) seedCategoryTree() map[string]*model.Category {
+	suite.cleanupData()
+	categories := map[string]*model.Category{}
+	create := func(name string, parent *model.Category) {
+		category := &model.Category{ID: uuid.New(), Name: name, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		if parent != nil {
+			category.ParentID = &parent.ID
+		}
+		suite.Require().NoError(suite.db.Create(category).Error)
+		categories[name] = category
+	}
+	create("Nông sản", nil)
+	create("Cà phê", categories["Nông sản"])
+	create("Robusta", categories["Cà phê"])
+	create("Trái cây", categories["Nông sản"])
+	create("Phân bón", nil)
+
+	for name, total := range map[string]int{"Cà phê": 1, "Robusta": 2, "Trái cây": 1, "Phân bón": 1} {
+		for i := 0; i < total; i++ {
+			product := &model.Product{
+				ID:         uuid.New(),
+				Name:       name,
+				SearchName: name,
+				Price:      1000,
+				CategoryID: categories[name].ID,
+				CreatedAt:  time.Now(),
+				UpdatedAt:  time.Now(),
+			}
+			suite.Require().NoError(suite.db.Create(product).Error)
+		}
+	}
+	return categories
+}
+
+func (suite *ProductRepositoryTestSuite) newCategoryRepository() (CategoryRepository, redismock.ClientMock) {
+	rdb, mock := redismock.NewClientMock()
+	return NewCategoryRepository(suite.db, rdb), mock
+}
+
+func (suite *ProductRepositoryTestSuite) TestGetCategoryTree() {
+	categories := suite.seedCategoryTree()
+	// sản phẩm đã xóa mềm không được đếm
+	suite.db.Model(&model.Product{}).Where("category_id = ?", categories["Trái cây"].ID).Update("deleted_at", time.Now())
+	repo, _ := suite.newCategoryRepository()
+
+	tree, resp := repo.GetTree(suite.ctx)
+
+	suite.Require().Nil(resp)
+	suite.Require().Len(tree, 2)
+	root := tree[0]
+	suite.Equal("Nông sản", root.Name)
+	suite.Equal(int64(3), root.ProductCount)
+	suite.Equal(int64(0), root.DirectProductCount)
+	suite.Require().Len(root.Children, 2)
+	suite.Equal("Cà phê", root.Children[0].Name)
+	suite.Equal(int64(3), root.Children[0].ProductCount)
+	suite.Equal(int64(1), root.Children[0].DirectProductCount)
+	suite.Equal(int64(0), root.Children[1].ProductCount)
+	suite.Equal("Phân bón", tree[1].Name)
+	suite.Empty(tree[1].Children)
+}
+
+func (suite *ProductRepositoryTestSuite) TestGetListIncludesDescendantCategories() {
+	categories := suite.seedCategoryTree()
+
+	result, resp := suite.repository.GetList(suite.ctx, &dto.PageProdRequest{
+		Page:        1,
+		Limit:       10,
+		CategoryIds: []uuid.UUID{categories["Nông sản"].ID},
+	})
+
+	suite.Require().Nil(resp)
+	suite.Equal(int64(4), result.Total)
+}
+
+func (suite *ProductRepositoryTestSuite) TestUpdateCategoryRejectsCycle() {
+	categories := suite.seedCategoryTree()
+	repo, mock := suite.newCategoryRepository()
+
+	category, resp := repo.UpdateCategory(suite.ctx, categories["Nông sản"].ID, &dto.CategoryRequest{
+		Name:     "Nông sản",
+		ParentID: &categories["Robusta"].ID,
+	})
+	suite.Nil(category)
+	suite.Require().NotNil(resp)
+	suite.Equal(400, resp.Status)
+	suite.Equal(ErrCategoryCycle, resp.Err)
+
+	// chuyển Cà phê sang làm con của Phân bón hợp lệ, cache sản phẩm bị xóa vì có kèm danh mục
+	mock.ExpectScan(0, baseProduct+"*", 100).SetVal([]string{}, 0)
+	mock.ExpectScan(0, baseProductSimilar+"*", 100).SetVal([]string{}, 0)
+	mock.ExpectScan(0, baseProductRelated+"*", 100).SetVal([]string{}, 0)
+	category, resp = repo.UpdateCategory(suite.ctx, categories["Cà phê"].ID, &dto.CategoryRequest{
+		Name:     "Cà phê",
+		ParentID: &categories["Phân bón"].ID,
+	})
+	suite.Require().Nil(resp)
+	suite.Equal(categories["Phân bón"].ID, *category.ParentID)
+	suite.NoError(mock.ExpectationsWereMet())
+}
+
+func (suite *ProductRepositoryTestSuite) TestDeleteCategory() {
+	categories := suite.seedCategoryTree()
+	repo, mock := suite.newCategoryRepository()
+
+	resp := repo.DeleteCategory(suite.ctx, categories["Cà phê"].ID, nil)
+	suite.Require().NotNil(resp)
+	suite.Equal(409, resp.Status)
+	suite.Equal(ErrCategoryHasChildren, resp.Err)
+
+	resp = repo.DeleteCategory(suite.ctx, categories["Robusta"].ID, nil)
+	suite.Require().NotNil(resp)
+	suite.Equal(409, resp.Status)
+	suite.Equal(ErrCategoryHasProducts, resp.Err)
+
+	missing := uuid.New()
+	resp = repo.DeleteCategory(suite.ctx, categories["Robusta"].ID, &missing)
+	suite.Require().NotNil(resp)
+	suite.Equal(ErrCategoryNotFound, resp.Err)
+
+	mock.ExpectScan(0, baseProduct+"*", 100).SetVal([]string{}, 0)
+	mock.ExpectScan(0, baseProductSimilar+"*", 100).SetVal([]string{}, 0)
+	mock.ExpectScan(0, baseProductRelated+"*", 100).SetVal([]string{}, 0)
+	resp = repo.DeleteCategory(suite.ctx, categories["Robusta"].ID, &categories["Cà phê"].ID)
+	suite.Require().Nil(resp)
+	suite.NoError(mock.ExpectationsWereMet())
+
+	var moved int64
+	suite.db.Model(&model.Product{}).Where("category_id = ?", categories["Cà phê"].ID).Count(&moved)
+	suite.Equal(int64(3), moved)
+	_, resp = repo.GetCategoryById(suite.ctx, categories["Robusta"].ID)
+	suite.Require().NotNil(resp)
+	suite.Equal(404, resp.Status)
+
+	// sản phẩm đã xóa mềm vẫn giữ khóa ngoại tới danh mục nên vẫn chặn xóa
+	suite.db.Where("category_id = ?", categories["Trái cây"].ID).Delete(&model.Product{})
+	resp = repo.DeleteCategory(suite.ctx, categories["Trái cây"].ID, nil)
+	suite.Require().NotNil(resp)
+	suite.Equal(ErrCategoryHasProducts, resp.Err)
+
+	// danh mục trống xóa được và không đụng tới cache
+	empty, resp := repo.CreateCategory(suite.ctx, &dto.CategoryRequest{Name: "Hạt giống"})
+	suite.Require().Nil(resp)
+	suite.Nil(repo.DeleteCategory(suite.ctx, empty.ID, nil))
+	suite.NoError(mock.ExpectationsWereMet())
+}
@@ -9,6 +9,7 @@ var (
 	baseRateOfUser     = "rateofuser:"
 	baseRateOfProduct  = "rateofproduct:"
 	baseRateStatistic  = "ratestatistic:"
+	baseImportJob      = "importjob:"
 )
 
 var (
@@ -16,4 +17,8 @@ var (
 	ErrNotFound            = errors.New("Dữ liệu không tìm thấy")
 	ErrWasRateProduct      = errors.New("Sản phẩm đã được đánh giá")
 	ErrForbidden           = errors.New("Bạn không có quyền thực hiện")
+	ErrCategoryNotFound    = errors.New("Danh mục không tồn tại")
+	ErrCategoryCycle       = errors.New("Danh mục cha không được là chính nó hoặc danh mục con của nó")
+	ErrCategoryHasChildren = errors.New("Danh mục còn danh mục con")
+	ErrCategoryHasProducts = errors.New("Danh mục còn sản phẩm, cần chọn danh mục để chuyển sản phẩm sang")
 )
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"productservice/internal/dto"
+	"productservice/internal/model"
+	"productservice/internal/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrImportJobNotFound khi job không tồn tại hoặc đã hết hạn trong redis
+var ErrImportJobNotFound = errors.New("Không tìm thấy job import")
+
+type ImportRepository interface {
+	Categories(ctx context.Context) ([]model.Category, error)
+	UpsertBySKU(ctx context.Context, products []model.Product) (int, error)
+	InvalidateProductCache(ctx context.Context)
+	SaveJob(ctx context.Context, job *dto.ImportJob, ttl time.Duration) error
+	GetJob(ctx context.Context, jobId uuid.UUID) (*dto.ImportJob, error)
+}
+
+type importRepository struct {
+	db *gorm.DB
+	rd *redis.Client
+}
+
+func NewImportRepository(db *gorm.DB, rd *redis.Client) ImportRepository {
+	return &importRepository{db: db, rd: rd}
+}
+
+func (r *importRepository) Categories(ctx context.Context) ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.WithContext(ctx).Find(&categories).Error
+	return categories, err
+}
+
+// UpsertBySKU thêm mới hoặc ghi đè sản phẩm có cùng SKU, trả về số sản phẩm được thêm mới.
+// Sản phẩm đã xóa mềm vẫn được cập nhật nhưng giữ nguyên trạng thái xóa.
+// products không được có hai phần tử trùng SKU.
+func (r *importRepository) UpsertBySKU(ctx context.Context, products []model.Product) (int, error) {
+	skus := make([]string, 0, len(products))
+	for _, product := range products {
+		skus = append(skus, *product.SKU)
+	}
+
+	var created int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Unscoped().Model(&model.Product{}).Where("sku IN ?", skus).Count(&existing).Error; err != nil {
+			return err
+		}
+		created = len(products) - int(existing)
+
+		return tx.Omit(clause.Associations).
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "sku"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "search_name", "description", "price", "category_id", "updated_at"}),
+			}).
+			Create(&products).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+// InvalidateProductCache xóa cache chi tiết và similar/related của mọi sản phẩm, gọi một lần khi import xong
+func (r *importRepository) InvalidateProductCache(ctx context.Context) {
+	log.Info("Delete redis...")
+	_ = utils.DeleteCacheByPattern(ctx, r.rd, baseProduct+"*")
+	_ = utils.DeleteCacheByPattern(ctx, r.rd, baseProductSimilar+"*")
+	_ = utils.DeleteCacheByPattern(ctx, r.rd, baseProductRelated+"*")
+}
+
+// SaveJob lưu trạng thái job vào redis để instance nào cũng trả lời được khi client hỏi tiến độ
+func (r *importRepository) SaveJob(ctx context.Context, job *dto.ImportJob, ttl time.Duration) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return r.rd.Set(ctx, baseImportJob+job.ID.String(), data, ttl).Err()
+}
+
+func (r *importRepository) GetJob(ctx context.Context, jobId uuid.UUID) (*dto.ImportJob, error) {
+	data, err := r.rd.Get(ctx, baseImportJob+jobId.String()).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrImportJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job dto.ImportJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"productservice/internal/dto"
+	"productservice/internal/model"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/google/uuid"
+)
+
+func (suite *ProductRepositoryTestSuite) newImportRepository() (ImportRepository, redismock.ClientMock) {
+	rdb, mock := redismock.NewClientMock()
+	return NewImportRepository(suite.db, rdb), mock
+}
+
+func importProduct(sku, name string, price float64, categoryId uuid.UUID) model.Product {
+	return model.Product{
+		SKU:        &sku,
+		Name:       name,
+		SearchName: name,
+		Price:      price,
+		CategoryID: categoryId,
+	}
+}
+
+func (suite *ProductRepositoryTestSuite) TestUpsertBySKU() {
+	suite.cleanupData()
+	category := suite.createCategory()
+	repo, _ := suite.newImportRepository()
+
+	created, err := repo.UpsertBySKU(suite.ctx, []model.Product{
+		importProduct("CF-01", "Cà phê", 25000, category.ID),
+		importProduct("CF-02", "Trà", 15000, category.ID),
+	})
+	suite.Require().NoError(err)
+	suite.Equal(2, created)
+
+	var first model.Product
+	suite.Require().NoError(suite.db.Where("sku = ?", "CF-01").First(&first).Error)
+	suite.Require().NoError(suite.db.Delete(&first).Error)
+
+	suite.Run("re-run updates by sku and keeps id", func() {
+		created, err := repo.UpsertBySKU(suite.ctx, []model.Product{
+			importProduct("CF-01", "Cà phê sữa", 27000, category.ID),
+			importProduct("CF-02", "Trà", 15000, category.ID),
+			importProduct("CF-03", "Bánh mì", 20000, category.ID),
+		})
+		suite.Require().NoError(err)
+		suite.Equal(1, created)
+
+		var count int64
+		suite.db.Unscoped().Model(&model.Product{}).Count(&count)
+		suite.Equal(int64(3), count)
+
+		// sản phẩm đã xóa mềm được cập nhật nhưng vẫn ở trạng thái xóa
+		var updated model.Product
+		suite.Require().NoError(suite.db.Unscoped().Where("sku = ?", "CF-01").First(&updated).Error)
+		suite.Equal(first.ID, updated.ID)
+		suite.Equal("Cà phê sữa", updated.Name)
+		suite.Equal(27000.0, updated.Price)
+		suite.True(updated.DeletedAt.Valid)
+	})
+
+}
+
+func (suite *ProductRepositoryTestSuite) TestImportJob() {
+	repo, mock := suite.newImportRepository()
+	job := &dto.ImportJob{ID: uuid.New(), Status: dto.ImportJob_Pending, Format: dto.ImportFormat_CSV}
+
+	suite.Run("success - save", func() {
+		mock.Regexp().ExpectSet(baseImportJob+job.ID.String(), `.*`, time.Hour).SetVal("OK")
+		suite.NoError(repo.SaveJob(suite.ctx, job, time.Hour))
+		suite.NoError(mock.ExpectationsWereMet())
+	})
+
+	suite.Run("success - get", func() {
+		mock.ExpectGet(baseImportJob + job.ID.String()).SetVal(`{"id":"` + job.ID.String() + `","status":"running","processed_rows":10}`)
+		got, err := repo.GetJob(suite.ctx, job.ID)
+		suite.Require().NoError(err)
+		suite.Equal(dto.ImportJob_Running, got.Status)
+		suite.Equal(10, got.ProcessedRows)
+	})
+
+	suite.Run("error - not found", func() {
+		mock.ExpectGet(baseImportJob + job.ID.String()).RedisNil()
+		_, err := repo.GetJob(suite.ctx, job.ID)
+		suite.ErrorIs(err, ErrImportJobNotFound)
+	})
+}
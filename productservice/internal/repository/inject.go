@@ -2,4 +2,4 @@ package repository
 
 import "github.com/google/wire"
 
-var Set = wire.NewSet(NewProductRepository, NewRateRepository)
+var Set = wire.NewSet(NewProductRepository, NewRateRepository, NewCategoryRepository, NewImportRepository, NewUserEventRepository)
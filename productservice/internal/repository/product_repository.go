@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net/http"
 	"productservice/internal/dto"
 	"productservice/internal/model"
 	"productservice/internal/utils"
@@ -15,12 +16,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository interface {
 	GetList(ctx context.Context, request *dto.PageProdRequest) (*dto.PageResponse, *dto.ServiceResponse)
 	GetProductById(ctx context.Context, productId uuid.UUID) (*model.Product, *dto.ServiceResponse)
 	GetProductSimilar(ctx context.Context, productId uuid.UUID, pageRequest *dto.PageSimilarAndRelatedRequest, relationType *dto.RelationType) (*dto.PageResponse, *dto.ServiceResponse)
+	CreateProduct(ctx context.Context, request *dto.ProductRequest) (*model.Product, *dto.ServiceResponse)
+	UpdateProduct(ctx context.Context, productId uuid.UUID, request *dto.PatchProductRequest) (*model.Product, *dto.ServiceResponse)
+	DeleteProduct(ctx context.Context, productId uuid.UUID) *dto.ServiceResponse
+	RestoreProduct(ctx context.Context, productId uuid.UUID) (*model.Product, *dto.ServiceResponse)
 }
 
 type productRepository struct {
@@ -201,9 +207,9 @@ func (p *productRepository) GetList(ctx context.Context, request *dto.PageProdRe
 		query = query.Where("search_name LIKE ?", "%"+normalizedSearch+"%")
 	}
 
-	// Filter theo danh sách CategoryIds
+	// Filter theo danh sách CategoryIds, gồm cả danh mục con cháu
 	if len(request.CategoryIds) > 0 {
-		query = query.Where("category_id IN ?", request.CategoryIds)
+		query = query.Where("category_id IN ("+categoryDescendantsSQL+")", request.CategoryIds)
 	}
 
 	// Filter theo khoảng giá
@@ -261,3 +267,160 @@ func (p *productRepository) GetList(ctx context.Context, request *dto.PageProdRe
 		Filter: request,
 	}, nil
 }
+
+// CreateProduct tạo sản phẩm mới, search_name được sinh từ name
+func (p *productRepository) CreateProduct(ctx context.Context, request *dto.ProductRequest) (*model.Product, *dto.ServiceResponse) {
+	query := p.db.WithContext(ctx)
+
+	if errResp := checkCategory(query, request.CategoryID); errResp != nil {
+		return nil, errResp
+	}
+
+	product := model.Product{
+		Name:        request.Name,
+		SearchName:  utils.NormalizeSearchText(request.Name),
+		Description: emptyToNil(request.Description),
+		Price:       request.Price,
+		CategoryID:  request.CategoryID,
+	}
+	if err := query.Omit(clause.Associations).Create(&product).Error; err != nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+
+	p.invalidateProductCache(ctx, product.ID)
+
+	return p.findProduct(query, product.ID, false)
+}
+
+// UpdateProduct cập nhật các trường khác nil của request trên sản phẩm chưa bị xóa
+func (p *productRepository) UpdateProduct(ctx context.Context, productId uuid.UUID, request *dto.PatchProductRequest) (*model.Product, *dto.ServiceResponse) {
+	query := p.db.WithContext(ctx)
+
+	var product model.Product
+	if err := query.Where("id = ?", productId).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &dto.ServiceResponse{
+				Status: http.StatusNotFound,
+				Err:    ErrNotFound,
+			}
+		}
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+
+	if request.CategoryID != nil {
+		if errResp := checkCategory(query, *request.CategoryID); errResp != nil {
+			return nil, errResp
+		}
+		product.CategoryID = *request.CategoryID
+	}
+	if request.Name != nil {
+		product.Name = *request.Name
+		product.SearchName = utils.NormalizeSearchText(*request.Name)
+	}
+	if request.Description != nil {
+		product.Description = emptyToNil(request.Description)
+	}
+	if request.Price != nil {
+		product.Price = *request.Price
+	}
+	product.UpdatedAt = time.Now()
+
+	// Select để ghi cả giá trị rỗng (description = NULL) và không đụng tới average_rating/total_ratings
+	err := query.Model(&product).
+		Select("name", "search_name", "description", "price", "category_id", "updated_at").
+		Updates(&product).Error
+	if err != nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+
+	p.invalidateProductCache(ctx, productId)
+
+	return p.findProduct(query, productId, false)
+}
+
+// DeleteProduct xóa mềm sản phẩm, có thể khôi phục bằng RestoreProduct
+func (p *productRepository) DeleteProduct(ctx context.Context, productId uuid.UUID) *dto.ServiceResponse {
+	result := p.db.WithContext(ctx).Where("id = ?", productId).Delete(&model.Product{})
+	if result.Error != nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+	if result.RowsAffected == 0 {
+		return &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    ErrNotFound,
+		}
+	}
+
+	p.invalidateProductCache(ctx, productId)
+
+	return nil
+}
+
+// RestoreProduct khôi phục sản phẩm đã xóa mềm. Sản phẩm chưa bị xóa được trả về nguyên trạng.
+func (p *productRepository) RestoreProduct(ctx context.Context, productId uuid.UUID) (*model.Product, *dto.ServiceResponse) {
+	query := p.db.WithContext(ctx)
+
+	product, errResp := p.findProduct(query, productId, true)
+	if errResp != nil {
+		return nil, errResp
+	}
+	if !product.DeletedAt.Valid {
+		return product, nil
+	}
+
+	err := query.Unscoped().Model(&model.Product{}).
+		Where("id = ?", productId).
+		Updates(map[string]any{"deleted_at": nil, "updated_at": time.Now()}).Error
+	if err != nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+
+	p.invalidateProductCache(ctx, productId)
+
+	return p.findProduct(query, productId, false)
+}
+
+func (p *productRepository) findProduct(query *gorm.DB, productId uuid.UUID, withDeleted bool) (*model.Product, *dto.ServiceResponse) {
+	if withDeleted {
+		query = query.Unscoped()
+	}
+
+	var product model.Product
+	if err := query.Preload("Category").Where("id = ?", productId).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &dto.ServiceResponse{
+				Status: http.StatusNotFound,
+				Err:    ErrNotFound,
+			}
+		}
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServerError,
+		}
+	}
+	return &product, nil
+}
+
+// invalidateProductCache xóa cache chi tiết của sản phẩm và toàn bộ cache similar/related,
+// vì sản phẩm có thể nằm trong danh sách similar/related của bất kỳ sản phẩm nào
+func (p *productRepository) invalidateProductCache(ctx context.Context, productId uuid.UUID) {
+	log.Info("Delete redis...")
+	_ = p.rd.Del(ctx, baseProduct+productId.String()).Err()
+	_ = utils.DeleteCacheByPattern(ctx, p.rd, baseProductSimilar+"*")
+	_ = utils.DeleteCacheByPattern(ctx, p.rd, baseProductRelated+"*")
+}
@@ -42,6 +42,7 @@ func (suite *ProductRepositoryTestSuite) SetupSuite() {
 		id TEXT PRIMARY KEY,
 		name TEXT NOT NULL,
 		description TEXT,
+		parent_id TEXT,
 		created_at DATETIME NOT NULL,
 		updated_at DATETIME NOT NULL
 	);
@@ -50,6 +51,7 @@ func (suite *ProductRepositoryTestSuite) SetupSuite() {
 		id TEXT PRIMARY KEY,
 		name TEXT NOT NULL,
 		search_name TEXT NOT NULL,
+		sku TEXT UNIQUE,
 		description TEXT,
 		price REAL NOT NULL,
 		category_id TEXT NOT NULL,
@@ -538,6 +540,169 @@ func (suite *ProductRepositoryTestSuite) TestGetList() {
 	}
 }
 
+func (suite *ProductRepositoryTestSuite) createCategory() *model.Category {
+	category := &model.Category{
+		ID:        uuid.New(),
+		Name:      "Đồ uống",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	suite.Require().NoError(suite.db.Create(category).Error)
+	return category
+}
+
+func (suite *ProductRepositoryTestSuite) expectInvalidate(productId uuid.UUID) {
+	suite.redis.ExpectDel(baseProduct + productId.String()).SetVal(1)
+	suite.redis.ExpectScan(0, baseProductSimilar+"*", 100).SetVal([]string{}, 0)
+	suite.redis.ExpectScan(0, baseProductRelated+"*", 100).SetVal([]string{}, 0)
+}
+
+// Test CreateProduct
+func (suite *ProductRepositoryTestSuite) TestCreateProduct() {
+	suite.cleanupData()
+	category := suite.createCategory()
+
+	suite.Run("success - search name derived from name", func() {
+		suite.redis.Regexp().ExpectDel(baseProduct + `.*`).SetVal(0)
+		suite.redis.ExpectScan(0, baseProductSimilar+"*", 100).SetVal([]string{}, 0)
+		suite.redis.ExpectScan(0, baseProductRelated+"*", 100).SetVal([]string{}, 0)
+
+		description := " "
+		product, resp := suite.repository.CreateProduct(suite.ctx, &dto.ProductRequest{
+			Name:        "Cà Phê Sữa Đá",
+			Description: &description,
+			Price:       25000,
+			CategoryID:  category.ID,
+		})
+
+		suite.Require().Nil(resp)
+		suite.NotEqual(uuid.Nil, product.ID)
+		suite.Equal("ca phe sua da", product.SearchName)
+		suite.Nil(product.Description)
+		suite.Equal(category.ID, product.Category.ID)
+		suite.NoError(suite.redis.ExpectationsWereMet())
+	})
+
+	suite.Run("error - category not found", func() {
+		product, resp := suite.repository.CreateProduct(suite.ctx, &dto.ProductRequest{
+			Name:       "Trà",
+			Price:      10000,
+			CategoryID: uuid.New(),
+		})
+
+		suite.Nil(product)
+		suite.Require().NotNil(resp)
+		suite.Equal(400, resp.Status)
+		suite.Equal(ErrCategoryNotFound, resp.Err)
+	})
+}
+
+// Test UpdateProduct
+func (suite *ProductRepositoryTestSuite) TestUpdateProduct() {
+	suite.cleanupData()
+	category := suite.createCategory()
+	description := "Mô tả cũ"
+	product := &model.Product{
+		ID:            uuid.New(),
+		Name:          "Trà đào",
+		SearchName:    "tra dao",
+		Description:   &description,
+		Price:         30000,
+		CategoryID:    category.ID,
+		AverageRating: 4.5,
+		TotalRatings:  2,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	suite.Require().NoError(suite.db.Create(product).Error)
+
+	suite.Run("success - only given fields change", func() {
+		suite.expectInvalidate(product.ID)
+
+		name := "Trà Vải"
+		empty := ""
+		updated, resp := suite.repository.UpdateProduct(suite.ctx, product.ID, &dto.PatchProductRequest{
+			Name:        &name,
+			Description: &empty,
+		})
+
+		suite.Require().Nil(resp)
+		suite.Equal("Trà Vải", updated.Name)
+		suite.Equal("tra vai", updated.SearchName)
+		suite.Nil(updated.Description)
+		suite.Equal(float64(30000), updated.Price)
+		suite.Equal(4.5, updated.AverageRating)
+		suite.Equal(2, updated.TotalRatings)
+		suite.NoError(suite.redis.ExpectationsWereMet())
+	})
+
+	suite.Run("error - category not found", func() {
+		categoryId := uuid.New()
+		updated, resp := suite.repository.UpdateProduct(suite.ctx, product.ID, &dto.PatchProductRequest{CategoryID: &categoryId})
+
+		suite.Nil(updated)
+		suite.Require().NotNil(resp)
+		suite.Equal(ErrCategoryNotFound, resp.Err)
+	})
+
+	suite.Run("error - product not found", func() {
+		price := 1.0
+		updated, resp := suite.repository.UpdateProduct(suite.ctx, uuid.New(), &dto.PatchProductRequest{Price: &price})
+
+		suite.Nil(updated)
+		suite.Require().NotNil(resp)
+		suite.Equal(404, resp.Status)
+	})
+}
+
+// Test DeleteProduct và RestoreProduct
+func (suite *ProductRepositoryTestSuite) TestDeleteAndRestoreProduct() {
+	suite.cleanupData()
+	category := suite.createCategory()
+	product := &model.Product{
+		ID:         uuid.New(),
+		Name:       "Bánh mì",
+		SearchName: "banh mi",
+		Price:      20000,
+		CategoryID: category.ID,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	suite.Require().NoError(suite.db.Create(product).Error)
+
+	suite.expectInvalidate(product.ID)
+	suite.Require().Nil(suite.repository.DeleteProduct(suite.ctx, product.ID))
+
+	var count int64
+	suite.db.Model(&model.Product{}).Where("id = ?", product.ID).Count(&count)
+	suite.Equal(int64(0), count)
+
+	resp := suite.repository.DeleteProduct(suite.ctx, product.ID)
+	suite.Require().NotNil(resp)
+	suite.Equal(404, resp.Status)
+
+	price := 1.0
+	_, resp = suite.repository.UpdateProduct(suite.ctx, product.ID, &dto.PatchProductRequest{Price: &price})
+	suite.Require().NotNil(resp)
+	suite.Equal(404, resp.Status)
+
+	suite.expectInvalidate(product.ID)
+	restored, resp := suite.repository.RestoreProduct(suite.ctx, product.ID)
+	suite.Require().Nil(resp)
+	suite.False(restored.DeletedAt.Valid)
+	suite.Equal("Bánh mì", restored.Name)
+
+	// khôi phục lần nữa không lỗi và không xóa cache
+	restored, resp = suite.repository.RestoreProduct(suite.ctx, product.ID)
+	suite.Require().Nil(resp)
+	suite.Equal(product.ID, restored.ID)
+	suite.NoError(suite.redis.ExpectationsWereMet())
+
+	_, resp = suite.repository.RestoreProduct(suite.ctx, uuid.New())
+	suite.Require().NotNil(resp)
+	suite.Equal(404, resp.Status)
+}
+
 func TestProductRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(ProductRepositoryTestSuite))
 }
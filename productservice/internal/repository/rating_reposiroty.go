@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"productservice/internal/auth"
 	"productservice/internal/dto"
 	"productservice/internal/grpc/client"
 	"productservice/internal/model"
@@ -27,6 +28,8 @@ type RateRepository interface {
 	GetRateListOfProduct(ctx context.Context, filterRequest *dto.RateListOfProduct) (*dto.PageResponse, *dto.ServiceResponse)
 	GetRateStatisticOfProduct(ctx context.Context, productId uuid.UUID) (*dto.RatingSummaryResponse, *dto.ServiceResponse)
 	GetMyRatings(ctx context.Context, userId uuid.UUID, request *dto.MyRatingsRequest) (*dto.MyRatingsResponse, *dto.ServiceResponse)
+	InvalidateUserRatings(ctx context.Context, userId uuid.UUID) error
+	HideRatingsOfUser(ctx context.Context, userId uuid.UUID) error
 }
 
 type rateRepository struct {
@@ -315,10 +318,11 @@ func (r *rateRepository) GetRateListOfProduct(ctx context.Context, filterRequest
 		"star:" + strconv.Itoa(filterRequest.Stars) +
 		"sort: " + filterRequest.SortBy
 	if cached, err := r.rd.Get(ctx, key).Bytes(); err == nil && cached != nil {
-		var response dto.PageResponse
-		if err := json.Unmarshal(cached, &response); err == nil {
+		var page rateListPage
+		if err := json.Unmarshal(cached, &page); err == nil {
 			log.Info("Using redis...")
-			return &response, nil
+			r.fillReviewers(ctx, page.Data)
+			return &dto.PageResponse{Total: page.Total, Data: page.Data, Filter: page.Filter}, nil
 		}
 	}
 
@@ -375,19 +379,70 @@ func (r *rateRepository) GetRateListOfProduct(ctx context.Context, filterRequest
 		}
 	}
 
-	// Return paginated response
-	response := dto.PageResponse{
-		Total:  total,
-		Data:   ratings,
-		Filter: filterRequest,
+	data := make([]*dto.RateOfProduct, 0, len(ratings))
+	for _, rating := range ratings {
+		comment := ""
+		if rating.Comment != nil {
+			comment = *rating.Comment
+		}
+		data = append(data, &dto.RateOfProduct{
+			Id:        rating.ID,
+			ProductId: rating.ProductID,
+			User:      dto.UserOfRate{Id: rating.UserID},
+			Star:      rating.Rating,
+			Comment:   comment,
+			CreatedAt: rating.CreatedAt,
+			UpdatedAt: rating.UpdatedAt,
+		})
 	}
+
 	// =====  Cache response =====
-	if b, err := json.Marshal(response); err == nil {
+	// cache không giữ tên người đánh giá để đổi tên ở userservice hiển thị ngay
+	if b, err := json.Marshal(rateListPage{Total: total, Data: data, Filter: filterRequest}); err == nil {
 		log.Info("Update/Save redis...")
 		_ = r.rd.Set(context.Background(), key, b, time.Hour).Err()
 	}
 
-	return &response, nil
+	r.fillReviewers(ctx, data)
+
+	// Return paginated response
+	return &dto.PageResponse{
+		Total:  total,
+		Data:   data,
+		Filter: filterRequest,
+	}, nil
+}
+
+// rateListPage là dạng lưu trong redis của GetRateListOfProduct
+type rateListPage struct {
+	Total  int64                  `json:"total"`
+	Data   []*dto.RateOfProduct   `json:"data"`
+	Filter *dto.RateListOfProduct `json:"filter"`
+}
+
+// fillReviewers lấy tên người đánh giá của cả trang bằng một lần gọi BatchGetUsers.
+// userservice lỗi thì vẫn trả đánh giá, chỉ thiếu tên.
+func (r *rateRepository) fillReviewers(ctx context.Context, ratings []*dto.RateOfProduct) {
+	if r.authClient == nil || len(ratings) == 0 {
+		return
+	}
+
+	seen := make(map[uuid.UUID]bool, len(ratings))
+	ids := make([]uuid.UUID, 0, len(ratings))
+	for _, rating := range ratings {
+		if !seen[rating.User.Id] {
+			seen[rating.User.Id] = true
+			ids = append(ids, rating.User.Id)
+		}
+	}
+
+	names, err := r.authClient.BatchGetUsers(ctx, ids)
+	if err != nil {
+		return
+	}
+	for _, rating := range ratings {
+		rating.User.Name = names[rating.User.Id]
+	}
 }
 
 func (r *rateRepository) DeleteRatingProduct(ctx context.Context, ratingId uuid.UUID) *dto.ServiceResponse {
@@ -408,9 +463,10 @@ func (r *rateRepository) DeleteRatingProduct(ctx context.Context, ratingId uuid.
 		}
 	}
 
-	userId := ctx.Value("userId")
-
-	if userId != rating.UserID {
+	// chủ đánh giá hoặc người có quyền kiểm duyệt mới được xóa
+	userId, _ := ctx.Value("userId").(string)
+	permissions, _ := ctx.Value("permissions").([]string)
+	if userId != rating.UserID.String() && !auth.HasPermission(permissions, auth.PermRatingsModerate) {
 		return &dto.ServiceResponse{
 			Status: http.StatusForbidden,
 			Err:    ErrForbidden,
@@ -452,21 +508,13 @@ func (r *rateRepository) UpdateRatingProduct(ctx context.Context, ratingProduct
 		}
 	}
 
-	token := ctx.Value("token")
-	user, err := r.authClient.GetCurrentUserInfo(ctx, token.(string))
+	userId, _ := ctx.Value("userId").(string)
+	userUuid, err := uuid.Parse(userId)
 	if err != nil {
-		response := dto.ServiceResponse{
+		return nil, &dto.ServiceResponse{
 			Status: http.StatusUnauthorized,
 			Err:    ErrNotFound,
 		}
-		return nil, &response
-	}
-	userUuid, err := uuid.Parse(user.Id)
-	if err != nil {
-		return nil, &dto.ServiceResponse{
-			Status: http.StatusInternalServerError,
-			Err:    ErrInternalServerError,
-		}
 	}
 
 	if rating.UserID != userUuid {
@@ -516,7 +564,7 @@ func (r *rateRepository) UpdateRatingProduct(ctx context.Context, ratingProduct
 		},
 		User: dto.UserOfRate{
 			Id:   userUuid,
-			Name: user.Name,
+			Name: r.reviewerName(ctx),
 		},
 		UpdateAt: rating.UpdatedAt,
 	}
@@ -570,16 +618,6 @@ func (r *rateRepository) RatingProduct(ctx context.Context, rateProduct *dto.Rat
 		}
 	}
 
-	token := ctx.Value("token")
-	resp, err := r.authClient.GetCurrentUserInfo(ctx, token.(string))
-	if err != nil {
-		response := dto.ServiceResponse{
-			Status: http.StatusUnauthorized,
-			Err:    ErrNotFound,
-		}
-		return nil, &response
-	}
-
 	newRating := model.Rating{
 		ID:        uuid.New(),
 		ProductID: rateProduct.ProductId,
@@ -615,10 +653,75 @@ func (r *rateRepository) RatingProduct(ctx context.Context, rateProduct *dto.Rat
 		},
 		User: dto.UserOfRate{
 			Id:   rateProduct.UserId,
-			Name: resp.Name,
+			Name: r.reviewerName(ctx),
 		},
 		Star:     newRating.Rating,
 		Comment:  *newRating.Comment,
 		CreateAt: newRating.CreatedAt,
 	}, nil
 }
+
+// reviewerName lấy tên người đánh giá từ JWT đã xác thực tại middleware,
+// chỉ gọi userservice khi token không mang claim name (token xác thực qua gRPC)
+func (r *rateRepository) reviewerName(ctx context.Context) string {
+	if name, ok := ctx.Value("name").(string); ok && name != "" {
+		return name
+	}
+
+	token, ok := ctx.Value("token").(string)
+	if !ok || token == "" {
+		return ""
+	}
+
+	user, err := r.authClient.GetCurrentUserInfo(ctx, token)
+	if err != nil {
+		log.Error(err)
+		return ""
+	}
+	return user.Name
+}
+
+// InvalidateUserRatings xóa cache đánh giá có dữ liệu của user (tên, trạng thái tài khoản)
+func (r *rateRepository) InvalidateUserRatings(ctx context.Context, userId uuid.UUID) error {
+	var productIds []uuid.UUID
+	if err := r.db.WithContext(ctx).Model(&model.Rating{}).
+		Where("user_id = ?", userId).
+		Distinct().Pluck("product_id", &productIds).Error; err != nil {
+		return err
+	}
+
+	r.invalidateRatingCache(ctx, userId, productIds)
+	return nil
+}
+
+// HideRatingsOfUser xóa mềm mọi đánh giá của user đã bị xóa vĩnh viễn ở userservice.
+// Trigger của bảng ratings tự tính lại điểm trung bình của sản phẩm.
+func (r *rateRepository) HideRatingsOfUser(ctx context.Context, userId uuid.UUID) error {
+	var productIds []uuid.UUID
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Rating{}).
+			Where("user_id = ? AND deleted_at IS NULL", userId).
+			Distinct().Pluck("product_id", &productIds).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userId).Delete(&model.Rating{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	r.invalidateRatingCache(ctx, userId, productIds)
+	for _, productId := range productIds {
+		_ = r.rd.Del(ctx, baseProduct+productId.String()).Err()
+	}
+	return nil
+}
+
+func (r *rateRepository) invalidateRatingCache(ctx context.Context, userId uuid.UUID, productIds []uuid.UUID) {
+	log.Info("Delete redis...")
+	_ = r.rd.Del(ctx, baseRateOfUser+userId.String()).Err()
+	for _, productId := range productIds {
+		_ = r.rd.Del(ctx, baseRateStatistic+productId.String()).Err()
+		_ = utils.DeleteCacheByPattern(ctx, r.rd, baseRateOfProduct+productId.String()+"*")
+	}
+}
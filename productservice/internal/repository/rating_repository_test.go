@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"productservice/config"
+	"productservice/internal/dto"
+	"productservice/internal/grpc/client"
+	"productservice/internal/grpc/pb/userservicepb"
+	"productservice/internal/model"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testServiceToken = "product-service-token"
+
+// fakeUserService trả về tên theo id và đếm số lần BatchGetUsers được gọi
+type fakeUserService struct {
+	userservicepb.UnimplementedUserServiceServer
+	names map[string]string
+	err   error
+	calls atomic.Int64
+}
+
+func (f *fakeUserService) BatchGetUsers(ctx context.Context, request *userservicepb.BatchGetUsersRequest) (*userservicepb.BatchGetUsersResponse, error) {
+	f.calls.Add(1)
+	if f.err != nil {
+		return nil, f.err
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if token := md.Get("x-service-token"); len(token) == 0 || token[0] != testServiceToken {
+		return nil, status.Error(codes.Unauthenticated, "invalid service token")
+	}
+
+	response := &userservicepb.BatchGetUsersResponse{}
+	for _, id := range request.GetIds() {
+		if name, ok := f.names[id]; ok {
+			response.Users = append(response.Users, &userservicepb.PublicUser{Id: id, Name: name})
+		}
+	}
+	return response, nil
+}
+
+func newTestAuthClient(t *testing.T, users *fakeUserService) *client.AuthClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	userservicepb.RegisterUserServiceServer(server, users)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	cfg := &config.Config{}
+	cfg.Server.Grpc.Auth.ServiceToken = testServiceToken
+	return client.NewAuthClient(conn, nil, cfg)
+}
+
+func (suite *ProductRepositoryTestSuite) TestGetRateListOfProduct() {
+	productID := uuid.New()
+	alice, bob, ghost := uuid.New(), uuid.New(), uuid.New()
+
+	setup := func() {
+		suite.cleanupData()
+		now := time.Now()
+		for i, userID := range []uuid.UUID{alice, bob, alice, ghost} {
+			comment := "comment"
+			suite.db.Create(&model.Rating{
+				ID:        uuid.New(),
+				ProductID: productID,
+				UserID:    userID,
+				Rating:    5,
+				Comment:   &comment,
+				CreatedAt: now.Add(-time.Duration(i) * time.Minute),
+				UpdatedAt: now,
+			})
+		}
+	}
+	request := func() *dto.RateListOfProduct {
+		return &dto.RateListOfProduct{ProductId: productID, Page: 1, Limit: 10}
+	}
+	key := baseRateOfProduct + productID.String() + "page:1limit:10star:0sort: "
+
+	suite.Run("success - fills reviewer names with one call", func() {
+		setup()
+		users := &fakeUserService{names: map[string]string{alice.String(): "Alice", bob.String(): "Bob"}}
+		rdb, redisMock := redismock.NewClientMock()
+		repository := NewRateRepository(suite.db, rdb, newTestAuthClient(suite.T(), users))
+		redisMock.ExpectGet(key).RedisNil()
+		redisMock.Regexp().ExpectSet(key, `.*`, time.Hour).SetVal("OK")
+
+		result, resp := repository.GetRateListOfProduct(suite.ctx, request())
+
+		suite.Nil(resp)
+		suite.Equal(int64(4), result.Total)
+		data := result.Data.([]*dto.RateOfProduct)
+		suite.Require().Len(data, 4)
+		suite.Equal(dto.UserOfRate{Id: alice, Name: "Alice"}, data[0].User)
+		suite.Equal(dto.UserOfRate{Id: bob, Name: "Bob"}, data[1].User)
+		suite.Equal("Alice", data[2].User.Name)
+		suite.Equal(dto.UserOfRate{Id: ghost}, data[3].User)
+		suite.Equal(int64(1), users.calls.Load())
+	})
+
+	suite.Run("success - cached page gets fresh names", func() {
+		setup()
+		users := &fakeUserService{names: map[string]string{alice.String(): "Alice Renamed"}}
+		rdb, redisMock := redismock.NewClientMock()
+		repository := NewRateRepository(suite.db, rdb, newTestAuthClient(suite.T(), users))
+		cached, _ := json.Marshal(rateListPage{
+			Total:  1,
+			Data:   []*dto.RateOfProduct{{Id: uuid.New(), ProductId: productID, User: dto.UserOfRate{Id: alice}, Star: 4}},
+			Filter: request(),
+		})
+		redisMock.ExpectGet(key).SetVal(string(cached))
+
+		result, resp := repository.GetRateListOfProduct(suite.ctx, request())
+
+		suite.Nil(resp)
+		data := result.Data.([]*dto.RateOfProduct)
+		suite.Require().Len(data, 1)
+		suite.Equal("Alice Renamed", data[0].User.Name)
+	})
+
+	suite.Run("success - userservice unavailable", func() {
+		setup()
+		rdb, redisMock := redismock.NewClientMock()
+		users := &fakeUserService{err: status.Error(codes.Unavailable, "unavailable")}
+		repository := NewRateRepository(suite.db, rdb, newTestAuthClient(suite.T(), users))
+		redisMock.ExpectGet(key).RedisNil()
+		redisMock.Regexp().ExpectSet(key, `.*`, time.Hour).SetVal("OK")
+
+		result, resp := repository.GetRateListOfProduct(suite.ctx, request())
+
+		suite.Nil(resp)
+		data := result.Data.([]*dto.RateOfProduct)
+		suite.Len(data, 4)
+		for _, rating := range data {
+			suite.Empty(rating.User.Name)
+		}
+	})
+}
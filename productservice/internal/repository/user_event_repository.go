@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// baseUserEventSeq lưu seq cuối cùng đã xử lý của WatchUserEvents, dùng chung giữa các instance
+const baseUserEventSeq = "userevents:seq"
+
+type UserEventRepository interface {
+	LastSeq(ctx context.Context) (int64, bool, error)
+	SaveSeq(ctx context.Context, seq int64) error
+}
+
+type userEventRepository struct {
+	rd *redis.Client
+}
+
+func NewUserEventRepository(rd *redis.Client) UserEventRepository {
+	return &userEventRepository{rd: rd}
+}
+
+// LastSeq trả về false khi chưa từng xử lý sự kiện nào
+func (r *userEventRepository) LastSeq(ctx context.Context) (int64, bool, error) {
+	value, err := r.rd.Get(ctx, baseUserEventSeq).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	seq, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return seq, true, nil
+}
+
+func (r *userEventRepository) SaveSeq(ctx context.Context, seq int64) error {
+	return r.rd.Set(ctx, baseUserEventSeq, seq, 0).Err()
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"productservice/internal/auth"
 	"productservice/internal/handler"
 	"productservice/internal/middleware"
 
@@ -8,18 +9,27 @@ import (
 )
 
 type RouterHandler struct {
-	productApi *handler.ProductHandler
-	rateApi    *handler.RateHandler
+	productApi  *handler.ProductHandler
+	rateApi     *handler.RateHandler
+	categoryApi *handler.CategoryHandler
+	importApi   *handler.ImportHandler
 }
 
-func NewRouterHandler(productApi *handler.ProductHandler, rateApi *handler.RateHandler) *RouterHandler {
+func NewRouterHandler(productApi *handler.ProductHandler, rateApi *handler.RateHandler, categoryApi *handler.CategoryHandler, importApi *handler.ImportHandler) *RouterHandler {
 	return &RouterHandler{
-		productApi: productApi,
-		rateApi:    rateApi,
+		productApi:  productApi,
+		rateApi:     rateApi,
+		categoryApi: categoryApi,
+		importApi:   importApi,
 	}
 }
 
+// importUploadPath là route duy nhất nhận body lớn hơn giới hạn mặc định
+const importUploadPath = "/products/imports"
+
 func (r *RouterHandler) InitRouter(root *fiber.App, md *middleware.Middleware) {
+	root.Use(md.BodyLimit.Handler(importUploadPath))
+
 	root.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
 			"status":  "ok",
@@ -37,9 +47,30 @@ func (r *RouterHandler) InitRouter(root *fiber.App, md *middleware.Middleware) {
 
 	ratingProductGroup := root.Group("/products")
 	ratingProductGroup.Use(md.Auth.Handler())
-	ratingProductGroup.Post("/:productId/ratings", r.rateApi.RateProduct)
+	ratingProductGroup.Post("/:productId/ratings", md.Auth.RequireVerifiedEmailForRating(), r.rateApi.RateProduct)
 	ratingProductGroup.Put("/ratings/:ratingId", r.rateApi.UpdateRateProduct)
-	ratingProductGroup.Delete("/ratings/:rateingId", r.rateApi.DeleteRateProduct)
+	ratingProductGroup.Delete("/ratings/:ratingId", r.rateApi.DeleteRateProduct)
+
+	// Quản trị sản phẩm, cần quyền products:write.
+	// md.Auth.Handler() đã chạy qua middleware của ratingProductGroup (cùng prefix, đăng ký trước)
+	adminProductGroup := root.Group("/products", md.Auth.RequirePermission(auth.PermProductsWrite))
+	adminProductGroup.Post("", r.productApi.CreateProduct)
+	adminProductGroup.Put("/:productId", r.productApi.ReplaceProduct)
+	adminProductGroup.Patch("/:productId", r.productApi.PatchProduct)
+	adminProductGroup.Delete("/:productId", r.productApi.DeleteProduct)
+	adminProductGroup.Post("/:productId/restore", r.productApi.RestoreProduct)
+	adminProductGroup.Post("/imports", r.importApi.ImportProducts)
+	adminProductGroup.Get("/imports/:jobId", r.importApi.GetImportJob)
+
+	categoryGroup := root.Group("/categories")
+	categoryGroup.Get("/tree", r.categoryApi.GetTree)
+	categoryGroup.Get("/:categoryId", r.categoryApi.GetCategoryById)
+
+	// Quản trị danh mục dùng chung quyền products:write với sản phẩm
+	adminCategoryGroup := root.Group("/categories", md.Auth.Handler(), md.Auth.RequirePermission(auth.PermProductsWrite))
+	adminCategoryGroup.Post("", r.categoryApi.CreateCategory)
+	adminCategoryGroup.Put("/:categoryId", r.categoryApi.UpdateCategory)
+	adminCategoryGroup.Delete("/:categoryId", r.categoryApi.DeleteCategory)
 
 	ratingGroup := root.Group("/ratings")
 	ratingGroup.Use(md.Auth.Handler())
@@ -6,11 +6,13 @@ package internal
 import (
 	"encoding/json"
 	"productservice/config"
+	"productservice/internal/auth"
 	"productservice/internal/cache"
 	"productservice/internal/database"
 	"productservice/internal/grpc/client"
 	"productservice/internal/grpc/provider"
 	"productservice/internal/handler"
+	"productservice/internal/metrics"
 	"productservice/internal/middleware"
 	"productservice/internal/repository"
 	"productservice/internal/router"
@@ -30,22 +32,40 @@ func New() (*fiber.App, func(), error) {
 		database.Set,
 		cache.Set,
 		provider.Set, // Cung cấp *grpc.ClientConn và cleanup
-		client.Set,   // Cung cấp *client.AuthClient
+		client.Set,   // Cung cấp *client.AuthClient và cache kết quả Authenticate
+		auth.Set,     // Cung cấp *auth.Verifier (JWKS của userservice)
 		repository.Set,
 		service.Set,
 		handler.Set,
 		router.Set,
 		middleware.Set, // Cung cấp *middleware.Middleware
+		metrics.Set,    // /debug/vars trên listener nội bộ
 		NewServer,
 	))
 }
 
-func NewServer(router *router.RouterHandler, md *middleware.Middleware) *fiber.App {
+// NewImporter dùng cho lệnh import ở cmd, chỉ cần database và redis
+func NewImporter() (*service.ProductImporter, func(), error) {
+	panic(wire.Build(
+		config.Set,
+		database.Set,
+		cache.Set,
+		repository.NewImportRepository,
+		service.NewProductImporter,
+	))
+}
+
+// events và metrics chạy nền cùng vòng đời với app, nhận ở đây để wire khởi tạo chúng
+func NewServer(cfg *config.Config, router *router.RouterHandler, md *middleware.Middleware, events *service.UserEventConsumer, metrics *metrics.Server) *fiber.App {
 	app := fiber.New(fiber.Config{
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 5 * time.Second,
 		JSONDecoder:  json.Unmarshal,
 		JSONEncoder:  json.Marshal,
+		// body lớn hơn BodyLimit mặc định được đọc dần thay vì bị từ chối, middleware BodyLimit
+		// chỉ cho route import vượt giới hạn này, file multipart được parse khi handler cần
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 	})
 
 	app.Use(logger.New())
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"productservice/internal/dto"
+	"productservice/internal/model"
+	"productservice/internal/repository"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+type CategoryService interface {
+	GetTree(ctx context.Context) ([]*dto.CategoryNode, *dto.ServiceResponse)
+	GetCategoryById(ctx context.Context, categoryId uuid.UUID) (*model.Category, *dto.ServiceResponse)
+	CreateCategory(ctx context.Context, request *dto.CategoryRequest) (*model.Category, *dto.ServiceResponse)
+	UpdateCategory(ctx context.Context, categoryId uuid.UUID, request *dto.CategoryRequest) (*model.Category, *dto.ServiceResponse)
+	DeleteCategory(ctx context.Context, categoryId uuid.UUID, reassignTo *uuid.UUID) *dto.ServiceResponse
+}
+
+type categoryService struct {
+	repo repository.CategoryRepository
+}
+
+func NewCategoryService(repo repository.CategoryRepository) CategoryService {
+	return &categoryService{repo: repo}
+}
+
+func (c *categoryService) GetTree(ctx context.Context) ([]*dto.CategoryNode, *dto.ServiceResponse) {
+	return c.repo.GetTree(ctx)
+}
+
+func (c *categoryService) GetCategoryById(ctx context.Context, categoryId uuid.UUID) (*model.Category, *dto.ServiceResponse) {
+	if categoryId == uuid.Nil {
+		return nil, &dto.ServiceResponse{
+			Status: 404,
+			Err:    ErrNotFound,
+		}
+	}
+
+	return c.repo.GetCategoryById(ctx, categoryId)
+}
+
+func (c *categoryService) CreateCategory(ctx context.Context, request *dto.CategoryRequest) (*model.Category, *dto.ServiceResponse) {
+	if errResp := validateCategoryRequest(request); errResp != nil {
+		return nil, errResp
+	}
+
+	return c.repo.CreateCategory(ctx, request)
+}
+
+func (c *categoryService) UpdateCategory(ctx context.Context, categoryId uuid.UUID, request *dto.CategoryRequest) (*model.Category, *dto.ServiceResponse) {
+	if categoryId == uuid.Nil {
+		return nil, &dto.ServiceResponse{
+			Status: 404,
+			Err:    ErrNotFound,
+		}
+	}
+	if errResp := validateCategoryRequest(request); errResp != nil {
+		return nil, errResp
+	}
+	if request.ParentID != nil && *request.ParentID == categoryId {
+		return nil, &dto.ServiceResponse{
+			Status: 400,
+			Err:    ErrCategoryParent,
+		}
+	}
+
+	return c.repo.UpdateCategory(ctx, categoryId, request)
+}
+
+func (c *categoryService) DeleteCategory(ctx context.Context, categoryId uuid.UUID, reassignTo *uuid.UUID) *dto.ServiceResponse {
+	if categoryId == uuid.Nil {
+		return &dto.ServiceResponse{
+			Status: 404,
+			Err:    ErrNotFound,
+		}
+	}
+
+	return c.repo.DeleteCategory(ctx, categoryId, reassignTo)
+}
+
+// validateCategoryRequest trim name tại chỗ, parent_id là uuid rỗng được coi như danh mục gốc
+func validateCategoryRequest(request *dto.CategoryRequest) *dto.ServiceResponse {
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" || utf8.RuneCountInString(request.Name) > maxProductNameLength {
+		return &dto.ServiceResponse{
+			Status: 400,
+			Err:    ErrCategoryName,
+		}
+	}
+	if request.ParentID != nil && *request.ParentID == uuid.Nil {
+		request.ParentID = nil
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"productservice/internal/dto"
+	"productservice/internal/model"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCategoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) GetTree(ctx context.Context) ([]*dto.CategoryNode, *dto.ServiceResponse) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(*dto.ServiceResponse)
+	}
+	return args.Get(0).([]*dto.CategoryNode), args.Get(1).(*dto.ServiceResponse)
+}
+
+func (m *MockCategoryRepository) GetCategoryById(ctx context.Context, categoryId uuid.UUID) (*model.Category, *dto.ServiceResponse) {
+	args := m.Called(ctx, categoryId)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(*dto.ServiceResponse)
+	}
+	return args.Get(0).(*model.Category), args.Get(1).(*dto.ServiceResponse)
+}
+
+func (m *MockCategoryRepository) CreateCategory(ctx context.Context, request *dto.CategoryRequest) (*model.Category, *dto.ServiceResponse) {
+	args := m.Called(ctx, request)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(*dto.ServiceResponse)
+	}
+	return args.Get(0).(*model.Category), args.Get(1).(*dto.ServiceResponse)
+}
+
+func (m *MockCategoryRepository) UpdateCategory(ctx context.Context, categoryId uuid.UUID, request *dto.CategoryRequest) (*model.Category, *dto.ServiceResponse) {
+	args := m.Called(ctx, categoryId, request)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(*dto.ServiceResponse)
+	}
+	return args.Get(0).(*model.Category), args.Get(1).(*dto.ServiceResponse)
+}
+
+func (m *MockCategoryRepository) DeleteCategory(ctx context.Context, categoryId uuid.UUID, reassignTo *uuid.UUID) *dto.ServiceResponse {
+	args := m.Called(ctx, categoryId, reassignTo)
+	return args.Get(0).(*dto.ServiceResponse)
+}
+
+func TestCreateCategory(t *testing.T) {
+	nilParent := uuid.Nil
+
+	tests := []struct {
+		name           string
+		request        *dto.CategoryRequest
+		shouldCallRepo bool
+		expectedErr    error
+	}{
+		{
+			name:           "Success - Nil Parent Becomes Root",
+			request:        &dto.CategoryRequest{Name: " Cà phê ", ParentID: &nilParent},
+			shouldCallRepo: true,
+		},
+		{
+			name:        "Error - Empty Name",
+			request:     &dto.CategoryRequest{Name: "  "},
+			expectedErr: ErrCategoryName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockCategoryRepository)
+			service := NewCategoryService(mockRepo)
+
+			if tt.shouldCallRepo {
+				mockRepo.On("CreateCategory", mock.Anything, mock.MatchedBy(func(req *dto.CategoryRequest) bool {
+					return req.Name == "Cà phê" && req.ParentID == nil
+				})).Return(&model.Category{ID: uuid.New()}, (*dto.ServiceResponse)(nil))
+			}
+
+			category, serviceResp := service.CreateCategory(context.Background(), tt.request)
+
+			if tt.expectedErr != nil {
+				assert.Nil(t, category)
+				assert.NotNil(t, serviceResp)
+				assert.Equal(t, 400, serviceResp.Status)
+				assert.Equal(t, tt.expectedErr, serviceResp.Err)
+				mockRepo.AssertNotCalled(t, "CreateCategory")
+			} else {
+				assert.NotNil(t, category)
+				assert.Nil(t, serviceResp)
+				mockRepo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestUpdateCategoryOwnParent(t *testing.T) {
+	categoryId := uuid.New()
+	mockRepo := new(MockCategoryRepository)
+	service := NewCategoryService(mockRepo)
+
+	category, serviceResp := service.UpdateCategory(context.Background(), categoryId, &dto.CategoryRequest{
+		Name:     "Cà phê",
+		ParentID: &categoryId,
+	})
+
+	assert.Nil(t, category)
+	assert.NotNil(t, serviceResp)
+	assert.Equal(t, 400, serviceResp.Status)
+	assert.Equal(t, ErrCategoryParent, serviceResp.Err)
+	mockRepo.AssertNotCalled(t, "UpdateCategory")
+}
@@ -5,4 +5,24 @@ import "errors"
 var (
 	ErrInvalid  = errors.New("Giá trị lọc không hợp lệ")
 	ErrNotFound = errors.New("Không tìm thấy dữ liệu")
+
+	ErrProductName     = errors.New("Tên sản phẩm không được để trống và tối đa 255 ký tự")
+	ErrProductPrice    = errors.New("Giá sản phẩm phải lớn hơn 0 và nhỏ hơn 10 tỷ")
+	ErrProductCategory = errors.New("Danh mục sản phẩm không hợp lệ")
+	ErrNothingToUpdate = errors.New("Không có dữ liệu cần cập nhật")
+
+	ErrCategoryName   = errors.New("Tên danh mục không được để trống và tối đa 255 ký tự")
+	ErrCategoryParent = errors.New("Danh mục cha không được là chính nó")
+
+	ErrImportFormat            = errors.New("Định dạng file không được hỗ trợ, dùng csv hoặc ndjson")
+	ErrImportHeader            = errors.New("Header CSV thiếu cột bắt buộc")
+	ErrImportRead              = errors.New("Không đọc được file import")
+	ErrImportRowFormat         = errors.New("Dòng không đúng định dạng")
+	ErrImportPrice             = errors.New("Giá không phải là số")
+	ErrImportSKU               = errors.New("SKU không được để trống và tối đa 64 ký tự")
+	ErrImportDuplicateSKU      = errors.New("SKU trùng với một dòng trước đó")
+	ErrImportCategory          = errors.New("Không tìm thấy danh mục")
+	ErrImportCategoryAmbiguous = errors.New("Có nhiều danh mục cùng tên, hãy dùng id của danh mục")
+	ErrImportSave              = errors.New("Không lưu được sản phẩm")
+	ErrImportInterrupted       = errors.New("Import bị dừng do service tắt, chạy lại file để tiếp tục")
 )
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"productservice/internal/dto"
+	"strconv"
+	"strings"
+)
+
+// maxImportLineSize là độ dài tối đa của một dòng NDJSON
+const maxImportLineSize = 1 << 20
+
+// importColumns là các cột bắt buộc của CSV, cũng là tên trường của NDJSON. Cột description không bắt buộc.
+var importColumns = []string{"sku", "name", "price", "category"}
+
+// importRow là một dòng đã đọc từ file, chưa kiểm tra nghiệp vụ
+type importRow struct {
+	line        int
+	sku         string
+	name        string
+	description *string
+	price       float64
+	category    string
+}
+
+// importRowError là lỗi của riêng một dòng, các dòng sau vẫn được đọc tiếp
+type importRowError struct {
+	line int
+	sku  string
+	err  error
+}
+
+func (e *importRowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+func (e *importRowError) Unwrap() error {
+	return e.err
+}
+
+// importReader đọc lần lượt từng dòng mà không nạp cả file vào bộ nhớ.
+// Next trả về io.EOF khi hết file và *importRowError khi chỉ dòng hiện tại bị lỗi.
+type importReader interface {
+	Next() (*importRow, error)
+}
+
+func newImportReader(format dto.ImportFormat, r io.Reader) (importReader, error) {
+	switch format {
+	case dto.ImportFormat_CSV:
+		return newCSVImportReader(r)
+	case dto.ImportFormat_NDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+		return &ndjsonImportReader{scanner: scanner}, nil
+	}
+	return nil, ErrImportFormat
+}
+
+type csvImportReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImportHeader, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Excel thường thêm BOM vào đầu file UTF-8
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrImportHeader, name)
+		}
+	}
+	return &csvImportReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvImportReader) Next() (*importRow, error) {
+	record, err := c.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &importRowError{line: parseErr.StartLine, err: ErrImportRowFormat}
+		}
+		return nil, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	row := &importRow{
+		line:     line,
+		sku:      c.field(record, "sku"),
+		name:     c.field(record, "name"),
+		category: c.field(record, "category"),
+	}
+	if description := c.field(record, "description"); description != "" {
+		row.description = &description
+	}
+	price, err := strconv.ParseFloat(c.field(record, "price"), 64)
+	if err != nil {
+		return nil, &importRowError{line: line, sku: row.sku, err: ErrImportPrice}
+	}
+	row.price = price
+	return row, nil
+}
+
+func (c *csvImportReader) field(record []string, name string) string {
+	i, ok := c.columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+type ndjsonImportRow struct {
+	SKU         string      `json:"sku"`
+	Name        string      `json:"name"`
+	Description *string     `json:"description"`
+	Price       json.Number `json:"price"`
+	Category    string      `json:"category"`
+}
+
+func (n *ndjsonImportReader) Next() (*importRow, error) {
+	for n.scanner.Scan() {
+		n.line++
+		data := bytes.TrimSpace(n.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var value ndjsonImportRow
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, &importRowError{line: n.line, err: ErrImportRowFormat}
+		}
+		row := &importRow{
+			line:        n.line,
+			sku:         strings.TrimSpace(value.SKU),
+			name:        strings.TrimSpace(value.Name),
+			description: value.Description,
+			category:    strings.TrimSpace(value.Category),
+		}
+		price, err := value.Price.Float64()
+		if err != nil {
+			return nil, &importRowError{line: n.line, sku: row.sku, err: ErrImportPrice}
+		}
+		row.price = price
+		return row, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
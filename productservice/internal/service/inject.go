@@ -2,4 +2,4 @@ package service
 
 import "github.com/google/wire"
 
-var Set = wire.NewSet(NewProductService, NewRateService)
+var Set = wire.NewSet(NewProductService, NewRateService, NewCategoryService, NewProductImporter, NewUserEventConsumer)
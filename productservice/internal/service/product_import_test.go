@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"productservice/config"
+	"productservice/internal/dto"
+	"productservice/internal/model"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeImportRepository giữ sản phẩm theo SKU trong bộ nhớ, failBatch làm lỗi mọi lần upsert nhiều dòng
+// và failSKU làm lỗi lần upsert có SKU đó
+type fakeImportRepository struct {
+	mu          sync.Mutex
+	categories  []model.Category
+	products    map[string]model.Product
+	jobs        map[uuid.UUID]dto.ImportJob
+	failBatch   bool
+	failSKU     string
+	invalidated int
+}
+
+func newFakeImportRepository(categories ...model.Category) *fakeImportRepository {
+	return &fakeImportRepository{
+		categories: categories,
+		products:   make(map[string]model.Product),
+		jobs:       make(map[uuid.UUID]dto.ImportJob),
+	}
+}
+
+func (f *fakeImportRepository) Categories(ctx context.Context) ([]model.Category, error) {
+	return f.categories, nil
+}
+
+func (f *fakeImportRepository) UpsertBySKU(ctx context.Context, products []model.Product) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failBatch && len(products) > 1 {
+		return 0, errors.New("batch failed")
+	}
+	for _, product := range products {
+		if *product.SKU == f.failSKU {
+			return 0, errors.New("row failed")
+		}
+	}
+	created := 0
+	for _, product := range products {
+		if _, ok := f.products[*product.SKU]; !ok {
+			created++
+		}
+		f.products[*product.SKU] = product
+	}
+	return created, nil
+}
+
+func (f *fakeImportRepository) InvalidateProductCache(ctx context.Context) {
+	f.invalidated++
+}
+
+func (f *fakeImportRepository) SaveJob(ctx context.Context, job *dto.ImportJob, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.jobs[job.ID] = *job
+	return nil
+}
+
+func (f *fakeImportRepository) GetJob(ctx context.Context, jobId uuid.UUID) (*dto.ImportJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	job, ok := f.jobs[jobId]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &job, nil
+}
+
+var (
+	drinkCategory = model.Category{ID: uuid.New(), Name: "Đồ uống"}
+	cakeCategory  = model.Category{ID: uuid.New(), Name: "Bánh"}
+	// cùng tên với cakeCategory ở nhánh khác của cây
+	otherCakeCategory = model.Category{ID: uuid.New(), Name: "bánh"}
+)
+
+func newTestImporter(t *testing.T, repo *fakeImportRepository, batchSize int) *ProductImporter {
+	importer, cleanup := NewProductImporter(repo, &config.Config{Import: config.ImportConfig{BatchSize: batchSize, MaxReportedErrors: 10}})
+	t.Cleanup(cleanup)
+	return importer
+}
+
+func runImport(t *testing.T, importer *ProductImporter, format dto.ImportFormat, input string) *dto.ImportJob {
+	job, errResp := importer.NewJob(context.Background(), &dto.ImportRequest{Format: format, Size: int64(len(input))})
+	require.Nil(t, errResp)
+	require.NoError(t, importer.Run(context.Background(), job, strings.NewReader(input)))
+	return job
+}
+
+func TestImportCSV(t *testing.T) {
+	repo := newFakeImportRepository(drinkCategory, cakeCategory, otherCakeCategory)
+	importer := newTestImporter(t, repo, 2)
+
+	input := "\ufeffSKU,Name,Description,Price,Category\n" +
+		"CF-01,Cà Phê Sữa,Đậm vị,25000,do uong\n" +
+		"CF-02,Trà đào,,15000," + drinkCategory.ID.String() + "\n" +
+		"CF-03,Bánh mì,,abc,Đồ uống\n" +
+		",Không SKU,,10000,Đồ uống\n" +
+		"CF-04,Bánh bông lan,,30000,Bánh\n" +
+		"CF-05,Kẹo,,5000,Không có\n" +
+		"CF-01,Cà phê đen,,20000,Đồ uống\n" +
+		"CF-06,Nước suối,,-1,Đồ uống\n"
+	job := runImport(t, importer, dto.ImportFormat_CSV, input)
+
+	assert.Equal(t, dto.ImportJob_Completed, job.Status)
+	assert.Equal(t, float64(100), job.Progress)
+	assert.Equal(t, 8, job.ProcessedRows)
+	assert.Equal(t, 2, job.Created)
+	assert.Equal(t, 0, job.Updated)
+	assert.Equal(t, 6, job.Failed)
+	assert.Equal(t, []dto.ImportRowError{
+		{Row: 4, SKU: "CF-03", Error: ErrImportPrice.Error()},
+		{Row: 5, SKU: "", Error: ErrImportSKU.Error()},
+		{Row: 6, SKU: "CF-04", Error: ErrImportCategoryAmbiguous.Error()},
+		{Row: 7, SKU: "CF-05", Error: ErrImportCategory.Error()},
+		{Row: 8, SKU: "CF-01", Error: ErrImportDuplicateSKU.Error() + " (dòng 2)"},
+		{Row: 9, SKU: "CF-06", Error: ErrProductPrice.Error()},
+	}, job.Errors)
+
+	product := repo.products["CF-01"]
+	assert.Equal(t, "Cà Phê Sữa", product.Name)
+	assert.Equal(t, "ca phe sua", product.SearchName)
+	assert.Equal(t, drinkCategory.ID, product.CategoryID)
+	assert.Nil(t, repo.products["CF-02"].Description)
+	assert.Equal(t, 1, repo.invalidated)
+
+	saved, errResp := importer.GetJob(context.Background(), job.ID)
+	require.Nil(t, errResp)
+	assert.Equal(t, dto.ImportJob_Completed, saved.Status)
+}
+
+func TestImportRerunIsIdempotent(t *testing.T) {
+	repo := newFakeImportRepository(drinkCategory)
+	importer := newTestImporter(t, repo, 2)
+
+	input := `{"sku":"CF-01","name":"Cà phê","price":25000,"category":"Đồ uống"}
+{"sku":"CF-02","name":"Trà","description":"Trà xanh","price":"15000.5","category":"Đồ uống"}
+
+{"sku":"CF-03","name":"Bánh",
+{"sku":"CF-04","name":"Sữa","price":12000,"category":"` + drinkCategory.ID.String() + `"}
+`
+	first := runImport(t, importer, dto.ImportFormat_NDJSON, input)
+	assert.Equal(t, 3, first.Created)
+	assert.Equal(t, 0, first.Updated)
+	assert.Equal(t, []dto.ImportRowError{{Row: 4, Error: ErrImportRowFormat.Error()}}, first.Errors)
+	assert.Equal(t, 15000.5, repo.products["CF-02"].Price)
+
+	second := runImport(t, importer, dto.ImportFormat_NDJSON, input)
+	assert.Equal(t, 0, second.Created)
+	assert.Equal(t, 3, second.Updated)
+	assert.Len(t, repo.products, 3)
+}
+
+func TestImportBatchFailureFallsBackToRows(t *testing.T) {
+	repo := newFakeImportRepository(drinkCategory)
+	repo.failBatch = true
+	repo.failSKU = "CF-02"
+	importer := newTestImporter(t, repo, 3)
+
+	input := "sku,name,price,category\n" +
+		"CF-01,Cà phê,25000,Đồ uống\n" +
+		"CF-02,Trà,15000,Đồ uống\n" +
+		"CF-03,Sữa,12000,Đồ uống\n"
+	job := runImport(t, importer, dto.ImportFormat_CSV, input)
+
+	assert.Equal(t, dto.ImportJob_Completed, job.Status)
+	assert.Equal(t, 2, job.Created)
+	assert.Equal(t, []dto.ImportRowError{{Row: 3, SKU: "CF-02", Error: ErrImportSave.Error()}}, job.Errors)
+}
+
+func TestImportErrors(t *testing.T) {
+	repo := newFakeImportRepository(drinkCategory)
+	importer := newTestImporter(t, repo, 2)
+
+	t.Run("Error - Unknown Format", func(t *testing.T) {
+		_, errResp := importer.NewJob(context.Background(), &dto.ImportRequest{Source: "products.xlsx"})
+		require.NotNil(t, errResp)
+		assert.Equal(t, 400, errResp.Status)
+		assert.Equal(t, ErrImportFormat, errResp.Err)
+	})
+
+	t.Run("Error - Missing Column", func(t *testing.T) {
+		job, errResp := importer.NewJob(context.Background(), &dto.ImportRequest{Source: "products.CSV"})
+		require.Nil(t, errResp)
+		assert.Equal(t, dto.ImportFormat_CSV, job.Format)
+
+		err := importer.Run(context.Background(), job, strings.NewReader("sku,name,price\nCF-01,Cà phê,25000\n"))
+		assert.ErrorIs(t, err, ErrImportHeader)
+		assert.Equal(t, dto.ImportJob_Failed, job.Status)
+		assert.NotEmpty(t, job.Error)
+	})
+
+	t.Run("Error - Too Many Errors Are Truncated", func(t *testing.T) {
+		var input strings.Builder
+		input.WriteString("sku,name,price,category\n")
+		for range 15 {
+			input.WriteString("CF-01,Cà phê,abc,Đồ uống\n")
+		}
+		job := runImport(t, importer, dto.ImportFormat_CSV, input.String())
+		assert.Equal(t, 15, job.Failed)
+		assert.Len(t, job.Errors, 10)
+		assert.True(t, job.ErrorsTruncated)
+	})
+}
+
+// failingReader trả về nội dung rồi lỗi đọc, mô phỏng kết nối upload bị ngắt giữa chừng
+type failingReader struct {
+	reader io.Reader
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.reader.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestImportFailureInvalidatesSavedBatches(t *testing.T) {
+	repo := newFakeImportRepository(drinkCategory)
+	importer := newTestImporter(t, repo, 1)
+
+	input := "sku,name,price,category\n" +
+		"CF-01,Cà phê,25000,Đồ uống\n" +
+		"CF-02,Trà,15000,Đồ uống\n" +
+		"CF-03,Sữa"
+	job, errResp := importer.NewJob(context.Background(), &dto.ImportRequest{Format: dto.ImportFormat_CSV})
+	require.Nil(t, errResp)
+
+	err := importer.Run(context.Background(), job, &failingReader{reader: strings.NewReader(input)})
+
+	assert.ErrorIs(t, err, ErrImportRead)
+	assert.Equal(t, dto.ImportJob_Failed, job.Status)
+	assert.Equal(t, 2, job.Created)
+	assert.Equal(t, 1, repo.invalidated)
+}
+
+func TestImportStartRunsInBackground(t *testing.T) {
+	repo := newFakeImportRepository(drinkCategory)
+	importer := newTestImporter(t, repo, 0)
+
+	input := "sku,name,price,category\nCF-01,Cà phê,25000,Đồ uống\n"
+	job, errResp := importer.Start(context.Background(), strings.NewReader(input), &dto.ImportRequest{Source: "products.csv"})
+	require.Nil(t, errResp)
+	assert.Equal(t, dto.ImportJob_Pending, job.Status)
+	assert.Equal(t, int64(len(input)), job.TotalBytes)
+
+	assert.Eventually(t, func() bool {
+		saved, errResp := importer.GetJob(context.Background(), job.ID)
+		return errResp == nil && saved.Status == dto.ImportJob_Completed && saved.Created == 1
+	}, time.Second, 10*time.Millisecond)
+}
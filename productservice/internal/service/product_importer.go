@@ -0,0 +1,441 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"productservice/config"
+	"productservice/internal/dto"
+	"productservice/internal/model"
+	"productservice/internal/repository"
+	"productservice/internal/utils"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultImportBatchSize = 500
+	defaultImportMaxErrors = 1000
+	defaultImportJobTTL    = 24 * time.Hour
+	maxImportSKULength     = 64
+)
+
+// ProductImporter import sản phẩm từ CSV/NDJSON theo lô và upsert theo SKU,
+// chạy lại cùng một file cho cùng kết quả. Job tạo từ API chạy nền trên instance nhận file,
+// trạng thái được lưu ở redis để client hỏi tiến độ qua instance nào cũng được.
+type ProductImporter struct {
+	repo      repository.ImportRepository
+	batchSize int
+	maxErrors int
+	jobTTL    time.Duration
+
+	// ctx bị hủy khi service tắt, job đang chạy dừng lại và được đánh dấu failed
+	ctx context.Context
+	wg  sync.WaitGroup
+}
+
+// NewProductImporter trả về cleanup chờ các job nền ghi xong trạng thái cuối
+func NewProductImporter(repo repository.ImportRepository, cfg *config.Config) (*ProductImporter, func()) {
+	importCfg := cfg.Import
+	i := &ProductImporter{
+		repo:      repo,
+		batchSize: importCfg.BatchSize,
+		maxErrors: importCfg.MaxReportedErrors,
+		jobTTL:    importCfg.JobTTL,
+	}
+	if i.batchSize <= 0 {
+		i.batchSize = defaultImportBatchSize
+	}
+	if i.maxErrors <= 0 {
+		i.maxErrors = defaultImportMaxErrors
+	}
+	if i.jobTTL <= 0 {
+		i.jobTTL = defaultImportJobTTL
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	i.ctx = ctx
+	return i, func() {
+		cancel()
+		i.wg.Wait()
+	}
+}
+
+// NewJob tạo job ở trạng thái pending, dùng trực tiếp với Run khi import đồng bộ (lệnh import ở cmd)
+func (i *ProductImporter) NewJob(ctx context.Context, request *dto.ImportRequest) (*dto.ImportJob, *dto.ServiceResponse) {
+	format, errResp := importFormat(request)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	job := &dto.ImportJob{
+		ID:         uuid.New(),
+		Status:     dto.ImportJob_Pending,
+		Format:     format,
+		Source:     request.Source,
+		TotalBytes: request.Size,
+		Errors:     []dto.ImportRowError{},
+		CreatedAt:  time.Now(),
+	}
+	if err := i.repo.SaveJob(ctx, job, i.jobTTL); err != nil {
+		log.Errorf("import: save job: %v", err)
+		return nil, &dto.ServiceResponse{
+			Status: 500,
+			Err:    repository.ErrInternalServerError,
+		}
+	}
+	return job, nil
+}
+
+// Start chép r vào file tạm rồi chạy job nền, trả về ngay khi đã chép xong để request không phải chờ import
+func (i *ProductImporter) Start(ctx context.Context, r io.Reader, request *dto.ImportRequest) (*dto.ImportJob, *dto.ServiceResponse) {
+	if _, errResp := importFormat(request); errResp != nil {
+		return nil, errResp
+	}
+
+	file, err := os.CreateTemp("", "product-import-*")
+	if err != nil {
+		log.Errorf("import: create temp file: %v", err)
+		return nil, &dto.ServiceResponse{
+			Status: 500,
+			Err:    repository.ErrInternalServerError,
+		}
+	}
+	discard := func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}
+
+	size, err := io.Copy(file, r)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		discard()
+		log.Errorf("import: copy upload: %v", err)
+		return nil, &dto.ServiceResponse{
+			Status: 500,
+			Err:    repository.ErrInternalServerError,
+		}
+	}
+	request.Size = size
+
+	job, errResp := i.NewJob(ctx, request)
+	if errResp != nil {
+		discard()
+		return nil, errResp
+	}
+	// goroutine nền sửa job, trả về bản sao để caller không đọc cùng lúc
+	snapshot := *job
+
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+		defer discard()
+		if err := i.Run(i.ctx, job, file); err != nil {
+			log.Errorf("import %s failed: %v", job.ID, err)
+		}
+	}()
+	return &snapshot, nil
+}
+
+func (i *ProductImporter) GetJob(ctx context.Context, jobId uuid.UUID) (*dto.ImportJob, *dto.ServiceResponse) {
+	job, err := i.repo.GetJob(ctx, jobId)
+	if errors.Is(err, repository.ErrImportJobNotFound) {
+		return nil, &dto.ServiceResponse{
+			Status: 404,
+			Err:    err,
+		}
+	}
+	if err != nil {
+		log.Errorf("import: get job %s: %v", jobId, err)
+		return nil, &dto.ServiceResponse{
+			Status: 500,
+			Err:    repository.ErrInternalServerError,
+		}
+	}
+	return job, nil
+}
+
+// Run đọc r tới hết và cập nhật job. Lỗi trả về là lỗi làm dừng cả job và đã được ghi vào job.Error,
+// lỗi của từng dòng chỉ nằm trong job.Errors.
+func (i *ProductImporter) Run(ctx context.Context, job *dto.ImportJob, r io.Reader) error {
+	startedAt := time.Now()
+	job.Status = dto.ImportJob_Running
+	job.StartedAt = &startedAt
+	i.saveJob(job)
+
+	err := i.run(ctx, job, r)
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	switch {
+	case ctx.Err() != nil:
+		err = ErrImportInterrupted
+		fallthrough
+	case err != nil:
+		job.Status = dto.ImportJob_Failed
+		job.Error = err.Error()
+	default:
+		job.Status = dto.ImportJob_Completed
+		job.Progress = 100
+	}
+	i.saveJob(job)
+	return err
+}
+
+// importItem là sản phẩm hợp lệ đang chờ upsert, giữ số dòng để báo lỗi khi lưu thất bại
+type importItem struct {
+	line    int
+	product model.Product
+}
+
+func (i *ProductImporter) run(ctx context.Context, job *dto.ImportJob, r io.Reader) error {
+	categories, err := i.repo.Categories(ctx)
+	if err != nil {
+		log.Errorf("import %s: load categories: %v", job.ID, err)
+		return repository.ErrInternalServerError
+	}
+	resolver := newCategoryResolver(categories)
+
+	// các lô đã lưu vẫn nằm trong database khi job lỗi hoặc bị hủy giữa chừng nên cache cũng phải xóa,
+	// không dùng ctx của job vì có thể đã bị hủy
+	defer func() {
+		if job.Created+job.Updated > 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			i.repo.InvalidateProductCache(ctx)
+		}
+	}()
+
+	counter := &countingReader{reader: r}
+	rows, err := newImportReader(job.Format, counter)
+	if err != nil {
+		return err
+	}
+
+	// SKU đã nhận -> số dòng, dòng trùng SKU phía sau bị bỏ qua
+	seen := make(map[string]int)
+	batch := make([]importItem, 0, i.batchSize)
+	for ctx.Err() == nil {
+		row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *importRowError
+		if errors.As(err, &rowErr) {
+			job.ProcessedRows++
+			i.addRowError(job, rowErr.line, rowErr.sku, rowErr.err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrImportRead, err)
+		}
+
+		job.ProcessedRows++
+		product, err := toImportProduct(row, resolver)
+		if err != nil {
+			i.addRowError(job, row.line, row.sku, err)
+			continue
+		}
+		if line, ok := seen[row.sku]; ok {
+			i.addRowError(job, row.line, row.sku, fmt.Errorf("%w (dòng %d)", ErrImportDuplicateSKU, line))
+			continue
+		}
+		seen[row.sku] = row.line
+
+		batch = append(batch, importItem{line: row.line, product: *product})
+		if len(batch) >= i.batchSize {
+			if err := i.flush(ctx, job, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+			i.reportProgress(job, counter.n)
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := i.flush(ctx, job, batch); err != nil {
+		return err
+	}
+	job.ReadBytes = counter.n
+	return nil
+}
+
+// flush upsert cả lô trong một câu lệnh, lỗi thì upsert lại từng dòng để chỉ ra dòng không lưu được
+func (i *ProductImporter) flush(ctx context.Context, job *dto.ImportJob, batch []importItem) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	products := make([]model.Product, 0, len(batch))
+	for _, item := range batch {
+		products = append(products, item.product)
+	}
+	created, err := i.repo.UpsertBySKU(ctx, products)
+	if err == nil {
+		job.Created += created
+		job.Updated += len(batch) - created
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	log.Warnf("import %s: batch upsert failed, retrying row by row: %v", job.ID, err)
+	for _, item := range batch {
+		created, err := i.repo.UpsertBySKU(ctx, []model.Product{item.product})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Warnf("import %s: line %d: %v", job.ID, item.line, err)
+			i.addRowError(job, item.line, *item.product.SKU, ErrImportSave)
+			continue
+		}
+		job.Created += created
+		job.Updated += 1 - created
+	}
+	return nil
+}
+
+func (i *ProductImporter) addRowError(job *dto.ImportJob, line int, sku string, err error) {
+	job.Failed++
+	if len(job.Errors) >= i.maxErrors {
+		job.ErrorsTruncated = true
+		return
+	}
+	job.Errors = append(job.Errors, dto.ImportRowError{Row: line, SKU: sku, Error: err.Error()})
+}
+
+// reportProgress tính tiến độ theo số byte đã đọc. Reader đọc trước một đoạn nên chỉ báo tối đa 99%
+// cho tới khi job xong.
+func (i *ProductImporter) reportProgress(job *dto.ImportJob, readBytes int64) {
+	job.ReadBytes = readBytes
+	if job.TotalBytes > 0 {
+		job.Progress = math.Min(99, math.Round(float64(readBytes)*1000/float64(job.TotalBytes))/10)
+	}
+	log.Infof("import %s: %d rows processed (%.1f%%)", job.ID, job.ProcessedRows, job.Progress)
+	i.saveJob(job)
+}
+
+// saveJob không dùng ctx của job để vẫn ghi được trạng thái cuối khi job bị hủy
+func (i *ProductImporter) saveJob(job *dto.ImportJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := i.repo.SaveJob(ctx, job, i.jobTTL); err != nil {
+		log.Errorf("import %s: save job: %v", job.ID, err)
+	}
+}
+
+func toImportProduct(row *importRow, categories *categoryResolver) (*model.Product, error) {
+	if row.sku == "" || utf8.RuneCountInString(row.sku) > maxImportSKULength {
+		return nil, ErrImportSKU
+	}
+	categoryId, err := categories.resolve(row.category)
+	if err != nil {
+		return nil, err
+	}
+
+	request := dto.ProductRequest{
+		Name:        row.name,
+		Description: row.description,
+		Price:       row.price,
+		CategoryID:  categoryId,
+	}
+	if errResp := validateProductRequest(&request); errResp != nil {
+		return nil, errResp.Err
+	}
+	if request.Description != nil && strings.TrimSpace(*request.Description) == "" {
+		request.Description = nil
+	}
+
+	sku := row.sku
+	return &model.Product{
+		SKU:         &sku,
+		Name:        request.Name,
+		SearchName:  utils.NormalizeSearchText(request.Name),
+		Description: request.Description,
+		Price:       request.Price,
+		CategoryID:  request.CategoryID,
+	}, nil
+}
+
+// importFormat lấy format từ request, không có thì suy ra từ đuôi file
+func importFormat(request *dto.ImportRequest) (dto.ImportFormat, *dto.ServiceResponse) {
+	format := dto.ImportFormat(strings.ToLower(string(request.Format)))
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(request.Source)) {
+		case ".csv":
+			format = dto.ImportFormat_CSV
+		case ".ndjson", ".jsonl":
+			format = dto.ImportFormat_NDJSON
+		}
+	}
+	if format != dto.ImportFormat_CSV && format != dto.ImportFormat_NDJSON {
+		return "", &dto.ServiceResponse{
+			Status: 400,
+			Err:    ErrImportFormat,
+		}
+	}
+	return format, nil
+}
+
+// categoryResolver tìm danh mục theo id hoặc tên. Tên so khớp không phân biệt hoa thường và dấu,
+// trùng nhiều danh mục (vd cùng tên ở hai nhánh của cây) thì phải dùng id.
+type categoryResolver struct {
+	ids    map[uuid.UUID]bool
+	byName map[string][]uuid.UUID
+}
+
+func newCategoryResolver(categories []model.Category) *categoryResolver {
+	resolver := &categoryResolver{
+		ids:    make(map[uuid.UUID]bool, len(categories)),
+		byName: make(map[string][]uuid.UUID, len(categories)),
+	}
+	for _, category := range categories {
+		resolver.ids[category.ID] = true
+		name := utils.NormalizeSearchText(category.Name)
+		resolver.byName[name] = append(resolver.byName[name], category.ID)
+	}
+	return resolver
+}
+
+func (c *categoryResolver) resolve(value string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(value); err == nil {
+		if !c.ids[id] {
+			return uuid.Nil, ErrImportCategory
+		}
+		return id, nil
+	}
+
+	matches := c.byName[utils.NormalizeSearchText(value)]
+	switch {
+	case value == "" || len(matches) == 0:
+		return uuid.Nil, ErrImportCategory
+	case len(matches) > 1:
+		return uuid.Nil, ErrImportCategoryAmbiguous
+	}
+	return matches[0], nil
+}
+
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -7,6 +7,7 @@ import (
 	"productservice/internal/repository"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
@@ -15,8 +16,19 @@ type ProductService interface {
 	GetList(ctx context.Context, request *dto.PageProdRequest) (*dto.PageResponse, *dto.ServiceResponse)
 	GetProductById(ctx context.Context, productId uuid.UUID) (*model.Product, *dto.ServiceResponse)
 	GetProductSimilar(ctx context.Context, productId uuid.UUID, pageRequest *dto.PageSimilarAndRelatedRequest, relationType *dto.RelationType) (*dto.PageResponse, *dto.ServiceResponse)
+	CreateProduct(ctx context.Context, request *dto.ProductRequest) (*model.Product, *dto.ServiceResponse)
+	ReplaceProduct(ctx context.Context, productId uuid.UUID, request *dto.ProductRequest) (*model.Product, *dto.ServiceResponse)
+	PatchProduct(ctx context.Context, productId uuid.UUID, request *dto.PatchProductRequest) (*model.Product, *dto.ServiceResponse)
+	DeleteProduct(ctx context.Context, productId uuid.UUID) *dto.ServiceResponse
+	RestoreProduct(ctx context.Context, productId uuid.UUID) (*model.Product, *dto.ServiceResponse)
 }
 
+const (
+	maxProductNameLength = 255
+	// price là NUMERIC(12,2)
+	maxProductPrice = 1e10
+)
+
 type productService struct {
 	repo repository.ProductRepository
 }
@@ -129,3 +141,132 @@ func (p *productService) GetList(ctx context.Context, request *dto.PageProdReque
 
 	return p.repo.GetList(ctx, request)
 }
+
+func (p *productService) CreateProduct(ctx context.Context, request *dto.ProductRequest) (*model.Product, *dto.ServiceResponse) {
+	if errResp := validateProductRequest(request); errResp != nil {
+		return nil, errResp
+	}
+
+	return p.repo.CreateProduct(ctx, request)
+}
+
+// ReplaceProduct ghi đè toàn bộ thông tin sản phẩm, mô tả không gửi lên sẽ bị xóa
+func (p *productService) ReplaceProduct(ctx context.Context, productId uuid.UUID, request *dto.ProductRequest) (*model.Product, *dto.ServiceResponse) {
+	if productId == uuid.Nil {
+		return nil, &dto.ServiceResponse{
+			Status: 404,
+			Err:    ErrNotFound,
+		}
+	}
+	if errResp := validateProductRequest(request); errResp != nil {
+		return nil, errResp
+	}
+
+	description := ""
+	if request.Description != nil {
+		description = *request.Description
+	}
+	return p.repo.UpdateProduct(ctx, productId, &dto.PatchProductRequest{
+		Name:        &request.Name,
+		Description: &description,
+		Price:       &request.Price,
+		CategoryID:  &request.CategoryID,
+	})
+}
+
+func (p *productService) PatchProduct(ctx context.Context, productId uuid.UUID, request *dto.PatchProductRequest) (*model.Product, *dto.ServiceResponse) {
+	if productId == uuid.Nil {
+		return nil, &dto.ServiceResponse{
+			Status: 404,
+			Err:    ErrNotFound,
+		}
+	}
+	if request.Name == nil && request.Description == nil && request.Price == nil && request.CategoryID == nil {
+		return nil, &dto.ServiceResponse{
+			Status: 400,
+			Err:    ErrNothingToUpdate,
+		}
+	}
+
+	if request.Name != nil {
+		name := strings.TrimSpace(*request.Name)
+		if errResp := validateProductName(name); errResp != nil {
+			return nil, errResp
+		}
+		request.Name = &name
+	}
+	if request.Price != nil {
+		if errResp := validateProductPrice(*request.Price); errResp != nil {
+			return nil, errResp
+		}
+	}
+	if request.CategoryID != nil && *request.CategoryID == uuid.Nil {
+		return nil, &dto.ServiceResponse{
+			Status: 400,
+			Err:    ErrProductCategory,
+		}
+	}
+
+	return p.repo.UpdateProduct(ctx, productId, request)
+}
+
+func (p *productService) DeleteProduct(ctx context.Context, productId uuid.UUID) *dto.ServiceResponse {
+	if productId == uuid.Nil {
+		return &dto.ServiceResponse{
+			Status: 404,
+			Err:    ErrNotFound,
+		}
+	}
+
+	return p.repo.DeleteProduct(ctx, productId)
+}
+
+func (p *productService) RestoreProduct(ctx context.Context, productId uuid.UUID) (*model.Product, *dto.ServiceResponse) {
+	if productId == uuid.Nil {
+		return nil, &dto.ServiceResponse{
+			Status: 404,
+			Err:    ErrNotFound,
+		}
+	}
+
+	return p.repo.RestoreProduct(ctx, productId)
+}
+
+// validateProductRequest kiểm tra dữ liệu đầy đủ của POST/PUT, name được trim tại chỗ
+func validateProductRequest(request *dto.ProductRequest) *dto.ServiceResponse {
+	request.Name = strings.TrimSpace(request.Name)
+	if errResp := validateProductName(request.Name); errResp != nil {
+		return errResp
+	}
+	if errResp := validateProductPrice(request.Price); errResp != nil {
+		return errResp
+	}
+	if request.CategoryID == uuid.Nil {
+		return &dto.ServiceResponse{
+			Status: 400,
+			Err:    ErrProductCategory,
+		}
+	}
+	return nil
+}
+
+func validateProductName(name string) *dto.ServiceResponse {
+	if name == "" || utf8.RuneCountInString(name) > maxProductNameLength {
+		return &dto.ServiceResponse{
+			Status: 400,
+			Err:    ErrProductName,
+		}
+	}
+	return nil
+}
+
+func validateProductPrice(price float64) *dto.ServiceResponse {
+	// viết dạng phủ định để NaN cũng bị từ chối
+	if !(price > 0 && price < maxProductPrice) {
+		return &dto.ServiceResponse{
+			Status: 400,
+			Err:    ErrProductPrice,
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"productservice/internal/dto"
 	"productservice/internal/model"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -44,6 +45,35 @@ func (p *MockRepository) GetList(ctx context.Context, request *dto.PageProdReque
 	return args.Get(0).(*dto.PageResponse), args.Get(1).(*dto.ServiceResponse)
 }
 
+func (p *MockRepository) CreateProduct(ctx context.Context, request *dto.ProductRequest) (*model.Product, *dto.ServiceResponse) {
+	args := p.Called(ctx, request)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(*dto.ServiceResponse)
+	}
+	return args.Get(0).(*model.Product), args.Get(1).(*dto.ServiceResponse)
+}
+
+func (p *MockRepository) UpdateProduct(ctx context.Context, productId uuid.UUID, request *dto.PatchProductRequest) (*model.Product, *dto.ServiceResponse) {
+	args := p.Called(ctx, productId, request)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(*dto.ServiceResponse)
+	}
+	return args.Get(0).(*model.Product), args.Get(1).(*dto.ServiceResponse)
+}
+
+func (p *MockRepository) DeleteProduct(ctx context.Context, productId uuid.UUID) *dto.ServiceResponse {
+	args := p.Called(ctx, productId)
+	return args.Get(0).(*dto.ServiceResponse)
+}
+
+func (p *MockRepository) RestoreProduct(ctx context.Context, productId uuid.UUID) (*model.Product, *dto.ServiceResponse) {
+	args := p.Called(ctx, productId)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(*dto.ServiceResponse)
+	}
+	return args.Get(0).(*model.Product), args.Get(1).(*dto.ServiceResponse)
+}
+
 // === TEST ===
 func TestGetProductById(t *testing.T) {
 	validUUID := uuid.New()
@@ -482,3 +512,179 @@ func TestGetProductSimilar(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateProduct(t *testing.T) {
+	categoryId := uuid.New()
+
+	tests := []struct {
+		name           string
+		request        *dto.ProductRequest
+		shouldCallRepo bool
+		expectedErr    error
+	}{
+		{
+			name:           "Success - Trim Name",
+			request:        &dto.ProductRequest{Name: "  Cà phê sữa  ", Price: 25000, CategoryID: categoryId},
+			shouldCallRepo: true,
+		},
+		{
+			name:        "Error - Empty Name",
+			request:     &dto.ProductRequest{Name: "   ", Price: 25000, CategoryID: categoryId},
+			expectedErr: ErrProductName,
+		},
+		{
+			name:        "Error - Name Too Long",
+			request:     &dto.ProductRequest{Name: strings.Repeat("a", maxProductNameLength+1), Price: 25000, CategoryID: categoryId},
+			expectedErr: ErrProductName,
+		},
+		{
+			name:        "Error - Zero Price",
+			request:     &dto.ProductRequest{Name: "Cà phê", Price: 0, CategoryID: categoryId},
+			expectedErr: ErrProductPrice,
+		},
+		{
+			name:        "Error - Price Overflow",
+			request:     &dto.ProductRequest{Name: "Cà phê", Price: maxProductPrice, CategoryID: categoryId},
+			expectedErr: ErrProductPrice,
+		},
+		{
+			name:        "Error - Missing Category",
+			request:     &dto.ProductRequest{Name: "Cà phê", Price: 25000},
+			expectedErr: ErrProductCategory,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewProductService(mockRepo)
+
+			if tt.shouldCallRepo {
+				mockRepo.On("CreateProduct", mock.Anything, mock.MatchedBy(func(req *dto.ProductRequest) bool {
+					return req.Name == "Cà phê sữa"
+				})).Return(&model.Product{ID: uuid.New()}, (*dto.ServiceResponse)(nil))
+			}
+
+			product, serviceResp := service.CreateProduct(context.Background(), tt.request)
+
+			if tt.expectedErr != nil {
+				assert.Nil(t, product)
+				assert.NotNil(t, serviceResp)
+				assert.Equal(t, 400, serviceResp.Status)
+				assert.Equal(t, tt.expectedErr, serviceResp.Err)
+				mockRepo.AssertNotCalled(t, "CreateProduct")
+			} else {
+				assert.NotNil(t, product)
+				assert.Nil(t, serviceResp)
+				mockRepo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestReplaceProductClearsDescription(t *testing.T) {
+	productId := uuid.New()
+	categoryId := uuid.New()
+	mockRepo := new(MockRepository)
+	service := NewProductService(mockRepo)
+
+	mockRepo.On("UpdateProduct", mock.Anything, productId, mock.MatchedBy(func(req *dto.PatchProductRequest) bool {
+		return *req.Name == "Trà đào" && *req.Description == "" && *req.Price == 30000 && *req.CategoryID == categoryId
+	})).Return(&model.Product{ID: productId}, (*dto.ServiceResponse)(nil))
+
+	product, serviceResp := service.ReplaceProduct(context.Background(), productId, &dto.ProductRequest{
+		Name:       "Trà đào",
+		Price:      30000,
+		CategoryID: categoryId,
+	})
+
+	assert.Nil(t, serviceResp)
+	assert.Equal(t, productId, product.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPatchProduct(t *testing.T) {
+	productId := uuid.New()
+	name := " Trà sữa "
+	blank := " "
+	price := -1.0
+	nilCategory := uuid.Nil
+
+	tests := []struct {
+		name           string
+		productId      uuid.UUID
+		request        *dto.PatchProductRequest
+		shouldCallRepo bool
+		expectedErr    error
+		expectedStatus int
+	}{
+		{
+			name:           "Success - Only Name",
+			productId:      productId,
+			request:        &dto.PatchProductRequest{Name: &name},
+			shouldCallRepo: true,
+		},
+		{
+			name:           "Error - Empty Body",
+			productId:      productId,
+			request:        &dto.PatchProductRequest{},
+			expectedErr:    ErrNothingToUpdate,
+			expectedStatus: 400,
+		},
+		{
+			name:           "Error - Blank Name",
+			productId:      productId,
+			request:        &dto.PatchProductRequest{Name: &blank},
+			expectedErr:    ErrProductName,
+			expectedStatus: 400,
+		},
+		{
+			name:           "Error - Negative Price",
+			productId:      productId,
+			request:        &dto.PatchProductRequest{Price: &price},
+			expectedErr:    ErrProductPrice,
+			expectedStatus: 400,
+		},
+		{
+			name:           "Error - Nil Category",
+			productId:      productId,
+			request:        &dto.PatchProductRequest{CategoryID: &nilCategory},
+			expectedErr:    ErrProductCategory,
+			expectedStatus: 400,
+		},
+		{
+			name:           "Error - Nil Product ID",
+			productId:      uuid.Nil,
+			request:        &dto.PatchProductRequest{Name: &name},
+			expectedErr:    ErrNotFound,
+			expectedStatus: 404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewProductService(mockRepo)
+
+			if tt.shouldCallRepo {
+				mockRepo.On("UpdateProduct", mock.Anything, tt.productId, mock.MatchedBy(func(req *dto.PatchProductRequest) bool {
+					return *req.Name == "Trà sữa" && req.Price == nil && req.Description == nil
+				})).Return(&model.Product{ID: tt.productId}, (*dto.ServiceResponse)(nil))
+			}
+
+			product, serviceResp := service.PatchProduct(context.Background(), tt.productId, tt.request)
+
+			if tt.expectedErr != nil {
+				assert.Nil(t, product)
+				assert.NotNil(t, serviceResp)
+				assert.Equal(t, tt.expectedStatus, serviceResp.Status)
+				assert.Equal(t, tt.expectedErr, serviceResp.Err)
+				mockRepo.AssertNotCalled(t, "UpdateProduct")
+			} else {
+				assert.NotNil(t, product)
+				assert.Nil(t, serviceResp)
+				mockRepo.AssertExpectations(t)
+			}
+		})
+	}
+}
@@ -64,6 +64,16 @@ func (m *MockRateRepository) GetMyRatings(ctx context.Context, userId uuid.UUID,
 	return args.Get(0).(*dto.MyRatingsResponse), args.Get(1).(*dto.ServiceResponse)
 }
 
+func (m *MockRateRepository) InvalidateUserRatings(ctx context.Context, userId uuid.UUID) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (m *MockRateRepository) HideRatingsOfUser(ctx context.Context, userId uuid.UUID) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
 // === TESTS ===
 
 func TestRatingProduct(t *testing.T) {
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"productservice/config"
+	"productservice/internal/grpc/client"
+	"productservice/internal/grpc/pb/userservicepb"
+	"productservice/internal/repository"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+)
+
+const (
+	userEventMinBackoff = time.Second
+	userEventMaxBackoff = 30 * time.Second
+)
+
+type userEventSource interface {
+	WatchUserEvents(ctx context.Context, afterSeq int64, fromNow bool) (grpc.ServerStreamingClient[userservicepb.UserEvent], error)
+}
+
+type userInvalidator interface {
+	InvalidateUser(ctx context.Context, userID string)
+}
+
+// UserEventConsumer nghe WatchUserEvents của userservice để xóa cache có dữ liệu cũ của user
+// và ẩn đánh giá của user đã bị xóa vĩnh viễn. Mỗi sự kiện có thể được xử lý lại sau khi kết nối lại
+// nên mọi thao tác đều phải idempotent.
+type UserEventConsumer struct {
+	source    userEventSource
+	authCache userInvalidator // nil nếu tắt cache Authenticate
+	rateRepo  repository.RateRepository
+	eventRepo repository.UserEventRepository
+}
+
+// NewUserEventConsumer chạy consumer nền tới khi cleanup được gọi.
+// Consumer bị tắt khi server.grpc.auth.service_token chưa được cấu hình.
+func NewUserEventConsumer(authClient *client.AuthClient, authCache *client.AuthCache, rateRepo repository.RateRepository, eventRepo repository.UserEventRepository, cfg *config.Config) (*UserEventConsumer, func()) {
+	c := &UserEventConsumer{source: authClient, rateRepo: rateRepo, eventRepo: eventRepo}
+	if authCache != nil {
+		c.authCache = authCache
+	}
+	if cfg.Server.Grpc.Auth.ServiceToken == "" {
+		log.Info("User event consumer is disabled")
+		return c, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.run(ctx)
+	}()
+	return c, func() {
+		cancel()
+		<-done
+	}
+}
+
+// run kết nối lại với backoff tăng dần, tiếp tục từ seq đã lưu
+func (c *UserEventConsumer) run(ctx context.Context) {
+	backoff := userEventMinBackoff
+	for {
+		handled, err := c.consume(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if handled > 0 {
+			backoff = userEventMinBackoff
+		}
+		log.Warnf("user event stream stopped, reconnecting in %s: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, userEventMaxBackoff)
+	}
+}
+
+// consume xử lý sự kiện tới khi stream lỗi, trả về số sự kiện đã xử lý
+func (c *UserEventConsumer) consume(ctx context.Context) (int, error) {
+	afterSeq, ok, err := c.eventRepo.LastSeq(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// chưa có checkpoint thì cache hiện tại chưa chứa dữ liệu nào cần xóa, bỏ qua lịch sử
+	stream, err := c.source.WatchUserEvents(ctx, afterSeq, !ok)
+	if err != nil {
+		return 0, err
+	}
+
+	handled := 0
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return handled, err
+		}
+		if err := c.handle(ctx, event); err != nil {
+			return handled, err
+		}
+		if err := c.eventRepo.SaveSeq(ctx, event.GetSeq()); err != nil {
+			return handled, err
+		}
+		handled++
+	}
+}
+
+func (c *UserEventConsumer) handle(ctx context.Context, event *userservicepb.UserEvent) error {
+	userId, err := uuid.Parse(event.GetUserId())
+	if err != nil {
+		log.Warnf("invalid user event seq=%d: %v", event.GetSeq(), err)
+		return nil
+	}
+
+	switch event.GetType() {
+	case "created":
+		return nil
+	case "updated":
+		err = c.rateRepo.InvalidateUserRatings(ctx, userId)
+	case "role_changed":
+	case "deleted":
+		if event.GetPurged() {
+			err = c.rateRepo.HideRatingsOfUser(ctx, userId)
+		} else {
+			err = c.rateRepo.InvalidateUserRatings(ctx, userId)
+		}
+	default:
+		log.Warnf("unknown user event type %q seq=%d", event.GetType(), event.GetSeq())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// tên, quyền và trạng thái tài khoản đều nằm trong kết quả Authenticate đã cache
+	if c.authCache != nil {
+		c.authCache.InvalidateUser(ctx, event.GetUserId())
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"productservice/internal/grpc/pb/userservicepb"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+)
+
+type MockUserEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserEventRepository) LastSeq(ctx context.Context) (int64, bool, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUserEventRepository) SaveSeq(ctx context.Context, seq int64) error {
+	args := m.Called(ctx, seq)
+	return args.Error(0)
+}
+
+// fakeEventStream trả lần lượt các sự kiện rồi io.EOF
+type fakeEventStream struct {
+	grpc.ClientStream
+	events []*userservicepb.UserEvent
+}
+
+func (s *fakeEventStream) Recv() (*userservicepb.UserEvent, error) {
+	if len(s.events) == 0 {
+		return nil, io.EOF
+	}
+	event := s.events[0]
+	s.events = s.events[1:]
+	return event, nil
+}
+
+type fakeEventSource struct {
+	events   []*userservicepb.UserEvent
+	afterSeq int64
+	fromNow  bool
+}
+
+func (f *fakeEventSource) WatchUserEvents(ctx context.Context, afterSeq int64, fromNow bool) (grpc.ServerStreamingClient[userservicepb.UserEvent], error) {
+	f.afterSeq, f.fromNow = afterSeq, fromNow
+	return &fakeEventStream{events: f.events}, nil
+}
+
+type fakeInvalidator struct {
+	users []string
+}
+
+func (f *fakeInvalidator) InvalidateUser(ctx context.Context, userID string) {
+	f.users = append(f.users, userID)
+}
+
+func TestUserEventConsumer(t *testing.T) {
+	renamed, promoted, deleted, purged := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	events := []*userservicepb.UserEvent{
+		{Seq: 11, Type: "created", UserId: uuid.NewString()},
+		{Seq: 12, Type: "updated", UserId: renamed.String()},
+		{Seq: 13, Type: "role_changed", UserId: promoted.String()},
+		{Seq: 14, Type: "deleted", UserId: deleted.String()},
+		{Seq: 15, Type: "deleted", UserId: purged.String(), Purged: true},
+	}
+
+	t.Run("Success - Resumes From Checkpoint", func(t *testing.T) {
+		rateRepo := new(MockRateRepository)
+		rateRepo.On("InvalidateUserRatings", mock.Anything, renamed).Return(nil)
+		rateRepo.On("InvalidateUserRatings", mock.Anything, deleted).Return(nil)
+		rateRepo.On("HideRatingsOfUser", mock.Anything, purged).Return(nil)
+		eventRepo := new(MockUserEventRepository)
+		eventRepo.On("LastSeq", mock.Anything).Return(int64(10), true, nil)
+		eventRepo.On("SaveSeq", mock.Anything, mock.Anything).Return(nil)
+		source := &fakeEventSource{events: events}
+		invalidator := &fakeInvalidator{}
+		consumer := &UserEventConsumer{source: source, authCache: invalidator, rateRepo: rateRepo, eventRepo: eventRepo}
+
+		handled, err := consumer.consume(context.Background())
+
+		assert.ErrorIs(t, err, io.EOF)
+		assert.Equal(t, 5, handled)
+		assert.Equal(t, int64(10), source.afterSeq)
+		assert.False(t, source.fromNow)
+		assert.Equal(t, []string{renamed.String(), promoted.String(), deleted.String(), purged.String()}, invalidator.users)
+		rateRepo.AssertExpectations(t)
+		rateRepo.AssertNotCalled(t, "InvalidateUserRatings", mock.Anything, promoted)
+		for _, event := range events {
+			eventRepo.AssertCalled(t, "SaveSeq", mock.Anything, event.Seq)
+		}
+	})
+
+	t.Run("Success - No Checkpoint Starts From Now", func(t *testing.T) {
+		eventRepo := new(MockUserEventRepository)
+		eventRepo.On("LastSeq", mock.Anything).Return(int64(0), false, nil)
+		source := &fakeEventSource{}
+		consumer := &UserEventConsumer{source: source, rateRepo: new(MockRateRepository), eventRepo: eventRepo}
+
+		handled, err := consumer.consume(context.Background())
+
+		assert.ErrorIs(t, err, io.EOF)
+		assert.Zero(t, handled)
+		assert.True(t, source.fromNow)
+	})
+
+	t.Run("Error - Handler Fails Keeps Checkpoint", func(t *testing.T) {
+		rateRepo := new(MockRateRepository)
+		rateRepo.On("InvalidateUserRatings", mock.Anything, renamed).Return(errors.New("redis down"))
+		eventRepo := new(MockUserEventRepository)
+		eventRepo.On("LastSeq", mock.Anything).Return(int64(11), true, nil)
+		consumer := &UserEventConsumer{source: &fakeEventSource{events: events[1:]}, rateRepo: rateRepo, eventRepo: eventRepo}
+
+		handled, err := consumer.consume(context.Background())
+
+		assert.EqualError(t, err, "redis down")
+		assert.Zero(t, handled)
+		eventRepo.AssertNotCalled(t, "SaveSeq", mock.Anything, mock.Anything)
+	})
+}
@@ -13,11 +13,13 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	recover2 "github.com/gofiber/fiber/v2/middleware/recover"
 	"productservice/config"
+	"productservice/internal/auth"
 	"productservice/internal/cache"
 	"productservice/internal/database"
 	"productservice/internal/grpc/client"
 	"productservice/internal/grpc/provider"
 	"productservice/internal/handler"
+	"productservice/internal/metrics"
 	"productservice/internal/middleware"
 	"productservice/internal/repository"
 	"productservice/internal/router"
@@ -43,31 +45,89 @@ func New() (*fiber.App, func(), error) {
 	productRepository := repository.NewProductRepository(db, redisClient)
 	productService := service.NewProductService(productRepository)
 	productHandler := handler.NewProductHandler(productService)
-	clientConn, cleanup, err := provider.ProvideGRPCConnection(configConfig)
+	circuitBreaker := client.NewCircuitBreaker(configConfig)
+	clientConn, cleanup, err := provider.ProvideGRPCConnection(configConfig, circuitBreaker)
 	if err != nil {
 		return nil, nil, err
 	}
-	authClient := client.NewAuthClient(clientConn)
+	authCache, cleanup2 := client.NewAuthCache(configConfig, redisClient)
+	authClient := client.NewAuthClient(clientConn, authCache, configConfig)
 	rateRepository := repository.NewRateRepository(db, redisClient, authClient)
 	rateService := service.NewRateService(rateRepository)
 	rateHandler := handler.NewRateHandler(rateService)
-	routerHandler := router.NewRouterHandler(productHandler, rateHandler)
-	authMiddleware := middleware.NewAuthMiddleware(authClient)
-	middlewareMiddleware := middleware.NewMiddleware(authMiddleware)
-	app := NewServer(routerHandler, middlewareMiddleware)
+	categoryRepository := repository.NewCategoryRepository(db, redisClient)
+	categoryService := service.NewCategoryService(categoryRepository)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+	importRepository := repository.NewImportRepository(db, redisClient)
+	productImporter, cleanup3 := service.NewProductImporter(importRepository, configConfig)
+	importHandler := handler.NewImportHandler(productImporter)
+	routerHandler := router.NewRouterHandler(productHandler, rateHandler, categoryHandler, importHandler)
+	keySet, cleanup4, err := auth.NewKeySet(configConfig)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	verifier := auth.NewVerifier(keySet)
+	authMiddleware := middleware.NewAuthMiddleware(authClient, verifier, configConfig)
+	bodyLimitMiddleware := middleware.NewBodyLimitMiddleware(configConfig)
+	middlewareMiddleware := middleware.NewMiddleware(authMiddleware, bodyLimitMiddleware)
+	userEventRepository := repository.NewUserEventRepository(redisClient)
+	userEventConsumer, cleanup5 := service.NewUserEventConsumer(authClient, authCache, rateRepository, userEventRepository, configConfig)
+	server, cleanup6, err := metrics.NewServer(configConfig)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	app := NewServer(configConfig, routerHandler, middlewareMiddleware, userEventConsumer, server)
 	return app, func() {
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+	}, nil
+}
+
+// NewImporter dùng cho lệnh import ở cmd, chỉ cần database và redis
+func NewImporter() (*service.ProductImporter, func(), error) {
+	configConfig, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := database.NewPostgresDB(configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	redisClient, err := cache.NewRedisClient(configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	importRepository := repository.NewImportRepository(db, redisClient)
+	productImporter, cleanup := service.NewProductImporter(importRepository, configConfig)
+	return productImporter, func() {
 		cleanup()
 	}, nil
 }
 
 // server.go:
 
-func NewServer(router2 *router.RouterHandler, md *middleware.Middleware) *fiber.App {
+// events và metrics chạy nền cùng vòng đời với app, nhận ở đây để wire khởi tạo chúng
+func NewServer(cfg *config.Config, router2 *router.RouterHandler, md *middleware.Middleware, events *service.UserEventConsumer, metrics2 *metrics.Server) *fiber.App {
 	app := fiber.New(fiber.Config{
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 5 * time.Second,
 		JSONDecoder:  json.Unmarshal,
 		JSONEncoder:  json.Marshal,
+
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 	})
 
 	app.Use(logger.New())
@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -14,53 +16,73 @@ import (
 	"github.com/agris/user-service/internal/grpc"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("All servers exited gracefully")
+}
+
+func run() error {
 	port := flag.Int("port", 8005, "Server port")
 	flag.Parse()
 	app, err := internal.New()
 	if err != nil {
-		log.Fatalf("Failed to initialize app: %v", err)
+		return fmt.Errorf("failed to initialize app: %w", err)
 	}
 
-	go func() {
-		// Wire inject - tạo gRPC server và tất cả dependencies
-		_, cleanup, err := grpc.InitGRPCServer()
-		if err != nil {
-			log.Fatalf("Failed to initialize gRPC server: %v", err)
-		}
-		defer cleanup()
-
-		log.Println("gRPC server started successfully")
-
-		// Graceful shutdown
-		quit := make(chan os.Signal, 1)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-		<-quit
-		log.Println("Received shutdown signal, cleaning up...")
-	}()
+	// Wire inject - tạo gRPC server và tất cả dependencies
+	grpcServer, cleanup, err := grpc.InitGRPCServer()
+	if err != nil {
+		return fmt.Errorf("failed to initialize gRPC server: %w", err)
+	}
+	defer cleanup()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-	// 5. Start HTTP server trong goroutine
+	// Lỗi của server nào cũng trả về đây để dừng cả hai
+	serveErr := make(chan error, 2)
+	go func() {
+		if err := grpcServer.Serve(); err != nil {
+			serveErr <- fmt.Errorf("gRPC server: %w", err)
+		}
+	}()
 	go func() {
 		addr := fmt.Sprintf(":%d", *port)
 		log.Printf("HTTP server starting on %s", addr)
 		if err := app.Listen(addr); err != nil {
-			log.Fatalf("Failed to start HTTP server: %v", err)
+			serveErr <- fmt.Errorf("HTTP server: %w", err)
 		}
 	}()
 
-	<-quit
-	log.Println("Shutting down servers...")
+	var failure error
+	select {
+	case sig := <-quit:
+		log.Printf("Received %s, shutting down servers...", sig)
+	case failure = <-serveErr:
+		log.Printf("Server failed, shutting down: %v", failure)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// HTTP và gRPC drain cùng lúc trong cùng một khoảng thời gian
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
-	if err := app.ShutdownWithContext(ctx); err != nil {
-		log.Fatalf("HTTP server forced to shutdown: %v", err)
-	}
+	var wg sync.WaitGroup
+	var errHTTP, errGRPC error
+	wg.Go(func() {
+		if err := app.ShutdownWithContext(ctx); err != nil {
+			errHTTP = fmt.Errorf("HTTP server forced to shutdown: %w", err)
+		}
+	})
+	wg.Go(func() {
+		if err := grpcServer.Shutdown(ctx); err != nil {
+			errGRPC = fmt.Errorf("gRPC server forced to shutdown: %w", err)
+		}
+	})
+	wg.Wait()
 
-	log.Println("All servers exited gracefully")
+	return errors.Join(failure, errHTTP, errGRPC)
 }
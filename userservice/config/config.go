@@ -12,10 +12,112 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Redis    RedisConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	Redis      RedisConfig
+	Security   SecurityConfig
+	Mail       MailConfig
+	UserEvents UserEventsConfig `mapstructure:"user_events"`
+}
+
+// UserEventsConfig điều khiển luồng WatchUserEvents
+type UserEventsConfig struct {
+	// PollInterval là khoảng thời gian kiểm tra sự kiện mới khi consumer đã nhận hết
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+}
+
+type MailConfig struct {
+	// Driver là "smtp" hoặc "log" (mặc định, chỉ ghi ra log và FilePath)
+	Driver   string     `mapstructure:"driver"`
+	From     string     `mapstructure:"from"`
+	FilePath string     `mapstructure:"file_path"`
+	SMTP     SMTPConfig `mapstructure:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type SecurityConfig struct {
+	Login             LoginProtectionConfig   `mapstructure:"login"`
+	PasswordReset     PasswordResetConfig     `mapstructure:"password_reset"`
+	EmailVerification EmailVerificationConfig `mapstructure:"email_verification"`
+	MFA               MFAConfig               `mapstructure:"mfa"`
+	OIDC              OIDCConfig              `mapstructure:"oidc"`
+	// ServiceTokens là các service nội bộ được gọi những RPC dành riêng cho service (GetUserById, BatchGetUsers)
+	ServiceTokens []ServiceTokenConfig `mapstructure:"service_tokens"`
+}
+
+// ServiceTokenConfig gửi kèm trong metadata "x-service-token", Name chỉ dùng để ghi log
+type ServiceTokenConfig struct {
+	Name  string `mapstructure:"name"`
+	Token string `mapstructure:"token"`
+	// Identity là URI SAN, DNS SAN hoặc CN trong chứng chỉ client của service.
+	// Có giá trị thì token chỉ dùng được qua kết nối mTLS với chứng chỉ mang định danh này.
+	Identity string `mapstructure:"identity"`
+}
+
+type OIDCConfig struct {
+	// StateTTL là thời gian tối đa từ lúc chuyển sang provider tới lúc callback
+	StateTTL  time.Duration        `mapstructure:"state_ttl"`
+	Providers []OIDCProviderConfig `mapstructure:"providers"`
+}
+
+// OIDCProviderConfig là một identity provider hỗ trợ discovery, Name dùng trong đường dẫn /users/oidc/:provider
+type OIDCProviderConfig struct {
+	Name         string `mapstructure:"name"`
+	Issuer       string `mapstructure:"issuer"`
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	// RedirectURL phải trỏ tới GET /users/oidc/:provider/callback và khớp với cấu hình ở provider
+	RedirectURL string   `mapstructure:"redirect_url"`
+	Scopes      []string `mapstructure:"scopes"`
+}
+
+type MFAConfig struct {
+	// Issuer là tên hiển thị trong ứng dụng authenticator
+	Issuer string `mapstructure:"issuer"`
+	// RequireForAdmin bắt admin đăng nhập bằng MFA mới được dùng quyền admin
+	RequireForAdmin      bool          `mapstructure:"require_for_admin"`
+	ChallengeTTL         time.Duration `mapstructure:"challenge_ttl"`
+	MaxChallengeAttempts int           `mapstructure:"max_challenge_attempts"`
+	EnrollmentTTL        time.Duration `mapstructure:"enrollment_ttl"`
+	RecoveryCodes        int           `mapstructure:"recovery_codes"`
+	// EncryptionKey (base64, 32 byte) mã hóa TOTP secret trong database, để trống thì không đăng ký MFA được
+	EncryptionKey string `mapstructure:"encryption_key"`
+}
+
+type EmailVerificationConfig struct {
+	TokenTTL       time.Duration `mapstructure:"token_ttl"`
+	URL            string        `mapstructure:"url"`
+	ResendInterval time.Duration `mapstructure:"resend_interval"`
+	// RequireForLogin chặn đăng nhập khi email chưa được xác minh
+	RequireForLogin bool `mapstructure:"require_for_login"`
+}
+
+type PasswordResetConfig struct {
+	TokenTTL time.Duration `mapstructure:"token_ttl"`
+	// URL là trang đặt lại mật khẩu của frontend, token được thêm vào query "token"
+	URL string `mapstructure:"url"`
+	// RequestInterval là khoảng cách tối thiểu giữa hai email đặt lại mật khẩu của một tài khoản, 0 thì không giới hạn
+	RequestInterval time.Duration `mapstructure:"request_interval"`
+}
+
+// LoginProtectionConfig giới hạn số lần đăng nhập sai, giá trị 0 thì tắt giới hạn tương ứng
+type LoginProtectionConfig struct {
+	MaxAttempts   int           `mapstructure:"max_attempts"`
+	IPMaxAttempts int           `mapstructure:"ip_max_attempts"`
+	AttemptWindow time.Duration `mapstructure:"attempt_window"`
+	// LockoutDuration là thời gian khóa lần đầu, mỗi lần khóa tiếp theo gấp đôi tới MaxLockoutDuration
+	LockoutDuration    time.Duration `mapstructure:"lockout_duration"`
+	MaxLockoutDuration time.Duration `mapstructure:"max_lockout_duration"`
+	// LockoutResetAfter là thời gian không bị khóa lại để bậc khóa trở về ban đầu
+	LockoutResetAfter time.Duration `mapstructure:"lockout_reset_after"`
 }
 
 type RedisConfig struct {
@@ -28,6 +130,23 @@ type ServerConfig struct {
 	HTTPPort string `mapstructure:"http_port"`
 	GRPCPort string `mapstructure:"grpc_port"`
 	Env      string `mapstructure:"env"`
+	// ProxyHeader là header chứa IP thật của client khi chạy sau reverse proxy (vd: X-Real-Ip)
+	ProxyHeader string `mapstructure:"proxy_header"`
+	// GRPCReflection bật server reflection cho grpcurl, không nên bật ở production
+	GRPCReflection bool `mapstructure:"grpc_reflection"`
+	// HealthCheckInterval là chu kỳ ping Postgres và Redis để cập nhật grpc.health.v1
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
+	GRPCTLS             GRPCTLSConfig `mapstructure:"grpc_tls"`
+}
+
+// GRPCTLSConfig bật TLS cho gRPC server, để trống CertFile thì chạy plaintext
+type GRPCTLSConfig struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ClientCAFile bật mTLS: client phải gửi chứng chỉ do CA này ký
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	// ReloadInterval là chu kỳ kiểm tra file chứng chỉ để nạp lại khi được xoay vòng
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
 }
 
 type DatabaseConfig struct {
@@ -45,6 +164,22 @@ type JWTConfig struct {
 	Secret        string        `mapstructure:"secret"`
 	AccessExpiry  time.Duration `mapstructure:"access_expiry"`
 	RefreshExpiry time.Duration `mapstructure:"refresh_expiry"`
+	// ActiveKeyID chọn khóa dùng để ký, để trống thì ký HS256 bằng Secret
+	ActiveKeyID string         `mapstructure:"active_key_id"`
+	Keys        []JWTKeyConfig `mapstructure:"keys"`
+	// AcceptLegacyHS256 vẫn nhận token HS256 ký bằng Secret khi đã có ActiveKeyID,
+	// chỉ bật trong lúc chuyển đổi tới khi token HS256 cuối cùng hết hạn
+	AcceptLegacyHS256 bool `mapstructure:"accept_legacy_hs256"`
+}
+
+// JWTKeyConfig là một khóa RSA hoặc Ed25519 dạng PEM.
+// Khóa cũ chỉ cần public key để tiếp tục xác thực token trong lúc xoay vòng.
+type JWTKeyConfig struct {
+	ID             string `mapstructure:"id"`
+	PrivateKey     string `mapstructure:"private_key"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKey      string `mapstructure:"public_key"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
 }
 
 func getDefaultConfig() string {
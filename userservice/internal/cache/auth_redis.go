@@ -2,16 +2,42 @@ package cache
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
-	"time"
 )
 
 type RedisRepository interface {
 	SaveRefreshToken(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) error
+	RotateRefreshToken(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) (bool, error)
+	IsRefreshTokenUsed(ctx context.Context, userID uuid.UUID, token string) (bool, error)
+	RemoveRefreshToken(ctx context.Context, userID uuid.UUID, token string) error
+	RevokeAllRefreshTokens(ctx context.Context, userID uuid.UUID) error
+	DenyAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	RevokeAccessTokensBefore(ctx context.Context, userID uuid.UUID, at time.Time, ttl time.Duration) error
+	RevokeSessionAccessTokens(ctx context.Context, sessionID string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, userID uuid.UUID, jti string, sessionID string, issuedAt time.Time) (bool, error)
+	PublishRevocation(ctx context.Context, event RevocationEvent) error
+	SaveSession(ctx context.Context, userID uuid.UUID, session *Session) error
+	GetSession(ctx context.Context, userID uuid.UUID, sessionID string) (*Session, error)
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]Session, error)
+	DeleteSession(ctx context.Context, userID uuid.UUID, sessionID string) error
+}
+
+// RevocationEvent được phát lên RevocationChannel, JTI để trống nghĩa là mọi token của user
+type RevocationEvent struct {
+	UserID    uuid.UUID `json:"user_id"`
+	JTI       string    `json:"jti,omitempty"`
+	RevokedAt time.Time `json:"revoked_at"`
 }
 
+// legacyRevokedAtThreshold phân biệt mốc thu hồi lưu theo giây (khoảng 1e9) với mili giây (khoảng 1e12)
+const legacyRevokedAtThreshold = 100_000_000_000
+
 type redisRepository struct {
 	rd *redis.Client
 }
@@ -21,7 +47,7 @@ func NewRedisRepository(rd *redis.Client) RedisRepository {
 }
 
 func (r *redisRepository) SaveRefreshToken(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) error {
-	key := fmt.Sprintf("refresh_tokens:%s", userID)
+	key := baseRefreshTokens + userID.String()
 	score := float64(expiresAt.Unix())
 
 	// Use pipeline to execute both commands atomically
@@ -37,3 +63,124 @@ func (r *redisRepository) SaveRefreshToken(ctx context.Context, userID uuid.UUID
 	_, err := pipe.Exec(ctx)
 	return err
 }
+
+// RotateRefreshToken xóa token khỏi tập đang hoạt động và ghi nhận nó là đã dùng.
+// Trả về false nếu token không còn trong tập (đã bị xoay vòng hoặc thu hồi).
+func (r *redisRepository) RotateRefreshToken(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) (bool, error) {
+	// ZRem chỉ thành công với một request, tránh hai lần refresh song song cùng một token
+	removed, err := r.rd.ZRem(ctx, baseRefreshTokens+userID.String(), token).Result()
+	if err != nil {
+		return false, err
+	}
+	if removed == 0 {
+		return false, nil
+	}
+
+	usedKey := baseUsedRefreshTokens + userID.String()
+	pipe := r.rd.Pipeline()
+	pipe.ZAdd(ctx, usedKey, redis.Z{
+		Score:  float64(expiresAt.Unix()),
+		Member: token,
+	})
+	// Dọn các token đã hết hạn, chúng không thể bị dùng lại nữa
+	pipe.ZRemRangeByScore(ctx, usedKey, "-inf", "("+strconv.FormatInt(time.Now().Unix(), 10))
+	pipe.Expire(ctx, usedKey, 30*24*time.Hour)
+
+	_, err = pipe.Exec(ctx)
+	return true, err
+}
+
+func (r *redisRepository) IsRefreshTokenUsed(ctx context.Context, userID uuid.UUID, token string) (bool, error) {
+	err := r.rd.ZScore(ctx, baseUsedRefreshTokens+userID.String(), token).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *redisRepository) RemoveRefreshToken(ctx context.Context, userID uuid.UUID, token string) error {
+	return r.rd.ZRem(ctx, baseRefreshTokens+userID.String(), token).Err()
+}
+
+// RevokeAllRefreshTokens thu hồi toàn bộ họ refresh token và mọi phiên của user
+func (r *redisRepository) RevokeAllRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	indexKey := baseSessions + userID.String()
+	ids, err := r.rd.ZRange(ctx, indexKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{baseRefreshTokens + userID.String(), indexKey}
+	for _, id := range ids {
+		keys = append(keys, sessionKey(userID, id))
+	}
+	return r.rd.Del(ctx, keys...).Err()
+}
+
+// DenyAccessToken đưa JTI vào denylist cho tới khi access token hết hạn
+func (r *redisRepository) DenyAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return r.rd.Set(ctx, baseDeniedJTI+jti, 1, ttl).Err()
+}
+
+// RevokeAccessTokensBefore vô hiệu hóa mọi access token của user được cấp trước thời điểm at.
+// ttl nên bằng thời hạn của access token, sau đó các token cũ đã tự hết hạn.
+// Mốc lưu theo mili giây và so với claim iat_ms, để token cấp ngay sau khi thu hồi, trong cùng một giây, vẫn dùng được.
+func (r *redisRepository) RevokeAccessTokensBefore(ctx context.Context, userID uuid.UUID, at time.Time, ttl time.Duration) error {
+	return r.rd.Set(ctx, baseTokensRevokedAt+userID.String(), at.UnixMilli(), ttl).Err()
+}
+
+// RevokeSessionAccessTokens vô hiệu hóa mọi access token mang sid của phiên, kể cả token cũ
+// chưa hết hạn sau các lần làm mới. expiresAt là hạn của access token cấp sau cùng cho phiên.
+func (r *redisRepository) RevokeSessionAccessTokens(ctx context.Context, sessionID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return r.rd.Set(ctx, baseRevokedSession+sessionID, 1, ttl).Err()
+}
+
+func (r *redisRepository) IsAccessTokenRevoked(ctx context.Context, userID uuid.UUID, jti string, sessionID string, issuedAt time.Time) (bool, error) {
+	keys := []string{baseDeniedJTI + jti}
+	if sessionID != "" {
+		keys = append(keys, baseRevokedSession+sessionID)
+	}
+
+	pipe := r.rd.Pipeline()
+	denied := pipe.Exists(ctx, keys...)
+	revokedAt := pipe.Get(ctx, baseTokensRevokedAt+userID.String())
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	if denied.Val() > 0 {
+		return true, nil
+	}
+
+	at, err := revokedAt.Int64()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// mốc ghi trước khi chuyển sang mili giây tính theo giây, khóa tự hết hạn sau một access_expiry
+	if at < legacyRevokedAtThreshold {
+		return issuedAt.Unix() <= at, nil
+	}
+	return issuedAt.UnixMilli() <= at, nil
+}
+
+func (r *redisRepository) PublishRevocation(ctx context.Context, event RevocationEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return r.rd.Publish(ctx, RevocationChannel, payload).Err()
+}
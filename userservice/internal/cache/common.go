@@ -0,0 +1,31 @@
+package cache
+
+// key redis
+var (
+	baseRefreshTokens      = "refresh_tokens:"
+	baseUsedRefreshTokens  = "refresh_tokens_used:"
+	baseDeniedJTI          = "denylist:jti:"
+	baseTokensRevokedAt    = "tokens_revoked_at:"
+	baseRevokedSession     = "session_revoked:"
+	baseLoginFailedUser    = "login_failed:account:"
+	baseLoginFailedIP      = "login_failed:ip:"
+	baseLoginLock          = "login_lock:account:"
+	baseLoginLockLevel     = "login_lock_level:account:"
+	basePasswordReset      = "password_reset:"
+	basePasswordResetUser  = "password_reset_user:"
+	basePasswordResetSent  = "password_reset_sent:"
+	baseEmailVerify        = "email_verify:"
+	baseEmailVerifyUser    = "email_verify_user:"
+	baseEmailVerifyResend  = "email_verify_resend:"
+	baseMFAEnrollment      = "mfa_enrollment:"
+	baseMFAChallenge       = "mfa_challenge:"
+	baseMFAChallengeFailed = "mfa_challenge_failed:"
+	baseMFAUsedCode        = "mfa_used_code:"
+	baseSessions           = "sessions:"
+	baseSession            = "session:"
+	baseOIDCState          = "oidc_state:"
+)
+
+// RevocationChannel là kênh pub/sub báo token của user vừa bị thu hồi,
+// productservice lắng nghe kênh này để xóa cache kết quả Authenticate
+const RevocationChannel = "auth:revocations"
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type EmailVerificationRepository interface {
+	SaveVerificationToken(ctx context.Context, userID uuid.UUID, tokenHash string, ttl time.Duration) error
+	ConsumeVerificationToken(ctx context.Context, tokenHash string) (uuid.UUID, bool, error)
+	AcquireResendSlot(ctx context.Context, userID uuid.UUID, interval time.Duration) (time.Duration, error)
+}
+
+type emailVerificationRepository struct {
+	rd     *redis.Client
+	tokens oneTimeTokenStore
+}
+
+func NewEmailVerificationRepository(rd *redis.Client) EmailVerificationRepository {
+	return &emailVerificationRepository{
+		rd:     rd,
+		tokens: oneTimeTokenStore{rd: rd, tokenPrefix: baseEmailVerify, userPrefix: baseEmailVerifyUser},
+	}
+}
+
+func (r *emailVerificationRepository) SaveVerificationToken(ctx context.Context, userID uuid.UUID, tokenHash string, ttl time.Duration) error {
+	return r.tokens.save(ctx, userID, tokenHash, ttl)
+}
+
+func (r *emailVerificationRepository) ConsumeVerificationToken(ctx context.Context, tokenHash string) (uuid.UUID, bool, error) {
+	return r.tokens.consume(ctx, tokenHash)
+}
+
+// AcquireResendSlot cho phép gửi lại email tối đa một lần mỗi interval.
+// Trả về thời gian phải chờ, 0 nếu được phép gửi.
+func (r *emailVerificationRepository) AcquireResendSlot(ctx context.Context, userID uuid.UUID, interval time.Duration) (time.Duration, error) {
+	key := baseEmailVerifyResend + userID.String()
+	ok, err := r.rd.SetNX(ctx, key, 1, interval).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return 0, nil
+	}
+
+	ttl, err := r.rd.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl <= 0 {
+		return interval, nil
+	}
+	return ttl, nil
+}
@@ -2,4 +2,4 @@ package cache
 
 import "github.com/google/wire"
 
-var Set = wire.NewSet(NewRedisClient, NewRedisRepository)
+var Set = wire.NewSet(NewRedisClient, NewRedisRepository, NewLoginAttemptRepository, NewPasswordResetRepository, NewEmailVerificationRepository, NewMFARepository, NewOIDCStateRepository)
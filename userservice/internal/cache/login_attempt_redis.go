@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type LoginAttemptRepository interface {
+	IncrementAccountFailures(ctx context.Context, userID uuid.UUID, window time.Duration) (int64, error)
+	IncrementIPFailures(ctx context.Context, ip string, window time.Duration) (int64, error)
+	GetIPFailures(ctx context.Context, ip string) (int64, time.Duration, error)
+	NextLockoutLevel(ctx context.Context, userID uuid.UUID, resetAfter time.Duration) (int64, error)
+	LockAccount(ctx context.Context, userID uuid.UUID, lockFor time.Duration) error
+	GetAccountLock(ctx context.Context, userID uuid.UUID) (time.Duration, error)
+	ResetAccount(ctx context.Context, userID uuid.UUID) error
+}
+
+type loginAttemptRepository struct {
+	rd *redis.Client
+}
+
+func NewLoginAttemptRepository(rd *redis.Client) LoginAttemptRepository {
+	return &loginAttemptRepository{rd: rd}
+}
+
+func (r *loginAttemptRepository) IncrementAccountFailures(ctx context.Context, userID uuid.UUID, window time.Duration) (int64, error) {
+	return r.increment(ctx, baseLoginFailedUser+userID.String(), window)
+}
+
+func (r *loginAttemptRepository) IncrementIPFailures(ctx context.Context, ip string, window time.Duration) (int64, error) {
+	return r.increment(ctx, baseLoginFailedIP+ip, window)
+}
+
+// increment đếm số lần sai trong cửa sổ cố định bắt đầu từ lần sai đầu tiên
+func (r *loginAttemptRepository) increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	pipe := r.rd.Pipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+// GetIPFailures trả về số lần sai của IP và thời gian còn lại của cửa sổ đếm
+func (r *loginAttemptRepository) GetIPFailures(ctx context.Context, ip string) (int64, time.Duration, error) {
+	key := baseLoginFailedIP + ip
+	pipe := r.rd.Pipeline()
+	count := pipe.Get(ctx, key)
+	ttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return 0, 0, err
+	}
+
+	n, err := count.Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, positive(ttl.Val()), nil
+}
+
+// NextLockoutLevel tăng bậc khóa của tài khoản và xóa bộ đếm lần sai hiện tại.
+// Bậc khóa được giữ tới resetAfter để các lần khóa liên tiếp dài dần.
+func (r *loginAttemptRepository) NextLockoutLevel(ctx context.Context, userID uuid.UUID, resetAfter time.Duration) (int64, error) {
+	key := baseLoginLockLevel + userID.String()
+	pipe := r.rd.Pipeline()
+	level := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, resetAfter)
+	pipe.Del(ctx, baseLoginFailedUser+userID.String())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return level.Val(), nil
+}
+
+func (r *loginAttemptRepository) LockAccount(ctx context.Context, userID uuid.UUID, lockFor time.Duration) error {
+	return r.rd.Set(ctx, baseLoginLock+userID.String(), 1, lockFor).Err()
+}
+
+// GetAccountLock trả về thời gian khóa còn lại, 0 nếu tài khoản không bị khóa
+func (r *loginAttemptRepository) GetAccountLock(ctx context.Context, userID uuid.UUID) (time.Duration, error) {
+	ttl, err := r.rd.PTTL(ctx, baseLoginLock+userID.String()).Result()
+	if err != nil {
+		return 0, err
+	}
+	return positive(ttl), nil
+}
+
+// ResetAccount xóa bộ đếm, khóa và bậc khóa của tài khoản
+func (r *loginAttemptRepository) ResetAccount(ctx context.Context, userID uuid.UUID) error {
+	id := userID.String()
+	return r.rd.Del(ctx, baseLoginFailedUser+id, baseLoginLock+id, baseLoginLockLevel+id).Err()
+}
+
+// PTTL trả về giá trị âm khi key không tồn tại hoặc không có hạn
+func positive(ttl time.Duration) time.Duration {
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type MFARepository interface {
+	SaveEnrollment(ctx context.Context, userID uuid.UUID, secret string, ttl time.Duration) error
+	GetEnrollment(ctx context.Context, userID uuid.UUID) (string, bool, error)
+	DeleteEnrollment(ctx context.Context, userID uuid.UUID) error
+	SaveChallenge(ctx context.Context, tokenHash string, userID uuid.UUID, ttl time.Duration) error
+	GetChallenge(ctx context.Context, tokenHash string) (uuid.UUID, bool, error)
+	IncrementChallengeFailures(ctx context.Context, tokenHash string, ttl time.Duration) (int64, error)
+	ConsumeChallenge(ctx context.Context, tokenHash string) (bool, error)
+	MarkCodeUsed(ctx context.Context, userID uuid.UUID, step int64, ttl time.Duration) (bool, error)
+}
+
+type mfaRepository struct {
+	rd *redis.Client
+}
+
+func NewMFARepository(rd *redis.Client) MFARepository {
+	return &mfaRepository{rd: rd}
+}
+
+// SaveEnrollment giữ secret chờ user xác nhận bằng mã đầu tiên, lần enroll sau ghi đè lần trước
+func (r *mfaRepository) SaveEnrollment(ctx context.Context, userID uuid.UUID, secret string, ttl time.Duration) error {
+	return r.rd.Set(ctx, baseMFAEnrollment+userID.String(), secret, ttl).Err()
+}
+
+func (r *mfaRepository) GetEnrollment(ctx context.Context, userID uuid.UUID) (string, bool, error) {
+	secret, err := r.rd.Get(ctx, baseMFAEnrollment+userID.String()).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return secret, true, nil
+}
+
+func (r *mfaRepository) DeleteEnrollment(ctx context.Context, userID uuid.UUID) error {
+	return r.rd.Del(ctx, baseMFAEnrollment+userID.String()).Err()
+}
+
+func (r *mfaRepository) SaveChallenge(ctx context.Context, tokenHash string, userID uuid.UUID, ttl time.Duration) error {
+	return r.rd.Set(ctx, baseMFAChallenge+tokenHash, userID.String(), ttl).Err()
+}
+
+func (r *mfaRepository) GetChallenge(ctx context.Context, tokenHash string) (uuid.UUID, bool, error) {
+	value, err := r.rd.Get(ctx, baseMFAChallenge+tokenHash).Result()
+	if errors.Is(err, redis.Nil) {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+
+	userID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, false, nil
+	}
+	return userID, true, nil
+}
+
+// IncrementChallengeFailures đếm số mã sai của một challenge
+func (r *mfaRepository) IncrementChallengeFailures(ctx context.Context, tokenHash string, ttl time.Duration) (int64, error) {
+	key := baseMFAChallengeFailed + tokenHash
+	pipe := r.rd.Pipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+// ConsumeChallenge xóa challenge, trả về false nếu challenge đã bị dùng bởi request khác
+func (r *mfaRepository) ConsumeChallenge(ctx context.Context, tokenHash string) (bool, error) {
+	deleted, err := r.rd.Del(ctx, baseMFAChallenge+tokenHash).Result()
+	if err != nil {
+		return false, err
+	}
+	_ = r.rd.Del(ctx, baseMFAChallengeFailed+tokenHash)
+	return deleted > 0, nil
+}
+
+// MarkCodeUsed trả về false nếu mã TOTP của bước thời gian này đã được dùng
+func (r *mfaRepository) MarkCodeUsed(ctx context.Context, userID uuid.UUID, step int64, ttl time.Duration) (bool, error) {
+	key := baseMFAUsedCode + userID.String() + ":" + strconv.FormatInt(step, 10)
+	return r.rd.SetNX(ctx, key, 1, ttl).Result()
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// OIDCState là dữ liệu của một lần đăng nhập OIDC đang chờ callback
+type OIDCState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+type OIDCStateRepository interface {
+	SaveState(ctx context.Context, stateHash string, state *OIDCState, ttl time.Duration) error
+	// ConsumeState lấy và xóa state nên mỗi state chỉ dùng được một lần, trả về nil nếu không tồn tại
+	ConsumeState(ctx context.Context, stateHash string) (*OIDCState, error)
+}
+
+type oidcStateRepository struct {
+	rd *redis.Client
+}
+
+func NewOIDCStateRepository(rd *redis.Client) OIDCStateRepository {
+	return &oidcStateRepository{rd: rd}
+}
+
+func (r *oidcStateRepository) SaveState(ctx context.Context, stateHash string, state *OIDCState, ttl time.Duration) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return r.rd.Set(ctx, baseOIDCState+stateHash, data, ttl).Err()
+}
+
+func (r *oidcStateRepository) ConsumeState(ctx context.Context, stateHash string) (*OIDCState, error) {
+	data, err := r.rd.GetDel(ctx, baseOIDCState+stateHash).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state OIDCState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, nil
+	}
+	return &state, nil
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// oneTimeTokenStore lưu hash của token gửi qua email, mỗi user chỉ giữ token mới nhất
+type oneTimeTokenStore struct {
+	rd          *redis.Client
+	tokenPrefix string
+	userPrefix  string
+}
+
+func (s oneTimeTokenStore) save(ctx context.Context, userID uuid.UUID, tokenHash string, ttl time.Duration) error {
+	previous, err := s.rd.SetArgs(ctx, s.userPrefix+userID.String(), tokenHash, redis.SetArgs{
+		TTL: ttl,
+		Get: true,
+	}).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	pipe := s.rd.Pipeline()
+	if previous != "" {
+		pipe.Del(ctx, s.tokenPrefix+previous)
+	}
+	pipe.Set(ctx, s.tokenPrefix+tokenHash, userID.String(), ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// consume lấy và xóa token trong một lệnh nên token chỉ dùng được một lần
+func (s oneTimeTokenStore) consume(ctx context.Context, tokenHash string) (uuid.UUID, bool, error) {
+	value, err := s.rd.GetDel(ctx, s.tokenPrefix+tokenHash).Result()
+	if errors.Is(err, redis.Nil) {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+
+	userID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, false, nil
+	}
+
+	_ = s.rd.Del(ctx, s.userPrefix+value)
+	return userID, true, nil
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type PasswordResetRepository interface {
+	SaveResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, ttl time.Duration) error
+	ConsumeResetToken(ctx context.Context, tokenHash string) (uuid.UUID, bool, error)
+	AcquireSendSlot(ctx context.Context, userID uuid.UUID, interval time.Duration) (bool, error)
+}
+
+type passwordResetRepository struct {
+	rd     *redis.Client
+	tokens oneTimeTokenStore
+}
+
+func NewPasswordResetRepository(rd *redis.Client) PasswordResetRepository {
+	return &passwordResetRepository{
+		rd:     rd,
+		tokens: oneTimeTokenStore{rd: rd, tokenPrefix: basePasswordReset, userPrefix: basePasswordResetUser},
+	}
+}
+
+// SaveResetToken lưu hash của token, token cũ của user bị vô hiệu hóa
+func (r *passwordResetRepository) SaveResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, ttl time.Duration) error {
+	return r.tokens.save(ctx, userID, tokenHash, ttl)
+}
+
+func (r *passwordResetRepository) ConsumeResetToken(ctx context.Context, tokenHash string) (uuid.UUID, bool, error) {
+	return r.tokens.consume(ctx, tokenHash)
+}
+
+// AcquireSendSlot cho phép gửi email đặt lại mật khẩu tối đa một lần mỗi interval cho mỗi tài khoản
+func (r *passwordResetRepository) AcquireSendSlot(ctx context.Context, userID uuid.UUID, interval time.Duration) (bool, error) {
+	return r.rd.SetNX(ctx, basePasswordResetSent+userID.String(), 1, interval).Result()
+}
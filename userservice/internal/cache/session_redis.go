@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Session là một lần đăng nhập, giữ nguyên ID qua các lần làm mới token
+type Session struct {
+	ID            string    `json:"id"`
+	UserAgent     string    `json:"user_agent"`
+	IP            string    `json:"ip"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastRefreshAt time.Time `json:"last_refresh_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	// token hiện tại của phiên, dùng để thu hồi riêng phiên này
+	RefreshToken    string    `json:"refresh_token"`
+	AccessJTI       string    `json:"access_jti"`
+	AccessExpiresAt time.Time `json:"access_expires_at"`
+}
+
+func sessionKey(userID uuid.UUID, sessionID string) string {
+	return baseSession + userID.String() + ":" + sessionID
+}
+
+// SaveSession lưu phiên tới khi refresh token hiện tại hết hạn
+func (r *redisRepository) SaveSession(ctx context.Context, userID uuid.UUID, session *Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	indexKey := baseSessions + userID.String()
+	pipe := r.rd.Pipeline()
+	pipe.Set(ctx, sessionKey(userID, session.ID), data, ttl)
+	pipe.ZAdd(ctx, indexKey, redis.Z{
+		Score:  float64(session.ExpiresAt.Unix()),
+		Member: session.ID,
+	})
+	pipe.Expire(ctx, indexKey, 30*24*time.Hour)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetSession trả về nil nếu phiên không tồn tại hoặc đã hết hạn
+func (r *redisRepository) GetSession(ctx context.Context, userID uuid.UUID, sessionID string) (*Session, error) {
+	data, err := r.rd.Get(ctx, sessionKey(userID, sessionID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListSessions trả về các phiên còn hiệu lực và dọn các phiên đã hết hạn khỏi index
+func (r *redisRepository) ListSessions(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	indexKey := baseSessions + userID.String()
+	if err := r.rd.ZRemRangeByScore(ctx, indexKey, "-inf", "("+strconv.FormatInt(time.Now().Unix(), 10)).Err(); err != nil {
+		return nil, err
+	}
+
+	ids, err := r.rd.ZRange(ctx, indexKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = sessionKey(userID, id)
+	}
+	values, err := r.rd.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(values))
+	var stale []interface{}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			stale = append(stale, ids[i])
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			stale = append(stale, ids[i])
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	if len(stale) > 0 {
+		_ = r.rd.ZRem(ctx, indexKey, stale...)
+	}
+	return sessions, nil
+}
+
+func (r *redisRepository) DeleteSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	pipe := r.rd.Pipeline()
+	pipe.Del(ctx, sessionKey(userID, sessionID))
+	pipe.ZRem(ctx, baseSessions+userID.String(), sessionID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
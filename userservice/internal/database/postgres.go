@@ -24,6 +24,8 @@ func NewPostgresDB(cfg *config.Config) (*gorm.DB, error) {
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
+		// chuyển lỗi của driver thành gorm.ErrDuplicatedKey... để repository xử lý
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
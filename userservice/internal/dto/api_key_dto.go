@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+
+	models "github.com/agris/user-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// CreateAPIKeyRequest: scopes rỗng nghĩa là khóa chỉ mang danh tính user, không có quyền quản trị.
+// expires_at bỏ trống thì khóa không hết hạn
+type CreateAPIKeyRequest struct {
+	Name      string              `json:"name" validate:"required,max=100"`
+	Scopes    []models.Permission `json:"scopes"`
+	ExpiresAt *time.Time          `json:"expires_at"`
+}
+
+type APIKeyResponse struct {
+	Id         uuid.UUID           `json:"id"`
+	Name       string              `json:"name"`
+	Prefix     string              `json:"prefix"`
+	Scopes     []models.Permission `json:"scopes"`
+	ExpiresAt  *time.Time          `json:"expires_at"`
+	LastUsedAt *time.Time          `json:"last_used_at"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+// CreateAPIKeyResponse trả khóa gốc duy nhất một lần, sau đó chỉ còn hash
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
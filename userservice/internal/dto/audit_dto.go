@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	models "github.com/agris/user-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// AuditQuery là query string của GET /users/audit, from/to theo RFC3339
+type AuditQuery struct {
+	ActorId  string `query:"actor_id"`
+	TargetId string `query:"target_id"`
+	Action   string `query:"action"`
+	From     string `query:"from"`
+	To       string `query:"to"`
+	Limit    int    `query:"limit"`
+	Cursor   string `query:"cursor"`
+}
+
+type AuditEventResponse struct {
+	Id        uuid.UUID          `json:"id"`
+	ActorId   *uuid.UUID         `json:"actor_id"`
+	TargetId  *uuid.UUID         `json:"target_id"`
+	Action    models.AuditAction `json:"action"`
+	Before    json.RawMessage    `json:"before,omitempty"`
+	After     json.RawMessage    `json:"after,omitempty"`
+	IP        string             `json:"ip"`
+	RequestId string             `json:"request_id"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// AuditPageResponse trả next_cursor rỗng khi đã hết dữ liệu
+type AuditPageResponse struct {
+	Data       []AuditEventResponse `json:"data"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
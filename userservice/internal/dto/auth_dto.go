@@ -1,13 +1,17 @@
 package dto
 
 import (
+	"time"
+
 	models "github.com/agris/user-service/internal/model"
 	"github.com/google/uuid"
 )
 
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 type AuthResponse struct {
@@ -17,6 +21,14 @@ type AuthResponse struct {
 	User         *UserTokenResponse `json:"user"`
 }
 
+// LoginResponse là AuthResponse, hoặc chỉ gồm các trường mfa_* khi tài khoản phải qua bước MFA
+type LoginResponse struct {
+	*AuthResponse
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+	MFAExpiresIn int64  `json:"mfa_expires_in,omitempty"`
+}
+
 type UserTokenResponse struct {
 	Id    uuid.UUID   `json:"id"`
 	Email string      `json:"email"`
@@ -25,4 +37,43 @@ type UserTokenResponse struct {
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
+	IP           string `json:"-"`
+	UserAgent    string `json:"-"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+// OIDCAuthorizeResponse là URL đăng nhập của provider kèm hash của state,
+// handler ghi hash vào cookie để callback chỉ chấp nhận state do chính trình duyệt này bắt đầu
+type OIDCAuthorizeResponse struct {
+	AuthURL   string
+	StateHash string
+	ExpiresIn time.Duration
+}
+
+// OIDCCallbackRequest là query provider gửi kèm khi chuyển hướng về callback
+type OIDCCallbackRequest struct {
+	Provider  string `json:"-"`
+	Code      string `query:"code"`
+	State     string `query:"state"`
+	Error     string `query:"error"`
+	StateHash string `json:"-"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
@@ -1,6 +1,10 @@
 package dto
 
-import models "github.com/agris/user-service/internal/model"
+import (
+	"time"
+
+	models "github.com/agris/user-service/internal/model"
+)
 
 type Response struct {
 	Message string `json:"message"`
@@ -24,4 +28,6 @@ type PageRequest struct {
 type ServiceResponse struct {
 	Err    error
 	Status int
+	// RetryAfter được trả về qua header Retry-After khi Status là 429
+	RetryAfter time.Duration
 }
@@ -0,0 +1,23 @@
+package dto
+
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	ExpiresIn  int64  `json:"expires_in"`
+}
+
+// MFACodeRequest nhận mã TOTP 6 số hoặc một mã khôi phục
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFALoginRequest struct {
+	MFAToken  string `json:"mfa_token" validate:"required"`
+	Code      string `json:"code" validate:"required"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
+}
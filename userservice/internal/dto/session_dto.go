@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+type SessionResponse struct {
+	Id            string    `json:"id"`
+	UserAgent     string    `json:"user_agent"`
+	IP            string    `json:"ip"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastRefreshAt time.Time `json:"last_refresh_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	// Current là phiên của access token đang gọi API
+	Current bool `json:"current"`
+}
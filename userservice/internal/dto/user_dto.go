@@ -42,9 +42,27 @@ var (
 )
 
 type GetUserResponse struct {
-	Id         uuid.UUID `json:"id"`
-	Name       string    `json:"name"`
-	Email      string    `json:"email"`
-	Status     Status    `json:"status"`
-	Created_at time.Time `json:"created_at"`
+	Id            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	MFAEnabled    bool      `json:"mfa_enabled"`
+	Status        Status    `json:"status"`
+	Created_at    time.Time `json:"created_at"`
+}
+
+// PublicUserResponse chỉ gồm các trường được phép chia sẻ cho service khác
+type PublicUserResponse struct {
+	Id   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// UpdateProfileRequest chỉ cập nhật các trường được gửi lên
+type UpdateProfileRequest struct {
+	Name  *string `json:"name" validate:"omitempty,min=6"`
+	Email *string `json:"email" validate:"omitempty,email"`
 }
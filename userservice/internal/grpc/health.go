@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/agris/user-service/config"
+	"github.com/agris/user-service/internal/grpc/pb/userservicepb"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultHealthCheckInterval = 5 * time.Second
+	healthPingTimeout          = 2 * time.Second
+)
+
+type dependencyCheck struct {
+	name string
+	ping func(ctx context.Context) error
+}
+
+// HealthChecker cập nhật grpc.health.v1 theo tình trạng Postgres và Redis.
+// Trạng thái ban đầu là NOT_SERVING, chỉ chuyển sang SERVING khi mọi dependency đều phản hồi.
+type HealthChecker struct {
+	server   *health.Server
+	checks   []dependencyCheck
+	interval time.Duration
+	serving  bool
+}
+
+func NewHealthChecker(cfg *config.Config, db *gorm.DB, rd *redis.Client) *HealthChecker {
+	return newHealthChecker(cfg.Server.HealthCheckInterval,
+		dependencyCheck{name: "postgres", ping: func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		}},
+		dependencyCheck{name: "redis", ping: func(ctx context.Context) error {
+			return rd.Ping(ctx).Err()
+		}},
+	)
+}
+
+func newHealthChecker(interval time.Duration, checks ...dependencyCheck) *HealthChecker {
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	h := &HealthChecker{server: health.NewServer(), checks: checks, interval: interval}
+	h.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+// Run kiểm tra ngay lần đầu rồi lặp lại theo interval tới khi ctx kết thúc
+func (h *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		h.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *HealthChecker) check(ctx context.Context) {
+	serving := true
+	for _, dep := range h.checks {
+		pingCtx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+		err := dep.ping(pingCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("health check %s failed: %v", dep.name, err)
+			}
+			serving = false
+		}
+	}
+	if ctx.Err() != nil || serving == h.serving {
+		return
+	}
+
+	h.serving = serving
+	if serving {
+		h.setStatus(healthpb.HealthCheckResponse_SERVING)
+	} else {
+		h.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+}
+
+// Shutdown chuyển mọi service sang NOT_SERVING và bỏ qua các lần cập nhật sau đó,
+// load balancer ngừng gửi request mới trong lúc server đang drain
+func (h *HealthChecker) Shutdown() {
+	h.server.Shutdown()
+}
+
+func (h *HealthChecker) setStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	h.server.SetServingStatus("", status)
+	h.server.SetServingStatus(userservicepb.UserService_ServiceDesc.ServiceName, status)
+}
@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/agris/user-service/internal/grpc/pb/userservicepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func healthStatus(t *testing.T, h *HealthChecker, service string) healthpb.HealthCheckResponse_ServingStatus {
+	resp, err := h.server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return resp.GetStatus()
+}
+
+func TestHealthChecker(t *testing.T) {
+	var redisErr error
+	h := newHealthChecker(0,
+		dependencyCheck{name: "postgres", ping: func(ctx context.Context) error { return nil }},
+		dependencyCheck{name: "redis", ping: func(ctx context.Context) error { return redisErr }},
+	)
+	userService := userservicepb.UserService_ServiceDesc.ServiceName
+
+	// chưa kiểm tra lần nào thì chưa nhận request
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, healthStatus(t, h, ""))
+
+	redisErr = errors.New("connection refused")
+	h.check(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, healthStatus(t, h, userService))
+
+	redisErr = nil
+	h.check(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, healthStatus(t, h, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, healthStatus(t, h, userService))
+
+	redisErr = errors.New("connection reset")
+	h.check(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, healthStatus(t, h, userService))
+
+	// đang tắt thì giữ NOT_SERVING dù dependency đã phản hồi lại
+	redisErr = nil
+	h.Shutdown()
+	h.check(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, healthStatus(t, h, ""))
+}
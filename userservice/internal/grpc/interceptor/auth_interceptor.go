@@ -2,24 +2,87 @@ package interceptor
 
 import (
 	"context"
+	"crypto/subtle"
+	"github.com/agris/user-service/config"
+	"github.com/agris/user-service/internal/service"
 	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/agris/user-service/pkg/tlsreload"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"slices"
 	"strings"
 )
 
+const serviceTokenHeader = "x-service-token"
+
+// infraMethodPrefixes là health check và reflection, không cần xác thực để grpc_health_probe
+// và load balancer kiểm tra được trạng thái
+var infraMethodPrefixes = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+}
+
 type AuthInterceptor struct {
-	jwtManager    *jwtMg.JWTManager
+	authService   service.AuthService
 	publicMethods map[string]bool
+	// serviceMethods chỉ dành cho service nội bộ, xác thực bằng service token thay vì token của user
+	serviceMethods map[string]bool
+	serviceTokens  []config.ServiceTokenConfig
+}
+
+func NewAuthInterceptor(authService service.AuthService, cfg *config.Config) *AuthInterceptor {
+	return &AuthInterceptor{
+		authService: authService,
+		publicMethods: map[string]bool{
+			"/user.UserService/GetCurrentUserInfo": false,
+			"/user.UserService/Authenticate":       true,
+		},
+		serviceMethods: map[string]bool{
+			"/user.UserService/GetUserById":     true,
+			"/user.UserService/BatchGetUsers":   true,
+			"/user.UserService/WatchUserEvents": true,
+		},
+		serviceTokens: cfg.Security.ServiceTokens,
+	}
+}
+
+// authenticateService trả về tên service sở hữu token, so sánh hết danh sách để thời gian không phụ thuộc vào token.
+// Token có Identity thì chứng chỉ client đã được xác minh của kết nối phải mang định danh đó.
+func (i *AuthInterceptor) authenticateService(ctx context.Context, md metadata.MD) (string, error) {
+	values := md.Get(serviceTokenHeader)
+	if len(values) == 0 || values[0] == "" {
+		return "", status.Error(codes.Unauthenticated, "invalid service token")
+	}
+	var matched *config.ServiceTokenConfig
+	for idx, st := range i.serviceTokens {
+		if st.Token != "" && subtle.ConstantTimeCompare([]byte(st.Token), []byte(values[0])) == 1 {
+			matched = &i.serviceTokens[idx]
+		}
+	}
+	if matched == nil {
+		return "", status.Error(codes.Unauthenticated, "invalid service token")
+	}
+	if matched.Identity != "" && !peerHasIdentity(ctx, matched.Identity) {
+		return "", status.Error(codes.PermissionDenied, "client certificate does not match service")
+	}
+	return matched.Name, nil
 }
 
-func NewAuthInterceptor(jwtManager *jwtMg.JWTManager) *AuthInterceptor {
-	return &AuthInterceptor{jwtManager: jwtManager, publicMethods: map[string]bool{
-		"/user.UserService/GetCurrentUserInfo": false,
-		"/user.UserService/Authenticate":       true,
-	}}
+// peerHasIdentity chỉ xét chứng chỉ đã được server xác minh theo client CA (mTLS)
+func peerHasIdentity(ctx context.Context, identity string) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return false
+	}
+	return slices.Contains(tlsreload.Identities(tlsInfo.State.VerifiedChains[0][0]), identity)
 }
 
 func (i *AuthInterceptor) Handler() grpc.UnaryServerInterceptor {
@@ -29,36 +92,87 @@ func (i *AuthInterceptor) Handler() grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		// next public method
-		if i.publicMethods[info.FullMethod] {
-			return handler(ctx, req)
+		ctx, err := i.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
 		}
 
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, status.Error(codes.Unauthenticated, "missing metadata")
-		}
+		// Tiếp tục xử lý request
+		return handler(ctx, req)
+	}
+}
 
-		authHeader := md.Get("authorization")
-		if len(authHeader) == 0 {
-			return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+// StreamHandler xác thực stream giống Handler, context đã xác thực được gắn vào stream
+func (i *AuthInterceptor) StreamHandler() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := i.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
 		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
 
-		token := strings.TrimPrefix(authHeader[0], "Bearer ")
-		if token == authHeader[0] {
-			return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+func (i *AuthInterceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	// next public method
+	if i.publicMethods[method] {
+		return ctx, nil
+	}
+	for _, prefix := range infraMethodPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return ctx, nil
 		}
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+	}
 
-		claims, err := i.jwtManager.ValidateToken(token)
+	if i.serviceMethods[method] {
+		name, err := i.authenticateService(ctx, md)
 		if err != nil {
-			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+			return nil, err
 		}
+		return context.WithValue(ctx, "service", name), nil
+	}
 
-		ctx = context.WithValue(ctx, "userId", claims.UserID)
-		ctx = context.WithValue(ctx, "role", claims.Role)
-		ctx = context.WithValue(ctx, "email", claims.Email)
+	authHeader := md.Get("authorization")
+	if len(authHeader) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+	}
 
-		// Tiếp tục xử lý request
-		return handler(ctx, req)
+	var claims *jwtMg.Claims
+	var err error
+	if apiKey, ok := strings.CutPrefix(authHeader[0], "ApiKey "); ok {
+		claims, err = i.authService.VerifyAPIKey(ctx, apiKey)
+	} else {
+		token, ok := strings.CutPrefix(authHeader[0], "Bearer ")
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+		}
+		claims, err = i.authService.VerifyAccessToken(ctx, token)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
 	}
+
+	ctx = context.WithValue(ctx, "userId", claims.UserID)
+	ctx = context.WithValue(ctx, "role", claims.Role)
+	ctx = context.WithValue(ctx, "email", claims.Email)
+	return ctx, nil
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
 }
@@ -0,0 +1,68 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/agris/user-service/config"
+	"github.com/agris/user-service/pkg/tlsreload/tlstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// peerContext giả lập kết nối mTLS mà server đã xác minh chứng chỉ client
+func peerContext(t *testing.T, ca *tlstest.CA, commonName string, token string) context.Context {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(serviceTokenHeader, token))
+	if commonName == "" {
+		return ctx
+	}
+	cert, err := ca.Issue(commonName)
+	require.NoError(t, err)
+	leaf, err := cert.Leaf()
+	require.NoError(t, err)
+	return peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf}}},
+	}})
+}
+
+func TestAuthorizeServiceIdentity(t *testing.T) {
+	ca, err := tlstest.NewCA("agris-test-ca")
+	require.NoError(t, err)
+	cfg := &config.Config{}
+	cfg.Security.ServiceTokens = []config.ServiceTokenConfig{
+		{Name: "productservice", Token: "product-token", Identity: "productservice"},
+		{Name: "reporting", Token: "reporting-token"},
+	}
+	interceptor := NewAuthInterceptor(nil, cfg)
+	method := "/user.UserService/BatchGetUsers"
+
+	tests := []struct {
+		name       string
+		commonName string
+		token      string
+		code       codes.Code
+	}{
+		{"Success - Matching Certificate", "productservice", "product-token", codes.OK},
+		{"Error - Other Certificate", "reporting", "product-token", codes.PermissionDenied},
+		{"Error - Plaintext Connection", "", "product-token", codes.PermissionDenied},
+		{"Error - Wrong Token", "productservice", "reporting-token-x", codes.Unauthenticated},
+		{"Success - Token Without Identity", "", "reporting-token", codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := interceptor.authorize(peerContext(t, ca, tt.commonName, tt.token), method)
+
+			assert.Equal(t, tt.code, status.Code(err))
+			if tt.code == codes.OK {
+				assert.NotEmpty(t, ctx.Value("service"))
+			}
+		})
+	}
+}
@@ -24,3 +24,21 @@ func LoggingInterceptor(
 
 	return resp, err
 }
+
+func LoggingStreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	start := time.Now()
+	err := handler(srv, stream)
+	log.Printf(
+		"[gRPC] stream=%s duration=%s error=%v",
+		info.FullMethod,
+		time.Since(start),
+		err,
+	)
+
+	return err
+}
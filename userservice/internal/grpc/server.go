@@ -1,21 +1,65 @@
 package grpc
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net"
+	"time"
 
 	"github.com/agris/user-service/config"
 	"github.com/agris/user-service/internal/grpc/interceptor"
 	"github.com/agris/user-service/internal/grpc/pb/userservicepb"
 	"github.com/agris/user-service/internal/grpc/service_grpc"
+	"github.com/agris/user-service/pkg/tlsreload"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
+const gracefulStopTimeout = 5 * time.Second
+
+// Server gói grpc.Server cùng listener và health checker, main điều khiển vòng đời qua Serve và Shutdown
+type Server struct {
+	server *grpc.Server
+	lis    net.Listener
+	health *HealthChecker
+	cancel context.CancelFunc
+}
+
 func NewGRPCServer(
 	config *config.Config,
 	authGRPCService *service_grpc.AuthGRPCService,
 	authInterceptor *interceptor.AuthInterceptor,
-) (*grpc.Server, func(), error) {
+	healthChecker *HealthChecker,
+) (*Server, func(), error) {
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			interceptor.LoggingInterceptor,
+			authInterceptor.Handler(),
+		),
+		grpc.ChainStreamInterceptor(
+			interceptor.LoggingStreamInterceptor,
+			authInterceptor.StreamHandler(),
+		),
+	}
+
+	// TLS, và mTLS khi có client_ca_file; chứng chỉ xoay vòng được nạp lại không cần restart
+	tlsCfg := config.Server.GRPCTLS
+	if tlsCfg.CertFile != "" {
+		reloader, err := tlsreload.New(tlsreload.Files{
+			CertFile: tlsCfg.CertFile,
+			KeyFile:  tlsCfg.KeyFile,
+			CAFile:   tlsCfg.ClientCAFile,
+		}, tlsCfg.ReloadInterval)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(reloader.ServerConfig())))
+		log.Printf("gRPC TLS enabled (mTLS: %t)", tlsCfg.ClientCAFile != "")
+	}
 
 	// Tạo listener
 	lis, err := net.Listen("tcp", ":"+config.Server.GRPCPort)
@@ -24,32 +68,63 @@ func NewGRPCServer(
 	}
 
 	// Tạo gRPC server với interceptors
-	server := grpc.NewServer(
-		grpc.ChainUnaryInterceptor(
-			interceptor.LoggingInterceptor,
-			authInterceptor.Handler(),
-		),
-	)
+	server := grpc.NewServer(opts...)
 
 	// Đăng ký service
 	userservicepb.RegisterUserServiceServer(server, authGRPCService)
+	healthpb.RegisterHealthServer(server, healthChecker.server)
+	if config.Server.GRPCReflection {
+		reflection.Register(server)
+	}
 
 	log.Printf("gRPC server configured to listen at %v", lis.Addr())
 
-	// Cleanup function
+	ctx, cancel := context.WithCancel(context.Background())
+	go healthChecker.Run(ctx)
+	s := &Server{server: server, lis: lis, health: healthChecker, cancel: cancel}
+
+	// Cleanup function, không làm gì nếu main đã gọi Shutdown
 	cleanup := func() {
-		log.Println("Shutting down gRPC server...")
-		server.GracefulStop()
+		ctx, cancel := context.WithTimeout(context.Background(), gracefulStopTimeout)
+		defer cancel()
+		if err := s.Shutdown(ctx); err != nil {
+			log.Printf("gRPC server forced to stop: %v", err)
+		}
 		lis.Close()
 	}
 
-	// Start server trong goroutine
+	return s, cleanup, nil
+}
+
+// Serve chặn tới khi server dừng, trả về nil nếu dừng do Shutdown
+func (s *Server) Serve() error {
+	log.Printf("gRPC server listening at %v", s.lis.Addr())
+	err := s.server.Serve(s.lis)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown báo NOT_SERVING rồi chờ các call đang chạy kết thúc.
+// Stream WatchUserEvents không tự kết thúc nên hết ctx thì đóng hẳn,
+// consumer sẽ kết nối lại và tiếp tục từ seq đã lưu.
+func (s *Server) Shutdown(ctx context.Context) error {
+	log.Println("Shutting down gRPC server...")
+	s.cancel()
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
 	go func() {
-		log.Printf("gRPC server listening at %v", lis.Addr())
-		if err := server.Serve(lis); err != nil {
-			log.Fatalf("failed to serve: %v", err)
-		}
+		s.server.GracefulStop()
+		close(stopped)
 	}()
-
-	return server, cleanup, nil
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		<-stopped
+		return ctx.Err()
+	}
 }
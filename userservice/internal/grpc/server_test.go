@@ -0,0 +1,59 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/config"
+	"github.com/agris/user-service/internal/grpc/interceptor"
+	"github.com/agris/user-service/internal/grpc/service_grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+)
+
+// TestServerInfraMethodsWithoutToken gọi health và reflection qua server thật với chuỗi interceptor
+// của production, client không gửi token như grpc_health_probe
+func TestServerInfraMethodsWithoutToken(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.GRPCPort = "0"
+	cfg.Server.GRPCReflection = true
+	checker := newHealthChecker(time.Hour,
+		dependencyCheck{name: "postgres", ping: func(ctx context.Context) error { return nil }},
+	)
+	server, cleanup, err := NewGRPCServer(cfg, &service_grpc.AuthGRPCService{}, interceptor.NewAuthInterceptor(nil, cfg), checker)
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+	go server.Serve()
+
+	conn, err := grpc.NewClient(server.lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	health := healthpb.NewHealthClient(conn)
+	assert.Eventually(t, func() bool {
+		resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+		return err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+	}, 2*time.Second, 10*time.Millisecond)
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.GetListServicesResponse().GetService())
+
+	// method của UserService vẫn cần token
+	err = conn.Invoke(ctx, "/user.UserService/GetCurrentUserInfo", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
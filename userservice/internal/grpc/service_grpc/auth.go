@@ -2,6 +2,7 @@ package service_grpc
 
 import (
 	"context"
+	"github.com/agris/user-service/internal/dto"
 	"github.com/agris/user-service/internal/grpc/pb/userservicepb"
 	"github.com/agris/user-service/internal/service"
 	"github.com/agris/user-service/pkg/jwtMg"
@@ -15,12 +16,12 @@ import (
 type AuthGRPCService struct {
 	userservicepb.UnimplementedUserServiceServer
 	auth        service.AuthService
-	jwtManager  *jwtMg.JWTManager
 	userService service.UserService
+	events      service.UserEventService
 }
 
-func NewAuthGRPCService(auth service.AuthService, jwtManager *jwtMg.JWTManager, userService service.UserService) *AuthGRPCService {
-	return &AuthGRPCService{auth: auth, jwtManager: jwtManager, userService: userService}
+func NewAuthGRPCService(auth service.AuthService, userService service.UserService, events service.UserEventService) *AuthGRPCService {
+	return &AuthGRPCService{auth: auth, userService: userService, events: events}
 }
 
 func (u *AuthGRPCService) GetCurrentUserInfo(ctx context.Context, empty *emptypb.Empty) (*userservicepb.UserResponse, error) {
@@ -46,23 +47,88 @@ func (u *AuthGRPCService) GetCurrentUserInfo(ctx context.Context, empty *emptypb
 }
 
 func (g *AuthGRPCService) Authenticate(ctx context.Context, authRequest *userservicepb.AuthRequest) (*userservicepb.AuthResponse, error) {
-	if authRequest.Token == "" {
+	var claims *jwtMg.Claims
+	var err error
+	switch {
+	case authRequest.ApiKey != "":
+		claims, err = g.auth.VerifyAPIKey(ctx, authRequest.ApiKey)
+	case authRequest.Token != "":
+		claims, err = g.auth.VerifyAccessToken(ctx, authRequest.Token)
+	default:
 		return nil, status.Error(codes.Unauthenticated, ErrUnAuthenticated)
 	}
-
-	claims, err := g.jwtManager.ValidateToken(authRequest.Token)
 	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, ErrUnAuthenticated)
 	}
 
-	_, errUser := g.userService.GetCurrentUser(ctx, claims.UserID)
+	user, errUser := g.userService.GetCurrentUser(ctx, claims.UserID)
 	if errUser != nil {
 		return nil, status.Error(codes.Unauthenticated, ErrUnAuthenticated)
 	}
 
+	permissions := make([]string, 0, len(claims.Permissions))
+	for _, permission := range claims.Permissions {
+		permissions = append(permissions, string(permission))
+	}
+
 	return &userservicepb.AuthResponse{
-		Valid:  true,
-		UserId: claims.UserID.String(),
-		Role:   string(claims.Role),
+		Valid:         true,
+		UserId:        claims.UserID.String(),
+		Role:          string(claims.Role),
+		Permissions:   permissions,
+		Name:          user.Name,
+		EmailVerified: user.EmailVerified,
+		Mfa:           claims.MFA,
 	}, nil
 }
+
+// GetUserById chỉ trả về thông tin công khai, user đã bị vô hiệu hóa coi như không tồn tại
+func (g *AuthGRPCService) GetUserById(ctx context.Context, request *userservicepb.GetUserByIdRequest) (*userservicepb.PublicUser, error) {
+	id, err := uuid.Parse(request.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, ErrInvalidData)
+	}
+
+	users, errUsers := g.userService.GetPublicUsers(ctx, []uuid.UUID{id})
+	if errUsers != nil {
+		return nil, status.Error(codes.Internal, errUsers.Err.Error())
+	}
+	if len(users) == 0 {
+		return nil, status.Error(codes.NotFound, ErrNotFound)
+	}
+	return toPublicUser(users[0]), nil
+}
+
+// BatchGetUsers tra cứu nhiều user trong một lần gọi, id không tìm thấy thì không có trong kết quả
+func (g *AuthGRPCService) BatchGetUsers(ctx context.Context, request *userservicepb.BatchGetUsersRequest) (*userservicepb.BatchGetUsersResponse, error) {
+	if len(request.GetIds()) > service.MaxBatchUsers {
+		return nil, status.Error(codes.InvalidArgument, ErrTooManyIds)
+	}
+
+	ids := make([]uuid.UUID, 0, len(request.GetIds()))
+	for _, raw := range request.GetIds() {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, ErrInvalidData)
+		}
+		ids = append(ids, id)
+	}
+
+	users, errUsers := g.userService.GetPublicUsers(ctx, ids)
+	if errUsers != nil {
+		return nil, status.Error(codes.Internal, errUsers.Err.Error())
+	}
+
+	response := &userservicepb.BatchGetUsersResponse{Users: make([]*userservicepb.PublicUser, 0, len(users))}
+	for _, user := range users {
+		response.Users = append(response.Users, toPublicUser(user))
+	}
+	return response, nil
+}
+
+func toPublicUser(user dto.PublicUserResponse) *userservicepb.PublicUser {
+	return &userservicepb.PublicUser{
+		Id:   user.Id.String(),
+		Name: user.Name,
+	}
+}
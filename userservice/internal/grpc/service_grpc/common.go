@@ -4,4 +4,5 @@ var (
 	ErrUnAuthenticated = "Không thể xác thực"
 	ErrInvalidData     = "Dữ liệu không hợp lệ"
 	ErrNotFound        = "Dữ liệu không tìm thấy"
+	ErrTooManyIds      = "Số lượng id vượt quá giới hạn"
 )
@@ -0,0 +1,36 @@
+package service_grpc
+
+import (
+	"github.com/agris/user-service/internal/grpc/pb/userservicepb"
+	models "github.com/agris/user-service/internal/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WatchUserEvents giữ stream mở và gửi sự kiện theo thứ tự seq,
+// client lưu seq cuối cùng đã xử lý và gửi lại trong after_seq khi kết nối lại
+func (g *AuthGRPCService) WatchUserEvents(request *userservicepb.WatchUserEventsRequest, stream grpc.ServerStreamingServer[userservicepb.UserEvent]) error {
+	if request.GetAfterSeq() < 0 {
+		return status.Error(codes.InvalidArgument, ErrInvalidData)
+	}
+
+	err := g.events.Watch(stream.Context(), request.GetAfterSeq(), request.GetFromNow(), func(event *models.UserEvent) error {
+		return stream.Send(&userservicepb.UserEvent{
+			Seq:        event.Seq,
+			Type:       string(event.Type),
+			UserId:     event.UserID.String(),
+			Name:       event.Name,
+			Role:       string(event.Role),
+			Purged:     event.Purged,
+			OccurredAt: event.CreatedAt.UnixMilli(),
+		})
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return nil
+}
@@ -12,16 +12,16 @@ import (
 	"github.com/agris/user-service/internal/database"
 	"github.com/agris/user-service/internal/grpc/interceptor"
 	"github.com/agris/user-service/internal/grpc/service_grpc"
+	"github.com/agris/user-service/internal/mailer"
 	"github.com/agris/user-service/internal/repository"
 	"github.com/agris/user-service/internal/service"
 	"github.com/agris/user-service/pkg/jwtMg"
-	"google.golang.org/grpc"
 )
 
 // Injectors from wire_grpc.go:
 
 // InitGRPCServer khởi tạo TẤT CẢ dependencies và tạo gRPC server
-func InitGRPCServer() (*grpc.Server, func(), error) {
+func InitGRPCServer() (*Server, func(), error) {
 	configConfig, err := config.Load()
 	if err != nil {
 		return nil, nil, err
@@ -35,17 +35,38 @@ func InitGRPCServer() (*grpc.Server, func(), error) {
 		return nil, nil, err
 	}
 	userRepository := repository.NewUserRepository(db, client)
-	jwtManager := jwtMg.NewJWTManager(configConfig)
+	jwtManager, err := jwtMg.NewJWTManager(configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
 	redisRepository := cache.NewRedisRepository(client)
-	authService := service.NewAuthService(userRepository, jwtManager, configConfig, redisRepository)
-	userService := service.NewUserService(userRepository)
-	authGRPCService := service_grpc.NewAuthGRPCService(authService, jwtManager, userService)
-	authInterceptor := interceptor.NewAuthInterceptor(jwtManager)
-	server, cleanup, err := NewGRPCServer(configConfig, authGRPCService, authInterceptor)
+	loginAttemptRepository := cache.NewLoginAttemptRepository(client)
+	mfaRepository := cache.NewMFARepository(client)
+	permissionRepository := repository.NewPermissionRepository(db)
+	auditRepository := repository.NewAuditRepository(db)
+	auditService := service.NewAuditService(auditRepository)
+	apiKeyRepository := repository.NewAPIKeyRepository(db)
+	identityRepository := repository.NewIdentityRepository(db)
+	oidcStateRepository := cache.NewOIDCStateRepository(client)
+	authService := service.NewAuthService(userRepository, jwtManager, configConfig, redisRepository, loginAttemptRepository, mfaRepository, permissionRepository, apiKeyRepository, identityRepository, oidcStateRepository, auditService)
+	emailVerificationRepository := cache.NewEmailVerificationRepository(client)
+	mailerMailer, err := mailer.NewMailer(configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	userService := service.NewUserService(userRepository, redisRepository, emailVerificationRepository, mailerMailer, configConfig, auditService)
+	userEventRepository := repository.NewUserEventRepository(db)
+	userEventService := service.NewUserEventService(userEventRepository, configConfig)
+	authGRPCService := service_grpc.NewAuthGRPCService(authService, userService, userEventService)
+	authInterceptor := interceptor.NewAuthInterceptor(authService, configConfig)
+	healthChecker := NewHealthChecker(configConfig, db, client)
+	server, cleanup, err := NewGRPCServer(configConfig, authGRPCService, authInterceptor, healthChecker)
 	if err != nil {
+		auditService.Close()
 		return nil, nil, err
 	}
 	return server, func() {
 		cleanup()
+		auditService.Close()
 	}, nil
 }
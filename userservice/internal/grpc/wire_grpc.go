@@ -11,11 +11,10 @@ package grpc
 //	"github.com/agris/user-service/internal/service"
 //	"github.com/agris/user-service/pkg/jwtMg"
 //	"github.com/google/wire"
-//	"google.golang.org/grpc"
 //)
 //
 //// InitGRPCServer khởi tạo TẤT CẢ dependencies và tạo gRPC server
-//func InitGRPCServer() (*grpc.Server, func(), error) {
+//func InitGRPCServer() (*Server, func(), error) {
 //	wire.Build(
 //		config.Set,
 //		cache.Set,
@@ -25,6 +24,7 @@ package grpc
 //		service.Set,
 //		interceptor.Set,
 //		service_grpc.Set,
+//		NewHealthChecker,
 //		NewGRPCServer, // ← Provider tạo server
 //	)
 //	return nil, nil, nil
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"github.com/agris/user-service/internal/dto"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"time"
+)
+
+func (h *AuthHandler) CreateAPIKey(ctx *fiber.Ctx) error {
+	claims, ok := ctx.Locals("claims").(*jwtMg.Claims)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	var request dto.CreateAPIKeyRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	if err := utils.ValidateStruct(&request); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": utils.FormatValidationError(err),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.s.CreateAPIKey(ct, claims, &request)
+	if err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+func (h *AuthHandler) ListAPIKeys(ctx *fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(uuid.UUID)
+	if !ok {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.s.ListAPIKeys(ct, userID)
+	if err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *AuthHandler) RevokeAPIKey(ctx *fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(uuid.UUID)
+	if !ok {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	keyId, err := uuid.Parse(ctx.Params("keyId"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if errRevoke := h.s.RevokeAPIKey(ct, userID, keyId); errRevoke != nil {
+		return ctx.Status(errRevoke.Status).JSON(fiber.Map{
+			"error": errRevoke.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+	"github.com/agris/user-service/internal/dto"
+	"github.com/agris/user-service/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListEvents trả nhật ký kiểm toán mới nhất trước, trang sau lấy bằng ?cursor=<next_cursor>
+func (h *AuditHandler) ListEvents(c *fiber.Ctx) error {
+	var query dto.AuditQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.auditService.ListEvents(ct, &query)
+	if err != nil {
+		return c.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return c.JSON(response)
+}
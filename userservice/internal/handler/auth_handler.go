@@ -5,7 +5,9 @@ import (
 	"github.com/agris/user-service/internal/dto"
 	"github.com/agris/user-service/internal/service"
 	"github.com/agris/user-service/internal/utils"
+	"github.com/agris/user-service/pkg/jwtMg"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"time"
 )
 
@@ -31,11 +33,15 @@ func (h *AuthHandler) Login(ctx *fiber.Ctx) error {
 		})
 	}
 
+	loginRequest.IP = ctx.IP()
+	loginRequest.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
 	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
 	defer cancel()
 
 	response, err := h.s.Login(ct, &loginRequest)
 	if err != nil {
+		setRetryAfter(ctx, err)
 		return ctx.Status(err.Status).JSON(fiber.Map{
 			"error": err.Err.Error(),
 		})
@@ -43,3 +49,115 @@ func (h *AuthHandler) Login(ctx *fiber.Ctx) error {
 
 	return ctx.JSON(response)
 }
+
+func (h *AuthHandler) RefreshToken(ctx *fiber.Ctx) error {
+	var refreshRequest dto.RefreshTokenRequest
+	if err := ctx.BodyParser(&refreshRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	if err := utils.ValidateStruct(&refreshRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": utils.FormatValidationError(err),
+		})
+	}
+
+	refreshRequest.IP = ctx.IP()
+	refreshRequest.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.s.RefreshToken(ct, &refreshRequest)
+	if err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *AuthHandler) Logout(ctx *fiber.Ctx) error {
+	claims, ok := ctx.Locals("claims").(*jwtMg.Claims)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	// refresh token là tùy chọn, body rỗng vẫn đăng xuất access token hiện tại
+	var logoutRequest dto.LogoutRequest
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&logoutRequest); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": ErrInvalidData,
+			})
+		}
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.s.Logout(ct, claims, &logoutRequest); err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *AuthHandler) LogoutAll(ctx *fiber.Ctx) error {
+	claims, ok := ctx.Locals("claims").(*jwtMg.Claims)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.s.LogoutAll(ct, claims); err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *AuthHandler) JWKS(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return ctx.JSON(h.s.JWKS())
+}
+
+func (h *AuthHandler) UnlockAccount(ctx *fiber.Ctx) error {
+	account, ok := ctx.Locals("userID").(uuid.UUID)
+	if !ok {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	userId, err := uuid.Parse(ctx.Params("userId"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if errUnlock := h.s.UnlockAccount(ct, account, userId); errUnlock != nil {
+		return ctx.Status(errUnlock.Status).JSON(fiber.Map{
+			"error": errUnlock.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -1,7 +1,22 @@
 package handler
 
+import (
+	"math"
+	"strconv"
+
+	"github.com/agris/user-service/internal/dto"
+	"github.com/gofiber/fiber/v2"
+)
+
 // error
 const (
 	ErrInvalidData         = "Dữ liệu không đúng "
 	ErrInternalServerError = "Máy chủ bị lỗi"
 )
+
+// setRetryAfter ghi header Retry-After (giây) khi service trả về thời gian phải chờ
+func setRetryAfter(ctx *fiber.Ctx, err *dto.ServiceResponse) {
+	if err.RetryAfter > 0 {
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	}
+}
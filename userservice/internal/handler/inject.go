@@ -2,4 +2,4 @@ package handler
 
 import "github.com/google/wire"
 
-var Set = wire.NewSet(NewAuthHandler, NewUserHandler)
+var Set = wire.NewSet(NewAuthHandler, NewUserHandler, NewPasswordHandler, NewAuditHandler)
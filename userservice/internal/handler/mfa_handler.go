@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"github.com/agris/user-service/internal/dto"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"time"
+)
+
+// LoginMFA là bước 2 của đăng nhập khi /users/login trả về mfa_required
+func (h *AuthHandler) LoginMFA(ctx *fiber.Ctx) error {
+	var mfaRequest dto.MFALoginRequest
+	if err := ctx.BodyParser(&mfaRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	if err := utils.ValidateStruct(&mfaRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": utils.FormatValidationError(err),
+		})
+	}
+
+	mfaRequest.IP = ctx.IP()
+	mfaRequest.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.s.LoginMFA(ct, &mfaRequest)
+	if err != nil {
+		setRetryAfter(ctx, err)
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *AuthHandler) EnrollMFA(ctx *fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(uuid.UUID)
+	if !ok {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.s.EnrollMFA(ct, userID)
+	if err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *AuthHandler) ActivateMFA(ctx *fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(uuid.UUID)
+	if !ok {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	var codeRequest dto.MFACodeRequest
+	if err := ctx.BodyParser(&codeRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	if err := utils.ValidateStruct(&codeRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": utils.FormatValidationError(err),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.s.ActivateMFA(ct, userID, &codeRequest)
+	if err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *AuthHandler) DisableMFA(ctx *fiber.Ctx) error {
+	userID, ok := ctx.Locals("userID").(uuid.UUID)
+	if !ok {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	var codeRequest dto.MFACodeRequest
+	if err := ctx.BodyParser(&codeRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	if err := utils.ValidateStruct(&codeRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": utils.FormatValidationError(err),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.s.DisableMFA(ct, userID, &codeRequest); err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
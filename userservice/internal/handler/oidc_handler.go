@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/agris/user-service/internal/dto"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/users/oidc"
+)
+
+// OIDCLogin chuyển hướng trình duyệt sang trang đăng nhập của provider
+func (h *AuthHandler) OIDCLogin(ctx *fiber.Ctx) error {
+	ct, cancel := context.WithTimeout(ctx.Context(), 10*time.Second)
+	defer cancel()
+
+	authorize, err := h.s.OIDCAuthorizeURL(ct, ctx.Params("provider"))
+	if err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	// SameSite Lax để cookie vẫn được gửi khi provider chuyển hướng về callback
+	ctx.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    authorize.StateHash,
+		Path:     oidcCookiePath,
+		MaxAge:   int(authorize.ExpiresIn.Seconds()),
+		Secure:   ctx.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return ctx.Redirect(authorize.AuthURL, fiber.StatusFound)
+}
+
+// OIDCCallback nhận code từ provider và trả về cùng kết quả với POST /users/login
+func (h *AuthHandler) OIDCCallback(ctx *fiber.Ctx) error {
+	var callbackRequest dto.OIDCCallbackRequest
+	if err := ctx.QueryParser(&callbackRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	callbackRequest.Provider = ctx.Params("provider")
+	callbackRequest.StateHash = ctx.Cookies(oidcStateCookie)
+	callbackRequest.IP = ctx.IP()
+	callbackRequest.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	// gọi token endpoint và tải JWKS của provider nên cần lâu hơn đăng nhập thường
+	ct, cancel := context.WithTimeout(ctx.Context(), 10*time.Second)
+	defer cancel()
+
+	// state chỉ dùng được một lần nên cookie cũng không còn cần
+	ctx.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Path:     oidcCookiePath,
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	response, err := h.s.OIDCCallback(ct, &callbackRequest)
+	if err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.JSON(response)
+}
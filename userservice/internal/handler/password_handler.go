@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"github.com/agris/user-service/internal/dto"
+	"github.com/agris/user-service/internal/service"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"time"
+)
+
+type PasswordHandler struct {
+	s service.PasswordService
+}
+
+func NewPasswordHandler(s service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{s: s}
+}
+
+func (h *PasswordHandler) ForgotPassword(ctx *fiber.Ctx) error {
+	var request dto.ForgotPasswordRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	if err := utils.ValidateStruct(&request); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": utils.FormatValidationError(err),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.s.ForgotPassword(ct, &request); err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusAccepted)
+}
+
+func (h *PasswordHandler) ResetPassword(ctx *fiber.Ctx) error {
+	var request dto.ResetPasswordRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	if err := utils.ValidateStruct(&request); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": utils.FormatValidationError(err),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.s.ResetPassword(ct, &request); err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// ChangePassword giữ lại phiên đang gọi, các phiên khác phải đăng nhập lại
+func (h *PasswordHandler) ChangePassword(ctx *fiber.Ctx) error {
+	claims, ok := ctx.Locals("claims").(*jwtMg.Claims)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	var request dto.ChangePasswordRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	if err := utils.ValidateStruct(&request); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": utils.FormatValidationError(err),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.s.ChangePassword(ct, claims.UserID, claims.SessionID, &request); err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// ForceResetPassword cho admin buộc user đặt lại mật khẩu qua email
+func (h *PasswordHandler) ForceResetPassword(ctx *fiber.Ctx) error {
+	account, ok := ctx.Locals("userID").(uuid.UUID)
+	if !ok {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	userId, err := uuid.Parse(ctx.Params("userId"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if errReset := h.s.ForceResetPassword(ct, account, userId); errReset != nil {
+		return ctx.Status(errReset.Status).JSON(fiber.Map{
+			"error": errReset.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
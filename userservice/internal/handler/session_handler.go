@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"time"
+)
+
+func (h *AuthHandler) ListSessions(ctx *fiber.Ctx) error {
+	claims, ok := ctx.Locals("claims").(*jwtMg.Claims)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.s.ListSessions(ct, claims.UserID, claims.SessionID)
+	if err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *AuthHandler) RevokeSession(ctx *fiber.Ctx) error {
+	claims, ok := ctx.Locals("claims").(*jwtMg.Claims)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	sessionId := ctx.Params("sessionId")
+	if sessionId == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.s.RevokeSession(ct, claims.UserID, sessionId); err != nil {
+		return ctx.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *AuthHandler) ListUserSessions(ctx *fiber.Ctx) error {
+	userId, err := uuid.Parse(ctx.Params("userId"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	response, errList := h.s.ListSessions(ct, userId, "")
+	if errList != nil {
+		return ctx.Status(errList.Status).JSON(fiber.Map{
+			"error": errList.Err.Error(),
+		})
+	}
+
+	return ctx.JSON(response)
+}
+
+func (h *AuthHandler) RevokeUserSession(ctx *fiber.Ctx) error {
+	account, ok := ctx.Locals("userID").(uuid.UUID)
+	if !ok {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	userId, err := uuid.Parse(ctx.Params("userId"))
+	if err != nil || ctx.Params("sessionId") == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if errRevoke := h.s.AdminRevokeSession(ct, account, userId, ctx.Params("sessionId")); errRevoke != nil {
+		return ctx.Status(errRevoke.Status).JSON(fiber.Map{
+			"error": errRevoke.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *AuthHandler) RevokeUserSessions(ctx *fiber.Ctx) error {
+	account, ok := ctx.Locals("userID").(uuid.UUID)
+	if !ok {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	userId, err := uuid.Parse(ctx.Params("userId"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(ctx.Context(), 5*time.Second)
+	defer cancel()
+
+	if errRevoke := h.s.AdminRevokeAllSessions(ct, account, userId); errRevoke != nil {
+		return ctx.Status(errRevoke.Status).JSON(fiber.Map{
+			"error": errRevoke.Err.Error(),
+		})
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
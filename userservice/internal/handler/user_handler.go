@@ -53,7 +53,7 @@ func (h *UserHandler) UpdateUserRole(c *fiber.Ctx) error {
 		Role:      request.Role,
 	}
 
-	ct, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ct, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 	defer cancel()
 
 	userUpdated, errUpdated := h.userService.UpdateUserRole(ct, &request)
@@ -131,3 +131,79 @@ func (h *UserHandler) CreateUser(ctx *fiber.Ctx) error {
 
 	return ctx.JSON(user)
 }
+
+func (h *UserHandler) VerifyEmail(c *fiber.Ctx) error {
+	ct, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.userService.VerifyEmail(ct, c.Query("token")); err != nil {
+		return c.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"email_verified": true,
+	})
+}
+
+func (h *UserHandler) ResendVerification(c *fiber.Ctx) error {
+	var request dto.ResendVerificationRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	if err := utils.ValidateStruct(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": utils.FormatValidationError(err),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.userService.ResendVerification(ct, &request); err != nil {
+		setRetryAfter(c, err)
+		return c.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	var request dto.UpdateProfileRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	if err := utils.ValidateStruct(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": utils.FormatValidationError(err),
+		})
+	}
+
+	ct, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.userService.UpdateProfile(ct, userID, &request)
+	if err != nil {
+		return c.Status(err.Status).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	}
+
+	return c.JSON(user)
+}
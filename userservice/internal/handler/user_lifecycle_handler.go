@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"time"
+)
+
+// DeactivateUser vô hiệu hóa (xóa mềm) tài khoản, có thể khôi phục bằng RestoreUser
+func (h *UserHandler) DeactivateUser(c *fiber.Ctx) error {
+	account, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	userId, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	if errAction := h.userService.DeactivateUser(ct, account, userId); errAction != nil {
+		return c.Status(errAction.Status).JSON(fiber.Map{
+			"error": errAction.Err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UserHandler) RestoreUser(c *fiber.Ctx) error {
+	account, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	userId, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	if errAction := h.userService.RestoreUser(ct, account, userId); errAction != nil {
+		return c.Status(errAction.Status).JSON(fiber.Map{
+			"error": errAction.Err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// PurgeUser xóa vĩnh viễn tài khoản
+func (h *UserHandler) PurgeUser(c *fiber.Ctx) error {
+	account, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": ErrInternalServerError,
+		})
+	}
+
+	userId, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": ErrInvalidData,
+		})
+	}
+
+	ct, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	if errAction := h.userService.PurgeUser(ct, account, userId); errAction != nil {
+		return c.Status(errAction.Status).JSON(fiber.Map{
+			"error": errAction.Err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
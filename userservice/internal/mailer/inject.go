@@ -0,0 +1,5 @@
+package mailer
+
+import "github.com/google/wire"
+
+var Set = wire.NewSet(NewMailer)
@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// LogMailer dùng cho môi trường dev: ghi email ra log, và nối vào file nếu có filePath
+type LogMailer struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+func NewLogMailer(filePath string) *LogMailer {
+	return &LogMailer{filePath: filePath}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Infof("[MAIL] : to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	if m.filePath == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/agris/user-service/config"
+)
+
+var ErrUnknownDriver = errors.New("unknown mail driver")
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer gửi email cho người dùng, chọn cài đặt bằng mail.driver
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer trả về SMTPMailer khi mail.driver là "smtp", mặc định ghi email ra log/file
+func NewMailer(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg.Mail), nil
+	case "", "log":
+		return NewLogMailer(cfg.Mail.FilePath), nil
+	default:
+		return nil, ErrUnknownDriver
+	}
+}
@@ -2,19 +2,25 @@ package middleware
 
 import (
 	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/service"
 	"github.com/agris/user-service/pkg/jwtMg"
 	"github.com/gofiber/fiber/v2"
 	"strings"
 )
 
 type AuthMiddleware struct {
-	jwtManager *jwtMg.JWTManager
+	authService service.AuthService
 }
 
-func NewAuthMiddleware(jwtManager *jwtMg.JWTManager) *AuthMiddleware {
-	return &AuthMiddleware{jwtManager: jwtManager}
+func NewAuthMiddleware(authService service.AuthService) *AuthMiddleware {
+	return &AuthMiddleware{authService: authService}
 }
 
+const (
+	schemeBearer = "Bearer"
+	schemeAPIKey = "ApiKey"
+)
+
 func (atw *AuthMiddleware) Authorize() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
@@ -25,15 +31,13 @@ func (atw *AuthMiddleware) Authorize() fiber.Handler {
 		}
 
 		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		if len(parts) != 2 || (parts[0] != schemeBearer && parts[0] != schemeAPIKey) {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": ErrTokenInvalid,
 			})
 		}
 
-		token := parts[1]
-
-		claims, err := atw.jwtManager.ValidateToken(token)
+		claims, err := atw.verify(c, parts[0], parts[1])
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": ErrAuth,
@@ -48,26 +52,31 @@ func (atw *AuthMiddleware) Authorize() fiber.Handler {
 	}
 }
 
-func (atw *AuthMiddleware) RequireRole(roles ...models.Role) fiber.Handler {
+// RequirePermission yêu cầu token có đủ mọi quyền được liệt kê.
+// Phải đặt sau Authorize().
+func (atw *AuthMiddleware) RequirePermission(permissions ...models.Permission) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-
-		userRole, ok := c.Locals("role").(models.Role)
-
+		claims, ok := c.Locals("claims").(*jwtMg.Claims)
 		if !ok {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": ok,
+				"error": ErrAuth,
 			})
 		}
 
-		for _, role := range roles {
-			if userRole == role {
-				return c.Next()
+		for _, permission := range permissions {
+			if !claims.HasPermission(permission) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": ErrPermission,
+				})
 			}
 		}
 
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": ErrPermission,
-		})
+		if atw.authService.RequiresMFA(claims) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": ErrMFARequired,
+			})
+		}
+		return c.Next()
 	}
 }
 
@@ -79,12 +88,11 @@ func (atw *AuthMiddleware) Optional() fiber.Handler {
 		}
 
 		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		if len(parts) != 2 || (parts[0] != schemeBearer && parts[0] != schemeAPIKey) {
 			return c.Next()
 		}
 
-		token := parts[1]
-		claims, err := atw.jwtManager.ValidateToken(token)
+		claims, err := atw.verify(c, parts[0], parts[1])
 		if err == nil {
 			c.Locals("userID", claims.UserID)
 			c.Locals("email", claims.Email)
@@ -95,3 +103,30 @@ func (atw *AuthMiddleware) Optional() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// RequireSession chặn request xác thực bằng API key ở các thao tác gắn với phiên đăng nhập
+// (đăng xuất, đổi mật khẩu, MFA, quản lý phiên và API key). Phải đặt sau Authorize().
+func (atw *AuthMiddleware) RequireSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("claims").(*jwtMg.Claims)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": ErrAuth,
+			})
+		}
+		if claims.TokenType == jwtMg.TokenTypeAPIKey {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": ErrAPIKeyNotAllowed,
+			})
+		}
+		return c.Next()
+	}
+}
+
+// verify xác thực access token (scheme Bearer) hoặc API key (scheme ApiKey)
+func (atw *AuthMiddleware) verify(c *fiber.Ctx, scheme string, credential string) (*jwtMg.Claims, error) {
+	if scheme == schemeAPIKey {
+		return atw.authService.VerifyAPIKey(c.Context(), credential)
+	}
+	return atw.authService.VerifyAccessToken(c.Context(), credential)
+}
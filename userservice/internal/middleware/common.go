@@ -4,4 +4,7 @@ var (
 	ErrPermission   = "Bạn không có quyền truy cập"
 	ErrAuth         = "Tài khoản không thể xác thực"
 	ErrTokenInvalid = "Token không đúng mẫu"
+	ErrMFARequired  = "Vui lòng đăng nhập bằng xác thực hai bước để dùng quyền này"
+	// ErrAPIKeyNotAllowed trả về khi dùng API key cho thao tác chỉ dành cho phiên đăng nhập
+	ErrAPIKeyNotAllowed = "Không thể dùng API key cho thao tác này"
 )
@@ -1,11 +1,11 @@
 package middleware
 
-import "github.com/agris/user-service/pkg/jwtMg"
+import "github.com/agris/user-service/internal/service"
 
 type Middleware struct {
 	Auth *AuthMiddleware
 }
 
-func NewMiddleware(jwtManager *jwtMg.JWTManager) *Middleware {
-	return &Middleware{Auth: NewAuthMiddleware(jwtManager)}
+func NewMiddleware(authService service.AuthService) *Middleware {
+	return &Middleware{Auth: NewAuthMiddleware(authService)}
 }
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/agris/user-service/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+// RequestMeta gắn IP client và request ID (header X-Request-ID, tự sinh nếu không có)
+// vào Locals để service đọc qua context khi ghi nhật ký kiểm toán
+func RequestMeta() fiber.Handler {
+	withRequestID := requestid.New(requestid.Config{ContextKey: service.CtxKeyRequestID})
+	return func(c *fiber.Ctx) error {
+		c.Locals(service.CtxKeyIP, c.IP())
+		return withRequestID(c)
+	}
+}
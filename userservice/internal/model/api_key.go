@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey là khóa truy cập cho script/tích hợp, chỉ lưu hash sha256 của khóa.
+// Scopes là các permission được giao cho khóa, quyền thực tế là phần giao với quyền hiện tại của role.
+type APIKey struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name   string    `gorm:"type:varchar(100);not null"`
+	// Prefix là phần đầu của khóa để người dùng nhận ra khóa trong danh sách
+	Prefix     string       `gorm:"type:varchar(16);not null"`
+	KeyHash    string       `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	Scopes     []Permission `gorm:"type:jsonb;serializer:json"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
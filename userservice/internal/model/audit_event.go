@@ -0,0 +1,52 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditAction string
+
+const (
+	AuditLoginSucceeded      AuditAction = "auth.login_succeeded"
+	AuditLoginFailed         AuditAction = "auth.login_failed"
+	AuditAccountLocked       AuditAction = "auth.account_locked"
+	AuditAccountUnlocked     AuditAction = "auth.account_unlocked"
+	AuditMFAEnabled          AuditAction = "mfa.enabled"
+	AuditMFADisabled         AuditAction = "mfa.disabled"
+	AuditMFARecoveryCodeUsed AuditAction = "mfa.recovery_code_used"
+	AuditSessionRevoked      AuditAction = "session.revoked"
+	AuditSessionsRevoked     AuditAction = "session.revoked_all"
+	AuditUserRegistered      AuditAction = "user.registered"
+	AuditProfileUpdated      AuditAction = "user.profile_updated"
+	AuditRoleUpdated         AuditAction = "user.role_updated"
+	AuditUserDeactivated     AuditAction = "user.deactivated"
+	AuditUserRestored        AuditAction = "user.restored"
+	AuditUserPurged          AuditAction = "user.purged"
+	AuditPasswordChanged     AuditAction = "password.changed"
+	AuditPasswordReset       AuditAction = "password.reset"
+	AuditPasswordResetForced AuditAction = "password.reset_forced"
+	AuditAPIKeyCreated       AuditAction = "api_key.created"
+	AuditAPIKeyRevoked       AuditAction = "api_key.revoked"
+	AuditIdentityLinked      AuditAction = "user.identity_linked"
+)
+
+// AuditEvent chỉ được thêm mới, bảng audit_events chặn UPDATE/DELETE bằng trigger.
+// ActorID nil khi hành động không gắn với user đã đăng nhập (đăng nhập sai, quên mật khẩu...)
+type AuditEvent struct {
+	ID        uuid.UUID       `gorm:"type:uuid;primaryKey"`
+	ActorID   *uuid.UUID      `gorm:"type:uuid;index"`
+	TargetID  *uuid.UUID      `gorm:"type:uuid;index"`
+	Action    AuditAction     `gorm:"type:varchar(64);not null;index"`
+	Before    json.RawMessage `gorm:"type:jsonb"`
+	After     json.RawMessage `gorm:"type:jsonb"`
+	IP        string          `gorm:"type:text"`
+	RequestID string          `gorm:"type:text"`
+	CreatedAt time.Time       `gorm:"not null"`
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
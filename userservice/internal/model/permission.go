@@ -0,0 +1,22 @@
+package model
+
+type Permission string
+
+const (
+	PermUsersRead       Permission = "users:read"
+	PermUsersWrite      Permission = "users:write"
+	PermUsersRoleWrite  Permission = "users:role:write"
+	PermRatingsModerate Permission = "ratings:moderate"
+	PermProductsWrite   Permission = "products:write"
+	PermAuditRead       Permission = "audit:read"
+)
+
+// RolePermission gán quyền cho vai trò, dữ liệu nằm trong bảng role_permissions
+type RolePermission struct {
+	Role       Role       `gorm:"type:text;primaryKey"`
+	Permission Permission `gorm:"type:text;primaryKey"`
+}
+
+func (RolePermission) TableName() string {
+	return "role_permissions"
+}
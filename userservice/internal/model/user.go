@@ -10,8 +10,9 @@ import (
 type Role string
 
 const (
-	RoleUser  Role = "user"
-	RoleAdmin Role = "admin"
+	RoleUser      Role = "user"
+	RoleAdmin     Role = "admin"
+	RoleModerator Role = "moderator"
 )
 
 type User struct {
@@ -20,9 +21,15 @@ type User struct {
 	Email        string    `gorm:"type:varchar(255);uniqueIndex;not null"`
 	PasswordHash string    `gorm:"type:varchar(255);not null" json:"-"`
 	Role         Role      `gorm:"type:varchar(20);default:'user';index"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	// EmailVerifiedAt nil nghĩa là email chưa được xác minh
+	EmailVerifiedAt *time.Time
+	// MFASecret chỉ có giá trị khi MFA đã được kích hoạt
+	MFASecret        string     `gorm:"column:mfa_secret" json:"-"`
+	MFAEnabledAt     *time.Time `gorm:"column:mfa_enabled_at"`
+	MFARecoveryCodes string     `gorm:"column:mfa_recovery_codes" json:"-"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
 }
 
 func (User) TableName() string {
@@ -35,3 +42,11 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) IsMFAEnabled() bool {
+	return u.MFAEnabledAt != nil && u.MFASecret != ""
+}
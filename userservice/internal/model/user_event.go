@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserEventType string
+
+const (
+	UserEventCreated     UserEventType = "created"
+	UserEventUpdated     UserEventType = "updated"
+	UserEventDeleted     UserEventType = "deleted"
+	UserEventRoleChanged UserEventType = "role_changed"
+)
+
+// UserEvent là một thay đổi ở thông tin công khai của user, được ghi cùng transaction với thay đổi đó.
+// Seq tăng dần nên consumer lưu Seq cuối cùng để tiếp tục khi kết nối lại.
+type UserEvent struct {
+	Seq    int64         `gorm:"primaryKey;autoIncrement"`
+	UserID uuid.UUID     `gorm:"type:uuid;not null"`
+	Type   UserEventType `gorm:"type:varchar(32);not null"`
+	Name   string        `gorm:"type:text"`
+	Role   Role          `gorm:"type:varchar(20)"`
+	// Purged chỉ có với UserEventDeleted, false là xóa mềm (vô hiệu hóa)
+	Purged    bool      `gorm:"not null;default:false"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+func (UserEvent) TableName() string {
+	return "user_events"
+}
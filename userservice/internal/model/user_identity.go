@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity liên kết tài khoản với một danh tính ở OIDC provider.
+// Subject là claim "sub", chỉ duy nhất trong phạm vi một provider.
+type UserIdentity struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Provider string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject  string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject"`
+	// Email là email provider trả về lúc liên kết, chỉ để tra cứu
+	Email     string `gorm:"type:varchar(255)"`
+	CreatedAt time.Time
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/internal/model"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestAPIKeyRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:api_keys?mode=memory"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.APIKey{}))
+
+	repository := NewAPIKeyRepository(db)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	key := &model.APIKey{
+		UserID:    userID,
+		Name:      "ci",
+		Prefix:    "agk_abcdefgh",
+		KeyHash:   "hash-1",
+		Scopes:    []model.Permission{model.PermUsersRead},
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, repository.Create(ctx, key))
+	require.NoError(t, repository.Create(ctx, &model.APIKey{UserID: uuid.New(), Name: "other", Prefix: "agk_other", KeyHash: "hash-2"}))
+
+	t.Run("FindByHash", func(t *testing.T) {
+		found, err := repository.FindByHash(ctx, "hash-1")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, key.ID, found.ID)
+		assert.Equal(t, []model.Permission{model.PermUsersRead}, found.Scopes)
+
+		missing, err := repository.FindByHash(ctx, "missing")
+		assert.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("TouchLastUsed", func(t *testing.T) {
+		now := time.Now()
+		require.NoError(t, repository.TouchLastUsed(ctx, key.ID, now))
+		// lần dùng ngay sau đó không ghi lại
+		require.NoError(t, repository.TouchLastUsed(ctx, key.ID, now.Add(time.Second)))
+
+		found, err := repository.FindByHash(ctx, "hash-1")
+		require.NoError(t, err)
+		require.NotNil(t, found.LastUsedAt)
+		assert.WithinDuration(t, now, *found.LastUsedAt, time.Millisecond)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		revoked, err := repository.Revoke(ctx, uuid.New(), key.ID)
+		require.NoError(t, err)
+		assert.False(t, revoked, "other user cannot revoke the key")
+
+		revoked, err = repository.Revoke(ctx, userID, key.ID)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		revoked, err = repository.Revoke(ctx, userID, key.ID)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		keys, err := repository.ListByUser(ctx, userID)
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/agris/user-service/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditFilter lọc nhật ký kiểm toán, AfterCreatedAt/AfterID là con trỏ của trang trước
+type AuditFilter struct {
+	ActorID        *uuid.UUID
+	TargetID       *uuid.UUID
+	Action         model.AuditAction
+	From           *time.Time
+	To             *time.Time
+	AfterCreatedAt *time.Time
+	AfterID        uuid.UUID
+	Limit          int
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, event *model.AuditEvent) error
+	List(ctx context.Context, filter *AuditFilter) ([]model.AuditEvent, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, event *model.AuditEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// List trả về sự kiện mới nhất trước, sắp theo (created_at, id) để con trỏ ổn định
+// khi nhiều sự kiện có cùng thời điểm
+func (r *auditRepository) List(ctx context.Context, filter *AuditFilter) ([]model.AuditEvent, error) {
+	query := r.db.WithContext(ctx).Model(&model.AuditEvent{})
+
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TargetID != nil {
+		query = query.Where("target_id = ?", *filter.TargetID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.AfterCreatedAt != nil {
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)",
+			*filter.AfterCreatedAt, *filter.AfterCreatedAt, filter.AfterID)
+	}
+
+	var events []model.AuditEvent
+	err := query.Order("created_at DESC").Order("id DESC").Limit(filter.Limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/internal/model"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestAuditRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:audit?mode=memory"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.AuditEvent{}))
+
+	repository := NewAuditRepository(db)
+	ctx := context.Background()
+
+	adminID := uuid.New()
+	userID := uuid.New()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// hai sự kiện cùng thời điểm để kiểm tra con trỏ theo id
+	events := []*model.AuditEvent{
+		{TargetID: &userID, Action: model.AuditLoginFailed, CreatedAt: base},
+		{ActorID: &userID, TargetID: &userID, Action: model.AuditLoginSucceeded, CreatedAt: base.Add(time.Minute)},
+		{ActorID: &adminID, TargetID: &userID, Action: model.AuditRoleUpdated, CreatedAt: base.Add(2 * time.Minute),
+			Before: json.RawMessage(`{"role":"user"}`), After: json.RawMessage(`{"role":"moderator"}`), IP: "10.0.0.1", RequestID: "req-1"},
+		{ActorID: &adminID, TargetID: &userID, Action: model.AuditUserDeactivated, CreatedAt: base.Add(2 * time.Minute)},
+	}
+	for _, event := range events {
+		require.NoError(t, repository.Create(ctx, event))
+		assert.NotEqual(t, uuid.Nil, event.ID)
+	}
+
+	t.Run("Cursor pagination", func(t *testing.T) {
+		var seen []uuid.UUID
+		filter := &AuditFilter{Limit: 3}
+		page, err := repository.List(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, page, 3)
+		for _, event := range page {
+			seen = append(seen, event.ID)
+		}
+
+		last := page[len(page)-1]
+		filter.AfterCreatedAt = &last.CreatedAt
+		filter.AfterID = last.ID
+		page, err = repository.List(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		seen = append(seen, page[0].ID)
+
+		assert.ElementsMatch(t, []uuid.UUID{events[0].ID, events[1].ID, events[2].ID, events[3].ID}, seen)
+		assert.Equal(t, events[0].ID, page[0].ID)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		page, err := repository.List(ctx, &AuditFilter{ActorID: &adminID, Action: model.AuditRoleUpdated, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.JSONEq(t, `{"role":"user"}`, string(page[0].Before))
+		assert.JSONEq(t, `{"role":"moderator"}`, string(page[0].After))
+		assert.Equal(t, "10.0.0.1", page[0].IP)
+		assert.Equal(t, "req-1", page[0].RequestID)
+
+		from := base.Add(30 * time.Second)
+		to := base.Add(2 * time.Minute)
+		page, err = repository.List(ctx, &AuditFilter{TargetID: &userID, From: &from, To: &to, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, model.AuditLoginSucceeded, page[0].Action)
+	})
+}
@@ -10,4 +10,6 @@ var baseUserEmail = "user:email:"
 var (
 	ErrInternalServer = errors.New("Máy chủ bị lỗi")
 	ErrNotFound       = errors.New("Không tìm thấy dữ liệu")
+	ErrDuplicateEmail = errors.New("Email đã được sử dụng")
+	ErrInvalidRole    = errors.New("Vai trò không tồn tại")
 )
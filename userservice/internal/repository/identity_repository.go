@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/agris/user-service/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type IdentityRepository interface {
+	// FindByProviderSubject trả về nil nếu danh tính chưa được liên kết với tài khoản nào
+	FindByProviderSubject(ctx context.Context, provider string, subject string) (*model.UserIdentity, error)
+	Create(ctx context.Context, identity *model.UserIdentity) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) FindByProviderSubject(ctx context.Context, provider string, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).Take(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	if identity.ID == uuid.Nil {
+		identity.ID = uuid.New()
+	}
+	return r.db.WithContext(ctx).Create(identity).Error
+}
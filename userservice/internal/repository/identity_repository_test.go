@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agris/user-service/internal/model"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestIdentityRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:user_identities?mode=memory"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.UserIdentity{}))
+
+	repository := NewIdentityRepository(db)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	identity := &model.UserIdentity{UserID: userID, Provider: "google", Subject: "sub-1", Email: "user@example.com"}
+	require.NoError(t, repository.Create(ctx, identity))
+	assert.NotEqual(t, uuid.Nil, identity.ID)
+
+	t.Run("FindByProviderSubject", func(t *testing.T) {
+		found, err := repository.FindByProviderSubject(ctx, "google", "sub-1")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, userID, found.UserID)
+
+		// cùng subject ở provider khác là danh tính khác
+		missing, err := repository.FindByProviderSubject(ctx, "github", "sub-1")
+		assert.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("Duplicate Provider Subject", func(t *testing.T) {
+		err := repository.Create(ctx, &model.UserIdentity{UserID: uuid.New(), Provider: "google", Subject: "sub-1"})
+		assert.Error(t, err)
+	})
+}
@@ -2,4 +2,4 @@ package repository
 
 import "github.com/google/wire"
 
-var Set = wire.NewSet(NewUserRepository)
+var Set = wire.NewSet(NewUserRepository, NewPermissionRepository, NewAuditRepository, NewAPIKeyRepository, NewIdentityRepository, NewUserEventRepository)
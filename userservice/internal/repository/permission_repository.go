@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/agris/user-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type PermissionRepository interface {
+	ListByRole(ctx context.Context, role model.Role) ([]model.Permission, error)
+}
+
+type permissionRepository struct {
+	db *gorm.DB
+}
+
+func NewPermissionRepository(db *gorm.DB) PermissionRepository {
+	return &permissionRepository{db: db}
+}
+
+// ListByRole chỉ được gọi khi cấp token nên không cần cache,
+// thay đổi quyền có hiệu lực từ lần làm mới token tiếp theo
+func (r *permissionRepository) ListByRole(ctx context.Context, role model.Role) ([]model.Permission, error) {
+	var permissions []model.Permission
+	err := r.db.WithContext(ctx).Model(&model.RolePermission{}).
+		Where("role = ?", role).
+		Order("permission").
+		Pluck("permission", &permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
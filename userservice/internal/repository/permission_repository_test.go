@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agris/user-service/internal/model"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestPermissionRepositoryListByRole(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:permissions?mode=memory"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.RolePermission{}))
+	require.NoError(t, db.Create([]model.RolePermission{
+		{Role: model.RoleAdmin, Permission: model.PermUsersWrite},
+		{Role: model.RoleAdmin, Permission: model.PermRatingsModerate},
+		{Role: model.RoleModerator, Permission: model.PermRatingsModerate},
+	}).Error)
+
+	repository := NewPermissionRepository(db)
+
+	permissions, err := repository.ListByRole(context.Background(), model.RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Permission{model.PermRatingsModerate, model.PermUsersWrite}, permissions)
+
+	permissions, err = repository.ListByRole(context.Background(), model.RoleUser)
+	assert.NoError(t, err)
+	assert.Empty(t, permissions)
+}
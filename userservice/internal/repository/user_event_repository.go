@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/agris/user-service/internal/model"
+	"gorm.io/gorm"
+)
+
+type UserEventRepository interface {
+	ListAfter(ctx context.Context, afterSeq int64, limit int) ([]model.UserEvent, error)
+	LatestSeq(ctx context.Context) (int64, error)
+}
+
+type userEventRepository struct {
+	db *gorm.DB
+}
+
+func NewUserEventRepository(db *gorm.DB) UserEventRepository {
+	return &userEventRepository{db: db}
+}
+
+// ListAfter trả về các sự kiện có seq lớn hơn afterSeq theo thứ tự tăng dần
+func (r *userEventRepository) ListAfter(ctx context.Context, afterSeq int64, limit int) ([]model.UserEvent, error) {
+	var events []model.UserEvent
+	err := r.db.WithContext(ctx).Where("seq > ?", afterSeq).Order("seq ASC").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *userEventRepository) LatestSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	err := r.db.WithContext(ctx).Model(&model.UserEvent{}).Select("COALESCE(MAX(seq), 0)").Scan(&seq).Error
+	return seq, err
+}
+
+// userEventsLockKey là khóa advisory để các transaction ghi user_events lần lượt
+const userEventsLockKey int64 = 0x75736572_65766e74
+
+// appendUserEvent được gọi trong transaction của userRepository để sự kiện và thay đổi cùng thành công hoặc cùng thất bại.
+// seq của BIGSERIAL được cấp lúc insert nhưng chỉ thấy được khi commit, hai transaction song song có thể commit
+// seq lớn trước seq nhỏ và ListAfter sẽ bỏ qua seq nhỏ. Khóa advisory giữ tới hết transaction nên transaction
+// sau chỉ lấy seq khi transaction trước đã commit, seq luôn hiện ra theo thứ tự tăng dần.
+// SQLite (test) chỉ cho một transaction ghi tại một thời điểm nên không cần khóa.
+func appendUserEvent(tx *gorm.DB, eventType model.UserEventType, user *model.User, purged bool) error {
+	if tx.Dialector.Name() == "postgres" {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", userEventsLockKey).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Create(&model.UserEvent{
+		UserID:    user.ID,
+		Type:      eventType,
+		Name:      user.Name,
+		Role:      user.Role,
+		Purged:    purged,
+		CreatedAt: time.Now(),
+	}).Error
+}
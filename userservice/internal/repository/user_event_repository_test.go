@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"github.com/agris/user-service/internal/model"
+	"github.com/google/uuid"
+)
+
+func (suite *UserRepositoryTestSuite) userEvents() []model.UserEvent {
+	events, err := NewUserEventRepository(suite.db).ListAfter(suite.ctx, 0, 100)
+	suite.Require().NoError(err)
+	return events
+}
+
+func (suite *UserRepositoryTestSuite) TestUserEvents() {
+	suite.Run("success - lifecycle emits events in order", func() {
+		suite.cleanupUsers()
+		suite.db.Exec("DELETE FROM user_events")
+		user := &model.User{ID: uuid.New(), Name: "Before", Email: "events@example.com", PasswordHash: "password", Role: model.RoleUser}
+
+		_, err := suite.repository.Create(suite.ctx, user)
+		suite.Require().NoError(err)
+
+		// đổi mật khẩu không phải thông tin công khai nên không phát sự kiện
+		user.PasswordHash = "changed"
+		_, resp := suite.repository.Update(suite.ctx, user)
+		suite.Require().Nil(resp)
+
+		user.Name = "After"
+		user.Role = model.RoleAdmin
+		_, resp = suite.repository.Update(suite.ctx, user)
+		suite.Require().Nil(resp)
+
+		suite.Require().NoError(suite.repository.Delete(suite.ctx, user.ID))
+		suite.Require().Nil(suite.repository.Restore(suite.ctx, user.ID))
+		suite.Require().Nil(suite.repository.HardDelete(suite.ctx, user.ID))
+
+		events := suite.userEvents()
+		types := make([]model.UserEventType, 0, len(events))
+		for _, event := range events {
+			suite.Equal(user.ID, event.UserID)
+			types = append(types, event.Type)
+		}
+		suite.Equal([]model.UserEventType{
+			model.UserEventCreated,
+			model.UserEventUpdated,
+			model.UserEventRoleChanged,
+			model.UserEventDeleted,
+			model.UserEventUpdated,
+			model.UserEventDeleted,
+		}, types)
+		suite.Equal("After", events[2].Name)
+		suite.Equal(model.RoleAdmin, events[2].Role)
+		suite.False(events[3].Purged)
+		suite.True(events[5].Purged)
+
+		latest, err := NewUserEventRepository(suite.db).LatestSeq(suite.ctx)
+		suite.NoError(err)
+		suite.Equal(events[5].Seq, latest)
+
+		after, err := NewUserEventRepository(suite.db).ListAfter(suite.ctx, events[3].Seq, 100)
+		suite.NoError(err)
+		suite.Len(after, 2)
+	})
+
+	suite.Run("success - missing user emits nothing", func() {
+		suite.db.Exec("DELETE FROM user_events")
+
+		suite.NoError(suite.repository.Delete(suite.ctx, uuid.New()))
+		suite.NotNil(suite.repository.HardDelete(suite.ctx, uuid.New()))
+
+		suite.Empty(suite.userEvents())
+	})
+}
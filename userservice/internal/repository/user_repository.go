@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
@@ -18,9 +19,14 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) (*model.User, error)
 	FindByID(ctx context.Context, id uuid.UUID) (*model.User, *dto.ServiceResponse)
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]model.User, error)
 	FindByEmail(ctx context.Context, email string, delete bool) (*model.User, *dto.ServiceResponse)
+	EmailTaken(ctx context.Context, email string, excludeID uuid.UUID) (bool, error)
 	Update(ctx context.Context, user *model.User) (*model.User, *dto.ServiceResponse)
+	SwapRecoveryCodes(ctx context.Context, id uuid.UUID, current string, next string) (bool, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	Restore(ctx context.Context, id uuid.UUID) *dto.ServiceResponse
+	HardDelete(ctx context.Context, id uuid.UUID) *dto.ServiceResponse
 	List(ctx context.Context, pageRequest *dto.PageRequest) (*dto.PageResponse, error)
 }
 
@@ -34,9 +40,14 @@ func NewUserRepository(db *gorm.DB, rd *redis.Client) UserRepository {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *model.User) (*model.User, error) {
-	result := r.db.WithContext(ctx).Create(user)
-	if result.Error != nil {
-		return nil, result.Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return appendUserEvent(tx, model.UserEventCreated, user, false)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return user, nil
 }
@@ -72,6 +83,21 @@ func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Use
 	return &user, nil
 }
 
+// FindByIDs lấy nhiều user trong một truy vấn, bỏ qua id không tồn tại hoặc đã bị xóa mềm.
+// Không đi qua redis vì mỗi lần gọi là một tập id khác nhau.
+func (r *userRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]model.User, error) {
+	users := make([]model.User, 0, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ? AND deleted_at IS NULL", ids).Find(&users).Error
+	if err != nil {
+		log.Error("[ERROR] : [USERREPOSITORY] : " + err.Error())
+		return nil, err
+	}
+	return users, nil
+}
+
 func (r *userRepository) FindByEmail(ctx context.Context, email string, delete bool) (*model.User, *dto.ServiceResponse) {
 
 	// redis
@@ -109,8 +135,43 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string, delete b
 
 func (r *userRepository) Update(ctx context.Context, user *model.User) (*model.User, *dto.ServiceResponse) {
 
-	// Update và tự động trả về dữ liệu mới nhất (PostgreSQL)
-	if err := r.db.WithContext(ctx).Clauses(clause.Returning{}).Save(user).Error; err != nil {
+	// email cũ để xóa cache khi user đổi email, tên và quyền cũ để biết cần phát sự kiện nào
+	previous := r.lookupUser(ctx, user.ID)
+	previousEmail := previous.Email
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Update và tự động trả về dữ liệu mới nhất (PostgreSQL)
+		if err := tx.Clauses(clause.Returning{}).Save(user).Error; err != nil {
+			return err
+		}
+		if previous.ID == uuid.Nil {
+			return appendUserEvent(tx, model.UserEventCreated, user, false)
+		}
+		if previous.Name != user.Name {
+			if err := appendUserEvent(tx, model.UserEventUpdated, user, false); err != nil {
+				return err
+			}
+		}
+		if previous.Role != user.Role {
+			return appendUserEvent(tx, model.UserEventRoleChanged, user, false)
+		}
+		return nil
+	})
+	if err != nil {
+		// cần gorm.Config.TranslateError để nhận ra lỗi unique index của email
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, &dto.ServiceResponse{
+				Status: http.StatusConflict,
+				Err:    ErrDuplicateEmail,
+			}
+		}
+		// users.role tham chiếu tới bảng roles
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			return nil, &dto.ServiceResponse{
+				Status: http.StatusBadRequest,
+				Err:    ErrInvalidRole,
+			}
+		}
 		response := dto.ServiceResponse{
 			Status: http.StatusInternalServerError,
 			Err:    ErrInternalServer,
@@ -119,25 +180,145 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) (*model.U
 	}
 
 	// delete redis
+	emailKeys := []string{baseUserEmail + user.Email}
+	if previousEmail != "" && previousEmail != user.Email {
+		emailKeys = append(emailKeys, baseUserEmail+previousEmail)
+	}
 	_ = r.rd.Del(ctx, baseUser+user.ID.String())
-	_ = r.rd.Del(ctx, baseUserEmail+user.ID.String())
+	_ = r.rd.Del(ctx, emailKeys...)
 
 	return user, nil
 }
 
+// EmailTaken kiểm tra email đã thuộc về tài khoản khác chưa, không phân biệt hoa thường
+// và tính cả tài khoản đã xóa mềm vì unique index vẫn áp dụng cho chúng
+func (r *userRepository) EmailTaken(ctx context.Context, email string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).
+		Where("LOWER(email) = LOWER(?) AND id <> ?", email, excludeID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SwapRecoveryCodes chỉ ghi cột mfa_recovery_codes khi giá trị đang lưu vẫn là current,
+// trả về false nếu một request khác đã đổi danh sách mã trước đó
+func (r *userRepository) SwapRecoveryCodes(ctx context.Context, id uuid.UUID, current string, next string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND mfa_recovery_codes = ?", id, current).
+		Update("mfa_recovery_codes", next)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	_ = r.rd.Del(ctx, baseUser+id.String())
+	return true, nil
+}
+
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	err := r.db.WithContext(ctx).Delete(&model.User{}, id).Error
+	user := r.lookupUser(ctx, id)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&model.User{}, id)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		user.ID = id
+		return appendUserEvent(tx, model.UserEventDeleted, &user, false)
+	})
 	if err != nil {
 		log.Error("[ERROR] : [USERREPOSITORY} : 106 : " + err.Error())
 		return err
 	}
 
 	// delete redis
-	_ = r.rd.Del(ctx, baseUser+id.String())
-	_ = r.rd.Del(ctx, baseUserEmail+id.String())
+	r.clearCache(ctx, id, user.Email)
 	return nil
 }
 
+// Restore bỏ xóa mềm, chỉ áp dụng cho user đang bị xóa mềm
+func (r *userRepository) Restore(ctx context.Context, id uuid.UUID) *dto.ServiceResponse {
+	user := r.lookupUser(ctx, id)
+	var rowsAffected int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&model.User{}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Update("deleted_at", nil)
+		rowsAffected = result.RowsAffected
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		user.ID = id
+		return appendUserEvent(tx, model.UserEventUpdated, &user, false)
+	})
+	if err != nil {
+		log.Error("[ERROR] : [USERREPOSITORY] : " + err.Error())
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServer,
+		}
+	}
+	if rowsAffected == 0 {
+		return &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    ErrNotFound,
+		}
+	}
+
+	r.clearCache(ctx, id, user.Email)
+	return nil
+}
+
+// HardDelete xóa vĩnh viễn user, kể cả user đã bị xóa mềm
+func (r *userRepository) HardDelete(ctx context.Context, id uuid.UUID) *dto.ServiceResponse {
+	user := r.lookupUser(ctx, id)
+	var rowsAffected int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Delete(&model.User{}, id)
+		rowsAffected = result.RowsAffected
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		user.ID = id
+		return appendUserEvent(tx, model.UserEventDeleted, &user, true)
+	})
+	if err != nil {
+		log.Error("[ERROR] : [USERREPOSITORY] : " + err.Error())
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    ErrInternalServer,
+		}
+	}
+	if rowsAffected == 0 {
+		return &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    ErrNotFound,
+		}
+	}
+
+	r.clearCache(ctx, id, user.Email)
+	return nil
+}
+
+// lookupUser lấy id, email, tên và quyền đang lưu (kể cả user đã xóa mềm)
+// để xóa cache theo email và ghi sự kiện
+func (r *userRepository) lookupUser(ctx context.Context, id uuid.UUID) model.User {
+	var user model.User
+	_ = r.db.WithContext(ctx).Unscoped().Select("id", "email", "name", "role").Where("id = ?", id).Take(&user).Error
+	return user
+}
+
+func (r *userRepository) clearCache(ctx context.Context, id uuid.UUID, email string) {
+	_ = r.rd.Del(ctx, baseUser+id.String())
+	if email != "" {
+		_ = r.rd.Del(ctx, baseUserEmail+email)
+	}
+}
+
 func (r *userRepository) List(ctx context.Context, pageRequest *dto.PageRequest) (*dto.PageResponse, error) {
 	var users []*model.User
 	var total int64
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
@@ -32,6 +33,7 @@ func (suite *UserRepositoryTestSuite) SetupSuite() {
 		Logger:                                   logger.Default.LogMode(logger.Silent),
 		DisableForeignKeyConstraintWhenMigrating: true,
 		SkipDefaultTransaction:                   true,
+		TranslateError:                           true,
 	})
 	suite.Require().NoError(err, "Failed to open database")
 
@@ -50,6 +52,10 @@ func (suite *UserRepositoryTestSuite) SetupSuite() {
 			email TEXT NOT NULL UNIQUE,
 			password_hash TEXT NOT NULL,
 			role TEXT DEFAULT 'user',
+			email_verified_at DATETIME,
+			mfa_secret TEXT,
+			mfa_enabled_at DATETIME,
+			mfa_recovery_codes TEXT,
 			created_at DATETIME,
 			updated_at DATETIME,
 			deleted_at DATETIME
@@ -69,12 +75,15 @@ func (suite *UserRepositoryTestSuite) SetupSuite() {
 	db.Raw("SELECT count(*) FROM sqlite_master WHERE type='table' AND name='users'").Scan(&count)
 	suite.Require().Equal(int64(1), count, "Users table was not created")
 
+	suite.Require().NoError(db.AutoMigrate(&model.UserEvent{}), "Failed to create user_events table")
+
 	suite.db = db
 	suite.ctx = context.Background()
 }
 
 func (suite *UserRepositoryTestSuite) SetupTest() {
 	suite.db.Exec("DELETE FROM users")
+	suite.db.Exec("DELETE FROM user_events")
 
 	rdb, mock := redismock.NewClientMock()
 	suite.redis = mock
@@ -269,6 +278,31 @@ func (suite *UserRepositoryTestSuite) TestFindByID() {
 }
 
 // Test FindByEmail with Table Driven
+func (suite *UserRepositoryTestSuite) TestFindByIDs() {
+	suite.Run("success - skips missing and soft deleted users", func() {
+		suite.cleanupUsers()
+		active := &model.User{ID: uuid.New(), Name: "Active", Email: "active@example.com", PasswordHash: "password"}
+		deleted := &model.User{ID: uuid.New(), Name: "Deleted", Email: "deleted@example.com", PasswordHash: "password"}
+		suite.db.Create(active)
+		suite.db.Create(deleted)
+		suite.db.Delete(deleted)
+
+		users, err := suite.repository.FindByIDs(suite.ctx, []uuid.UUID{active.ID, deleted.ID, uuid.New()})
+
+		suite.NoError(err)
+		suite.Len(users, 1)
+		suite.Equal(active.ID, users[0].ID)
+		suite.Equal("Active", users[0].Name)
+	})
+
+	suite.Run("success - empty ids", func() {
+		users, err := suite.repository.FindByIDs(suite.ctx, nil)
+
+		suite.NoError(err)
+		suite.Empty(users)
+	})
+}
+
 func (suite *UserRepositoryTestSuite) TestFindByEmail() {
 	tests := []struct {
 		name           string
@@ -417,7 +451,7 @@ func (suite *UserRepositoryTestSuite) TestUpdate() {
 			},
 			mockRedis: func(user *model.User) {
 				suite.redis.ExpectDel(baseUser + user.ID.String()).SetVal(1)
-				suite.redis.ExpectDel(baseUserEmail + user.ID.String()).SetVal(1)
+				suite.redis.ExpectDel(baseUserEmail + user.Email).SetVal(1)
 			},
 			validate: func(t *testing.T, result *model.User, resp *dto.ServiceResponse) {
 				assert.Nil(t, resp)
@@ -444,7 +478,7 @@ func (suite *UserRepositoryTestSuite) TestUpdate() {
 			},
 			mockRedis: func(user *model.User) {
 				suite.redis.ExpectDel(baseUser + user.ID.String()).SetVal(1)
-				suite.redis.ExpectDel(baseUserEmail + user.ID.String()).SetVal(1)
+				suite.redis.ExpectDel(baseUserEmail + user.Email).SetVal(1)
 			},
 			validate: func(t *testing.T, result *model.User, resp *dto.ServiceResponse) {
 				assert.Nil(t, resp)
@@ -471,7 +505,7 @@ func (suite *UserRepositoryTestSuite) TestUpdate() {
 			},
 			mockRedis: func(user *model.User) {
 				suite.redis.ExpectDel(baseUser + user.ID.String()).SetVal(1)
-				suite.redis.ExpectDel(baseUserEmail + user.ID.String()).SetVal(1)
+				suite.redis.ExpectDel(baseUserEmail + user.Email).SetVal(1)
 			},
 			validate: func(t *testing.T, result *model.User, resp *dto.ServiceResponse) {
 				assert.Nil(t, resp)
@@ -479,6 +513,64 @@ func (suite *UserRepositoryTestSuite) TestUpdate() {
 				assert.Equal(t, "newpass", result.PasswordHash)
 			},
 		},
+		{
+			name: "success - update email clears both cache keys",
+			setup: func() *model.User {
+				suite.cleanupUsers()
+				user := &model.User{
+					ID:           uuid.New(),
+					Email:        "old@example.com",
+					PasswordHash: "pass",
+					Role:         model.RoleUser,
+				}
+				suite.db.Create(user)
+				return user
+			},
+			update: func(user *model.User) *model.User {
+				user.Email = "new@example.com"
+				return user
+			},
+			mockRedis: func(user *model.User) {
+				suite.redis.ExpectDel(baseUser + user.ID.String()).SetVal(1)
+				suite.redis.ExpectDel(baseUserEmail+"new@example.com", baseUserEmail+"old@example.com").SetVal(1)
+			},
+			validate: func(t *testing.T, result *model.User, resp *dto.ServiceResponse) {
+				assert.Nil(t, resp)
+				assert.Equal(t, "new@example.com", result.Email)
+				assert.NoError(t, suite.redis.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "error - duplicate email",
+			setup: func() *model.User {
+				suite.cleanupUsers()
+				suite.db.Create(&model.User{
+					ID:           uuid.New(),
+					Email:        "taken@example.com",
+					PasswordHash: "pass",
+					Role:         model.RoleUser,
+				})
+				user := &model.User{
+					ID:           uuid.New(),
+					Email:        "mine@example.com",
+					PasswordHash: "pass",
+					Role:         model.RoleUser,
+				}
+				suite.db.Create(user)
+				return user
+			},
+			update: func(user *model.User) *model.User {
+				user.Email = "taken@example.com"
+				return user
+			},
+			mockRedis: func(user *model.User) {},
+			validate: func(t *testing.T, result *model.User, resp *dto.ServiceResponse) {
+				assert.Nil(t, result)
+				assert.NotNil(t, resp)
+				assert.Equal(t, http.StatusConflict, resp.Status)
+				assert.Equal(t, ErrDuplicateEmail, resp.Err)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -515,7 +607,7 @@ func (suite *UserRepositoryTestSuite) TestDelete() {
 			},
 			mockRedis: func(id uuid.UUID) {
 				suite.redis.ExpectDel(baseUser + id.String()).SetVal(1)
-				suite.redis.ExpectDel(baseUserEmail + id.String()).SetVal(1)
+				suite.redis.ExpectDel(baseUserEmail + "delete@example.com").SetVal(1)
 			},
 			wantError: false,
 			validate: func(t *testing.T, err error, id uuid.UUID) {
@@ -534,7 +626,6 @@ func (suite *UserRepositoryTestSuite) TestDelete() {
 			},
 			mockRedis: func(id uuid.UUID) {
 				suite.redis.ExpectDel(baseUser + id.String()).SetVal(0)
-				suite.redis.ExpectDel(baseUserEmail + id.String()).SetVal(0)
 			},
 			wantError: false,
 			validate: func(t *testing.T, err error, id uuid.UUID) {
@@ -553,6 +644,121 @@ func (suite *UserRepositoryTestSuite) TestDelete() {
 	}
 }
 
+func (suite *UserRepositoryTestSuite) TestRestore() {
+	suite.Run("success - restore soft deleted user", func() {
+		suite.cleanupUsers()
+		user := &model.User{ID: uuid.New(), Email: "restore@example.com", PasswordHash: "password"}
+		suite.db.Create(user)
+		suite.db.Delete(user)
+		suite.redis.ExpectDel(baseUser + user.ID.String()).SetVal(1)
+		suite.redis.ExpectDel(baseUserEmail + user.Email).SetVal(1)
+
+		resp := suite.repository.Restore(suite.ctx, user.ID)
+
+		suite.Nil(resp)
+		var restored model.User
+		suite.NoError(suite.db.First(&restored, user.ID).Error)
+		suite.False(restored.DeletedAt.Valid)
+	})
+
+	suite.Run("error - user is not deleted", func() {
+		suite.cleanupUsers()
+		user := &model.User{ID: uuid.New(), Email: "active@example.com", PasswordHash: "password"}
+		suite.db.Create(user)
+
+		resp := suite.repository.Restore(suite.ctx, user.ID)
+
+		suite.NotNil(resp)
+		suite.Equal(http.StatusNotFound, resp.Status)
+	})
+}
+
+func (suite *UserRepositoryTestSuite) TestEmailTaken() {
+	suite.cleanupUsers()
+	user := &model.User{ID: uuid.New(), Email: "john@example.com", PasswordHash: "password"}
+	deleted := &model.User{ID: uuid.New(), Email: "deleted@example.com", PasswordHash: "password"}
+	suite.db.Create(user)
+	suite.db.Create(deleted)
+	suite.db.Delete(deleted)
+
+	suite.Run("success - other account ignoring case", func() {
+		taken, err := suite.repository.EmailTaken(suite.ctx, "John@Example.com", uuid.New())
+		suite.NoError(err)
+		suite.True(taken)
+	})
+
+	suite.Run("success - soft deleted account", func() {
+		taken, err := suite.repository.EmailTaken(suite.ctx, "deleted@example.com", user.ID)
+		suite.NoError(err)
+		suite.True(taken)
+	})
+
+	suite.Run("success - own email", func() {
+		taken, err := suite.repository.EmailTaken(suite.ctx, "JOHN@example.com", user.ID)
+		suite.NoError(err)
+		suite.False(taken)
+	})
+}
+
+func (suite *UserRepositoryTestSuite) TestSwapRecoveryCodes() {
+	suite.Run("success - current value matches", func() {
+		suite.cleanupUsers()
+		user := &model.User{ID: uuid.New(), Email: "mfa@example.com", PasswordHash: "password", MFARecoveryCodes: `["a","b"]`}
+		suite.db.Create(user)
+		suite.redis.ExpectDel(baseUser + user.ID.String()).SetVal(1)
+
+		swapped, err := suite.repository.SwapRecoveryCodes(suite.ctx, user.ID, `["a","b"]`, `["b"]`)
+
+		suite.NoError(err)
+		suite.True(swapped)
+		var stored model.User
+		suite.NoError(suite.db.First(&stored, user.ID).Error)
+		suite.Equal(`["b"]`, stored.MFARecoveryCodes)
+		suite.Equal("password", stored.PasswordHash)
+	})
+
+	suite.Run("error - codes changed by another request", func() {
+		suite.cleanupUsers()
+		user := &model.User{ID: uuid.New(), Email: "mfa@example.com", PasswordHash: "password", MFARecoveryCodes: `["b"]`}
+		suite.db.Create(user)
+
+		swapped, err := suite.repository.SwapRecoveryCodes(suite.ctx, user.ID, `["a","b"]`, `["a"]`)
+
+		suite.NoError(err)
+		suite.False(swapped)
+		var stored model.User
+		suite.NoError(suite.db.First(&stored, user.ID).Error)
+		suite.Equal(`["b"]`, stored.MFARecoveryCodes)
+	})
+}
+
+func (suite *UserRepositoryTestSuite) TestHardDelete() {
+	suite.Run("success - purge soft deleted user", func() {
+		suite.cleanupUsers()
+		user := &model.User{ID: uuid.New(), Email: "purge@example.com", PasswordHash: "password"}
+		suite.db.Create(user)
+		suite.db.Delete(user)
+		suite.redis.ExpectDel(baseUser + user.ID.String()).SetVal(1)
+		suite.redis.ExpectDel(baseUserEmail + user.Email).SetVal(1)
+
+		resp := suite.repository.HardDelete(suite.ctx, user.ID)
+
+		suite.Nil(resp)
+		var count int64
+		suite.db.Unscoped().Model(&model.User{}).Where("id = ?", user.ID).Count(&count)
+		suite.Equal(int64(0), count)
+	})
+
+	suite.Run("error - user not found", func() {
+		suite.cleanupUsers()
+
+		resp := suite.repository.HardDelete(suite.ctx, uuid.New())
+
+		suite.NotNil(resp)
+		suite.Equal(http.StatusNotFound, resp.Status)
+	})
+}
+
 // Test List with Table Driven
 func (suite *UserRepositoryTestSuite) TestList() {
 	tests := []struct {
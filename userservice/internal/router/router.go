@@ -8,13 +8,15 @@ import (
 )
 
 type RouterHandler struct {
-	authApi *handler.AuthHandler
-	userApi *handler.UserHandler
-	md      *middleware.Middleware
+	authApi     *handler.AuthHandler
+	userApi     *handler.UserHandler
+	passwordApi *handler.PasswordHandler
+	auditApi    *handler.AuditHandler
+	md          *middleware.Middleware
 }
 
-func NewRouterHandler(authApi *handler.AuthHandler, userApi *handler.UserHandler, md *middleware.Middleware) *RouterHandler {
-	return &RouterHandler{authApi: authApi, userApi: userApi, md: md}
+func NewRouterHandler(authApi *handler.AuthHandler, userApi *handler.UserHandler, passwordApi *handler.PasswordHandler, auditApi *handler.AuditHandler, md *middleware.Middleware) *RouterHandler {
+	return &RouterHandler{authApi: authApi, userApi: userApi, passwordApi: passwordApi, auditApi: auditApi, md: md}
 }
 
 func (r *RouterHandler) InitRouter(root *fiber.App) {
@@ -25,17 +27,47 @@ func (r *RouterHandler) InitRouter(root *fiber.App) {
 		})
 	})
 
+	root.Get("/.well-known/jwks.json", r.authApi.JWKS)
+
 	authGroup := (*root).Group("/users")
 	authGroup.Use(r.md.Auth.Optional())
 	authGroup.Post("/login", r.authApi.Login)
+	authGroup.Post("/login/mfa", r.authApi.LoginMFA)
 	authGroup.Post("/register", r.userApi.CreateUser)
+	authGroup.Post("/refresh", r.authApi.RefreshToken)
+	authGroup.Post("/password/forgot", r.passwordApi.ForgotPassword)
+	authGroup.Post("/password/reset", r.passwordApi.ResetPassword)
+	authGroup.Get("/verify", r.userApi.VerifyEmail)
+	authGroup.Post("/verify/resend", r.userApi.ResendVerification)
+	authGroup.Get("/oidc/:provider/login", r.authApi.OIDCLogin)
+	authGroup.Get("/oidc/:provider/callback", r.authApi.OIDCCallback)
 
 	userGroup := (*root).Group("/users")
 	userGroup.Use(r.md.Auth.Authorize())
 	userGroup.Get("/me", r.userApi.GetCurrentUserInfo)
+	userGroup.Patch("/me", r.userApi.UpdateProfile)
+	userGroup.Post("/me/password", r.md.Auth.RequireSession(), r.passwordApi.ChangePassword)
+	userGroup.Post("/logout", r.md.Auth.RequireSession(), r.authApi.Logout)
+	userGroup.Post("/logout-all", r.md.Auth.RequireSession(), r.authApi.LogoutAll)
+	userGroup.Post("/me/mfa/enroll", r.md.Auth.RequireSession(), r.authApi.EnrollMFA)
+	userGroup.Post("/me/mfa/activate", r.md.Auth.RequireSession(), r.authApi.ActivateMFA)
+	userGroup.Post("/me/mfa/disable", r.md.Auth.RequireSession(), r.authApi.DisableMFA)
+	userGroup.Get("/me/sessions", r.md.Auth.RequireSession(), r.authApi.ListSessions)
+	userGroup.Delete("/me/sessions/:sessionId", r.md.Auth.RequireSession(), r.authApi.RevokeSession)
+	userGroup.Post("/me/api-keys", r.md.Auth.RequireSession(), r.authApi.CreateAPIKey)
+	userGroup.Get("/me/api-keys", r.md.Auth.RequireSession(), r.authApi.ListAPIKeys)
+	userGroup.Delete("/me/api-keys/:keyId", r.md.Auth.RequireSession(), r.authApi.RevokeAPIKey)
 
-	userGroupWithAdminRole := (*root).Group("/users")
-	userGroupWithAdminRole.Use(r.md.Auth.RequireRole(models.RoleAdmin))
-	userGroupWithAdminRole.Get("/list", r.userApi.GetListUser)
-	userGroupWithAdminRole.Patch("/:userId/role", r.userApi.UpdateUserRole)
+	adminGroup := (*root).Group("/users")
+	adminGroup.Get("/list", r.md.Auth.RequirePermission(models.PermUsersRead), r.userApi.GetListUser)
+	adminGroup.Get("/audit", r.md.Auth.RequirePermission(models.PermAuditRead), r.auditApi.ListEvents)
+	adminGroup.Patch("/:userId/role", r.md.Auth.RequirePermission(models.PermUsersRoleWrite), r.userApi.UpdateUserRole)
+	adminGroup.Post("/:userId/unlock", r.md.Auth.RequirePermission(models.PermUsersWrite), r.authApi.UnlockAccount)
+	adminGroup.Post("/:userId/deactivate", r.md.Auth.RequirePermission(models.PermUsersWrite), r.userApi.DeactivateUser)
+	adminGroup.Post("/:userId/restore", r.md.Auth.RequirePermission(models.PermUsersWrite), r.userApi.RestoreUser)
+	adminGroup.Delete("/:userId", r.md.Auth.RequirePermission(models.PermUsersWrite), r.userApi.PurgeUser)
+	adminGroup.Post("/:userId/password/reset", r.md.Auth.RequirePermission(models.PermUsersWrite), r.passwordApi.ForceResetPassword)
+	adminGroup.Get("/:userId/sessions", r.md.Auth.RequirePermission(models.PermUsersRead), r.authApi.ListUserSessions)
+	adminGroup.Delete("/:userId/sessions", r.md.Auth.RequirePermission(models.PermUsersWrite), r.authApi.RevokeUserSessions)
+	adminGroup.Delete("/:userId/sessions/:sessionId", r.md.Auth.RequirePermission(models.PermUsersWrite), r.authApi.RevokeUserSession)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/agris/user-service/internal/cache"
 	"github.com/agris/user-service/internal/database"
 	"github.com/agris/user-service/internal/handler"
+	"github.com/agris/user-service/internal/mailer"
 	"github.com/agris/user-service/internal/middleware"
 	"github.com/agris/user-service/internal/repository"
 	"github.com/agris/user-service/internal/router"
@@ -27,6 +28,7 @@ func New() (*fiber.App, error) {
 		config.Set,
 		database.Set,
 		cache.Set,
+		mailer.Set,
 		service.Set,
 		handler.Set,
 		repository.Set,
@@ -37,18 +39,26 @@ func New() (*fiber.App, error) {
 	))
 }
 
-func NewServer(router *router.RouterHandler) *fiber.App {
+func NewServer(router *router.RouterHandler, auditService service.AuditService, cfg *config.Config) *fiber.App {
 	app := fiber.New(fiber.Config{
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 5 * time.Second,
 		JSONDecoder:  json.Unmarshal,
 		JSONEncoder:  json.Marshal,
+		ProxyHeader:  cfg.Server.ProxyHeader,
 	})
 
 	app.Use(logger.New())
 	app.Use(cors.New())
 	recoverConfig := recoverFiber.ConfigDefault
 	app.Use(recoverFiber.New(recoverConfig))
+	app.Use(middleware.RequestMeta())
+
+	// ghi nốt nhật ký kiểm toán còn trong hàng đợi trước khi tắt
+	app.Hooks().OnShutdown(func() error {
+		auditService.Close()
+		return nil
+	})
 
 	router.InitRouter(app)
 
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agris/user-service/internal/cache"
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// apiKeyPrefix giúp nhận ra khóa bị lộ (secret scanning) và loại sớm chuỗi không phải API key
+	apiKeyPrefix = "agk_"
+	// apiKeyDisplayLength là số ký tự đầu của khóa được lưu để hiển thị
+	apiKeyDisplayLength = 12
+)
+
+// CreateAPIKey tạo khóa cho chính user, scopes phải nằm trong quyền hiện tại của role.
+// Khóa gốc chỉ được trả về một lần, database chỉ lưu hash.
+func (s *authService) CreateAPIKey(ctx context.Context, claims *jwtMg.Claims, request *dto.CreateAPIKeyRequest) (*dto.CreateAPIKeyResponse, *dto.ServiceResponse) {
+	if claims == nil || request == nil || strings.TrimSpace(request.Name) == "" {
+		return nil, badRequest(ErrInvalidData)
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, badRequest(ErrAPIKeyExpiry)
+	}
+
+	user, errFind := s.userRepo.FindByID(ctx, claims.UserID)
+	if errFind != nil || user == nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrUserNotFound),
+		}
+	}
+
+	granted, err := s.permRepo.ListByRole(ctx, user.Role)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+	scopes := make([]models.Permission, 0, len(request.Scopes))
+	for _, scope := range request.Scopes {
+		if !containsPermission(granted, scope) {
+			return nil, badRequest(ErrAPIKeyScope)
+		}
+		if !containsPermission(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	// khóa được coi như đã qua MFA nên chỉ cấp quyền quản trị khi phiên tạo khóa đã qua MFA
+	if len(scopes) > 0 && s.RequiresMFA(claims) {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusForbidden,
+			Err:    errors.New(ErrAPIKeyMFARequired),
+		}
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+	rawKey := apiKeyPrefix + token
+
+	key := &models.APIKey{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(request.Name),
+		Prefix:    rawKey[:apiKeyDisplayLength],
+		KeyHash:   utils.HashToken(rawKey),
+		Scopes:    scopes,
+		ExpiresAt: request.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	event := newAuditEvent(models.AuditAPIKeyCreated, user.ID, user.ID)
+	event.After = auditValues(map[string]any{"id": key.ID, "name": key.Name, "scopes": key.Scopes, "expires_at": key.ExpiresAt})
+	s.audit.Record(ctx, event)
+
+	return &dto.CreateAPIKeyResponse{APIKeyResponse: toAPIKeyResponse(key), Key: rawKey}, nil
+}
+
+func (s *authService) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]dto.APIKeyResponse, *dto.ServiceResponse) {
+	keys, err := s.apiKeyRepo.ListByUser(ctx, userID)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	response := make([]dto.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		response = append(response, toAPIKeyResponse(&keys[i]))
+	}
+	return response, nil
+}
+
+// RevokeAPIKey thu hồi khóa ngay lập tức, service khác xóa kết quả xác thực đã cache qua sự kiện thu hồi
+func (s *authService) RevokeAPIKey(ctx context.Context, userID uuid.UUID, keyID uuid.UUID) *dto.ServiceResponse {
+	revoked, err := s.apiKeyRepo.Revoke(ctx, userID, keyID)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return internalError()
+	}
+	if !revoked {
+		return &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrAPIKeyNotFound),
+		}
+	}
+
+	if err := s.rdRepo.PublishRevocation(ctx, cache.RevocationEvent{UserID: userID}); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+	}
+
+	event := newAuditEvent(models.AuditAPIKeyRevoked, userID, userID)
+	event.Before = auditValues(map[string]any{"id": keyID})
+	s.audit.Record(ctx, event)
+	return nil
+}
+
+// VerifyAPIKey dựng claims từ API key để middleware và Authenticate dùng chung với access token.
+// Quyền thực tế là phần giao giữa scopes của khóa và quyền hiện tại của role.
+func (s *authService) VerifyAPIKey(ctx context.Context, rawKey string) (*jwtMg.Claims, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, jwtMg.ErrInvalidToken
+	}
+
+	key, err := s.apiKeyRepo.FindByHash(ctx, utils.HashToken(rawKey))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if key == nil || !key.IsActive(now) {
+		return nil, jwtMg.ErrInvalidToken
+	}
+
+	// tài khoản bị vô hiệu hóa thì FindByID không trả về user
+	user, errFind := s.userRepo.FindByID(ctx, key.UserID)
+	if errFind != nil || user == nil {
+		return nil, jwtMg.ErrInvalidToken
+	}
+
+	granted, err := s.permRepo.ListByRole(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	permissions := make([]models.Permission, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		if containsPermission(granted, scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+	}
+
+	claims := &jwtMg.Claims{
+		UserID:        user.ID,
+		Email:         user.Email,
+		Name:          user.Name,
+		Role:          user.Role,
+		EmailVerified: user.IsEmailVerified(),
+		MFA:           true,
+		Permissions:   permissions,
+		TokenType:     jwtMg.TokenTypeAPIKey,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       key.ID.String(),
+			IssuedAt: jwt.NewNumericDate(key.CreatedAt),
+		},
+	}
+	if key.ExpiresAt != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*key.ExpiresAt)
+	}
+	return claims, nil
+}
+
+func toAPIKeyResponse(key *models.APIKey) dto.APIKeyResponse {
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []models.Permission{}
+	}
+	return dto.APIKeyResponse{
+		Id:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func containsPermission(permissions []models.Permission, permission models.Permission) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
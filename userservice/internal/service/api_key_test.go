@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/internal/cache"
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAPIKeyRepository implements repository.APIKeyRepository
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, userID uuid.UUID, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	admin := &models.User{ID: uuid.New(), Email: "admin@example.com", Role: models.RoleAdmin}
+	permRepo := &MockPermissionRepository{permissions: map[models.Role][]models.Permission{
+		models.RoleAdmin: {models.PermUsersRead, models.PermUsersWrite},
+	}}
+
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		apiKeyRepo := new(MockAPIKeyRepository)
+		audit := new(MockAuditService)
+		userRepo.On("FindByID", mock.Anything, admin.ID).Return(admin, nil)
+
+		var saved *models.APIKey
+		apiKeyRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*models.APIKey)
+		}).Return(nil)
+
+		service := NewAuthService(userRepo, nil, newTestConfig(), new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), permRepo, apiKeyRepo, new(MockIdentityRepository), new(MockOIDCStateRepository), audit)
+		expiresAt := time.Now().Add(24 * time.Hour)
+		response, errCreate := service.CreateAPIKey(context.Background(), &jwtMg.Claims{UserID: admin.ID, Role: admin.Role, MFA: true}, &dto.CreateAPIKeyRequest{
+			Name:      " ci ",
+			Scopes:    []models.Permission{models.PermUsersRead, models.PermUsersRead},
+			ExpiresAt: &expiresAt,
+		})
+
+		require.Nil(t, errCreate)
+		assert.True(t, strings.HasPrefix(response.Key, apiKeyPrefix))
+		assert.Equal(t, response.Key[:apiKeyDisplayLength], response.Prefix)
+		assert.Equal(t, "ci", response.Name)
+		assert.Equal(t, []models.Permission{models.PermUsersRead}, response.Scopes)
+
+		require.NotNil(t, saved)
+		assert.Equal(t, utils.HashToken(response.Key), saved.KeyHash)
+		assert.NotContains(t, saved.KeyHash, response.Key)
+		assert.Equal(t, []models.AuditAction{models.AuditAPIKeyCreated}, audit.Actions())
+	})
+
+	t.Run("Error - Scope Not Granted", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		apiKeyRepo := new(MockAPIKeyRepository)
+		userRepo.On("FindByID", mock.Anything, admin.ID).Return(admin, nil)
+
+		service := NewAuthService(userRepo, nil, newTestConfig(), new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), permRepo, apiKeyRepo, new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		_, errCreate := service.CreateAPIKey(context.Background(), &jwtMg.Claims{UserID: admin.ID, Role: admin.Role, MFA: true}, &dto.CreateAPIKeyRequest{
+			Name:   "ci",
+			Scopes: []models.Permission{models.PermProductsWrite},
+		})
+
+		assert.Equal(t, http.StatusBadRequest, errCreate.Status)
+		assert.Equal(t, ErrAPIKeyScope, errCreate.Err.Error())
+		apiKeyRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - Scoped Key Without MFA", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		apiKeyRepo := new(MockAPIKeyRepository)
+		userRepo.On("FindByID", mock.Anything, admin.ID).Return(admin, nil)
+
+		cfg := newTestConfig()
+		cfg.Security.MFA.RequireForAdmin = true
+		service := NewAuthService(userRepo, nil, cfg, new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), permRepo, apiKeyRepo, new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		_, errCreate := service.CreateAPIKey(context.Background(), &jwtMg.Claims{UserID: admin.ID, Role: admin.Role}, &dto.CreateAPIKeyRequest{
+			Name:   "ci",
+			Scopes: []models.Permission{models.PermUsersRead},
+		})
+
+		assert.Equal(t, http.StatusForbidden, errCreate.Status)
+		assert.Equal(t, ErrAPIKeyMFARequired, errCreate.Err.Error())
+	})
+
+	t.Run("Error - Expiry In The Past", func(t *testing.T) {
+		service := NewAuthService(new(MockUserRepository), nil, newTestConfig(), new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), permRepo, new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		expiresAt := time.Now().Add(-time.Minute)
+		_, errCreate := service.CreateAPIKey(context.Background(), &jwtMg.Claims{UserID: admin.ID, Role: admin.Role}, &dto.CreateAPIKeyRequest{
+			Name:      "ci",
+			ExpiresAt: &expiresAt,
+		})
+
+		assert.Equal(t, http.StatusBadRequest, errCreate.Status)
+		assert.Equal(t, ErrAPIKeyExpiry, errCreate.Err.Error())
+	})
+}
+
+func TestVerifyAPIKey(t *testing.T) {
+	rawKey := apiKeyPrefix + "secret"
+	user := &models.User{ID: uuid.New(), Name: "Integration", Email: "ci@example.com", Role: models.RoleModerator}
+	permRepo := &MockPermissionRepository{permissions: map[models.Role][]models.Permission{
+		models.RoleModerator: {models.PermRatingsModerate},
+	}}
+
+	t.Run("Success - Scopes Limited By Current Role", func(t *testing.T) {
+		key := &models.APIKey{
+			ID:     uuid.New(),
+			UserID: user.ID,
+			// users:read còn trong scopes nhưng role hiện tại không còn quyền này
+			Scopes:    []models.Permission{models.PermRatingsModerate, models.PermUsersRead},
+			CreatedAt: time.Now().Add(-time.Hour),
+		}
+		userRepo := new(MockUserRepository)
+		apiKeyRepo := new(MockAPIKeyRepository)
+		apiKeyRepo.On("FindByHash", mock.Anything, utils.HashToken(rawKey)).Return(key, nil)
+		apiKeyRepo.On("TouchLastUsed", mock.Anything, key.ID, mock.Anything).Return(nil)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+
+		service := NewAuthService(userRepo, nil, newTestConfig(), new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), permRepo, apiKeyRepo, new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		claims, err := service.VerifyAPIKey(context.Background(), rawKey)
+
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, claims.UserID)
+		assert.Equal(t, models.RoleModerator, claims.Role)
+		assert.Equal(t, "Integration", claims.Name)
+		assert.Equal(t, jwtMg.TokenTypeAPIKey, claims.TokenType)
+		assert.Equal(t, []models.Permission{models.PermRatingsModerate}, claims.Permissions)
+		apiKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Wrong Prefix", func(t *testing.T) {
+		apiKeyRepo := new(MockAPIKeyRepository)
+		service := NewAuthService(new(MockUserRepository), nil, newTestConfig(), new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), permRepo, apiKeyRepo, new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+
+		_, err := service.VerifyAPIKey(context.Background(), "eyJhbGciOi...")
+
+		assert.ErrorIs(t, err, jwtMg.ErrInvalidToken)
+		apiKeyRepo.AssertNotCalled(t, "FindByHash", mock.Anything, mock.Anything)
+	})
+
+	inactive := map[string]*models.APIKey{
+		"Revoked": {ID: uuid.New(), UserID: user.ID, RevokedAt: ptrTime(time.Now().Add(-time.Minute))},
+		"Expired": {ID: uuid.New(), UserID: user.ID, ExpiresAt: ptrTime(time.Now().Add(-time.Minute))},
+	}
+	for name, key := range inactive {
+		t.Run("Error - "+name, func(t *testing.T) {
+			apiKeyRepo := new(MockAPIKeyRepository)
+			apiKeyRepo.On("FindByHash", mock.Anything, utils.HashToken(rawKey)).Return(key, nil)
+
+			service := NewAuthService(new(MockUserRepository), nil, newTestConfig(), new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), permRepo, apiKeyRepo, new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+			_, err := service.VerifyAPIKey(context.Background(), rawKey)
+
+			assert.ErrorIs(t, err, jwtMg.ErrInvalidToken)
+			apiKeyRepo.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Error - Deactivated User", func(t *testing.T) {
+		key := &models.APIKey{ID: uuid.New(), UserID: user.ID}
+		userRepo := new(MockUserRepository)
+		apiKeyRepo := new(MockAPIKeyRepository)
+		apiKeyRepo.On("FindByHash", mock.Anything, utils.HashToken(rawKey)).Return(key, nil)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(nil, &dto.ServiceResponse{Status: http.StatusNotFound})
+
+		service := NewAuthService(userRepo, nil, newTestConfig(), new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), permRepo, apiKeyRepo, new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		_, err := service.VerifyAPIKey(context.Background(), rawKey)
+
+		assert.ErrorIs(t, err, jwtMg.ErrInvalidToken)
+	})
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	userID := uuid.New()
+	keyID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		apiKeyRepo := new(MockAPIKeyRepository)
+		rdRepo := new(MockRedisRepository)
+		audit := new(MockAuditService)
+		apiKeyRepo.On("Revoke", mock.Anything, userID, keyID).Return(true, nil)
+		rdRepo.On("PublishRevocation", mock.Anything, cache.RevocationEvent{UserID: userID}).Return(nil)
+
+		service := NewAuthService(new(MockUserRepository), nil, newTestConfig(), rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), apiKeyRepo, new(MockIdentityRepository), new(MockOIDCStateRepository), audit)
+		errRevoke := service.RevokeAPIKey(context.Background(), userID, keyID)
+
+		assert.Nil(t, errRevoke)
+		rdRepo.AssertExpectations(t)
+		assert.Equal(t, []models.AuditAction{models.AuditAPIKeyRevoked}, audit.Actions())
+	})
+
+	t.Run("Error - Not Found", func(t *testing.T) {
+		apiKeyRepo := new(MockAPIKeyRepository)
+		rdRepo := new(MockRedisRepository)
+		apiKeyRepo.On("Revoke", mock.Anything, userID, keyID).Return(false, nil)
+
+		service := NewAuthService(new(MockUserRepository), nil, newTestConfig(), rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), apiKeyRepo, new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		errRevoke := service.RevokeAPIKey(context.Background(), userID, keyID)
+
+		assert.Equal(t, http.StatusNotFound, errRevoke.Status)
+		assert.Equal(t, ErrAPIKeyNotFound, errRevoke.Err.Error())
+		rdRepo.AssertNotCalled(t, "PublishRevocation", mock.Anything, mock.Anything)
+	})
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
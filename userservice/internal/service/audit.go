@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/repository"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+)
+
+// key trong context do middleware RequestMeta gắn vào mỗi request
+const (
+	CtxKeyIP        = "ip"
+	CtxKeyRequestID = "requestid"
+)
+
+const (
+	auditQueueSize    = 1024
+	auditWriteTimeout = 3 * time.Second
+	auditDefaultLimit = 20
+	auditMaxLimit     = 100
+)
+
+type AuditService interface {
+	// Record không bao giờ chặn hay làm hỏng thao tác chính,
+	// hàng đợi đầy thì sự kiện bị bỏ và chỉ ghi log
+	Record(ctx context.Context, event *models.AuditEvent)
+	ListEvents(ctx context.Context, query *dto.AuditQuery) (*dto.AuditPageResponse, *dto.ServiceResponse)
+	// Close ghi nốt các sự kiện còn trong hàng đợi, gọi khi tắt server
+	Close()
+}
+
+type auditService struct {
+	auditRepo repository.AuditRepository
+	queue     chan *models.AuditEvent
+	done      chan struct{}
+	mu        sync.RWMutex
+	closed    bool
+}
+
+func NewAuditService(auditRepo repository.AuditRepository) AuditService {
+	s := &auditService{
+		auditRepo: auditRepo,
+		queue:     make(chan *models.AuditEvent, auditQueueSize),
+		done:      make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *auditService) Record(ctx context.Context, event *models.AuditEvent) {
+	// đọc IP/request ID ngay trong request vì context của fiber được tái sử dụng sau khi trả về
+	if event.IP == "" {
+		event.IP, _ = ctx.Value(CtxKeyIP).(string)
+	}
+	if event.RequestID == "" {
+		event.RequestID, _ = ctx.Value(CtxKeyRequestID).(string)
+	}
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		// postgres chỉ lưu tới micro giây, cắt trước để con trỏ khớp với dữ liệu đọc lại
+		event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		log.Warnf("[AUDIT] : dropped action=%s target=%s reason=closed", event.Action, uuidString(event.TargetID))
+		return
+	}
+	select {
+	case s.queue <- event:
+	default:
+		log.Warnf("[AUDIT] : dropped action=%s target=%s reason=queue_full", event.Action, uuidString(event.TargetID))
+	}
+}
+
+func (s *auditService) run() {
+	defer close(s.done)
+	for event := range s.queue {
+		ct, cancel := context.WithTimeout(context.Background(), auditWriteTimeout)
+		if err := s.auditRepo.Create(ct, event); err != nil {
+			log.Errorf("[AUDIT] : failed to write action=%s target=%s: %s", event.Action, uuidString(event.TargetID), err.Error())
+		}
+		cancel()
+	}
+}
+
+func (s *auditService) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+}
+
+func (s *auditService) ListEvents(ctx context.Context, query *dto.AuditQuery) (*dto.AuditPageResponse, *dto.ServiceResponse) {
+	if query == nil {
+		return nil, badRequest(ErrInvalidData)
+	}
+
+	filter := repository.AuditFilter{
+		Action: models.AuditAction(query.Action),
+		Limit:  query.Limit,
+	}
+	if filter.Limit < 1 {
+		filter.Limit = auditDefaultLimit
+	}
+	if filter.Limit > auditMaxLimit {
+		filter.Limit = auditMaxLimit
+	}
+
+	var err error
+	if filter.ActorID, err = parseOptionalUUID(query.ActorId); err != nil {
+		return nil, badRequest(ErrInvalidData)
+	}
+	if filter.TargetID, err = parseOptionalUUID(query.TargetId); err != nil {
+		return nil, badRequest(ErrInvalidData)
+	}
+	if filter.From, err = parseOptionalTime(query.From); err != nil {
+		return nil, badRequest(ErrInvalidData)
+	}
+	if filter.To, err = parseOptionalTime(query.To); err != nil {
+		return nil, badRequest(ErrInvalidData)
+	}
+	if query.Cursor != "" {
+		createdAt, id, errCursor := decodeAuditCursor(query.Cursor)
+		if errCursor != nil {
+			return nil, badRequest(ErrInvalidCursor)
+		}
+		filter.AfterCreatedAt = &createdAt
+		filter.AfterID = id
+	}
+
+	// lấy dư một bản ghi để biết còn trang sau hay không
+	limit := filter.Limit
+	filter.Limit++
+	events, errList := s.auditRepo.List(ctx, &filter)
+	if errList != nil {
+		log.Error("[ERROR] : ", errList.Error())
+		return nil, internalError()
+	}
+
+	response := dto.AuditPageResponse{Data: make([]dto.AuditEventResponse, 0, len(events))}
+	if len(events) > limit {
+		events = events[:limit]
+		last := events[limit-1]
+		response.NextCursor = encodeAuditCursor(last.CreatedAt, last.ID)
+	}
+	for _, event := range events {
+		response.Data = append(response.Data, dto.AuditEventResponse{
+			Id:        event.ID,
+			ActorId:   event.ActorID,
+			TargetId:  event.TargetID,
+			Action:    event.Action,
+			Before:    event.Before,
+			After:     event.After,
+			IP:        event.IP,
+			RequestId: event.RequestID,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	return &response, nil
+}
+
+// auditValues chuyển giá trị trước/sau thành JSON, lỗi thì bỏ qua vì audit không được làm hỏng thao tác chính
+func auditValues(values map[string]any) json.RawMessage {
+	if len(values) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(values)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil
+	}
+	return raw
+}
+
+func encodeAuditCursor(createdAt time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeAuditCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	createdAtRaw, idRaw, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, errors.New("malformed cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtRaw)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	id, err := uuid.Parse(idRaw)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	return createdAt, id, nil
+}
+
+func parseOptionalUUID(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return "-"
+	}
+	return id.String()
+}
+
+func badRequest(msg string) *dto.ServiceResponse {
+	return &dto.ServiceResponse{
+		Status: http.StatusBadRequest,
+		Err:    errors.New(msg),
+	}
+}
+
+// newAuditEvent tạo sự kiện với actor/target, uuid.Nil nghĩa là không xác định
+func newAuditEvent(action models.AuditAction, actorID uuid.UUID, targetID uuid.UUID) *models.AuditEvent {
+	event := &models.AuditEvent{Action: action}
+	if actorID != uuid.Nil {
+		event.ActorID = &actorID
+	}
+	if targetID != uuid.Nil {
+		event.TargetID = &targetID
+	}
+	return event
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAuditService giữ lại các sự kiện đã ghi để test kiểm tra
+type MockAuditService struct {
+	mu     sync.Mutex
+	events []*models.AuditEvent
+}
+
+func (m *MockAuditService) Record(ctx context.Context, event *models.AuditEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+}
+
+func (m *MockAuditService) ListEvents(ctx context.Context, query *dto.AuditQuery) (*dto.AuditPageResponse, *dto.ServiceResponse) {
+	return nil, nil
+}
+
+func (m *MockAuditService) Close() {}
+
+func (m *MockAuditService) Actions() []models.AuditAction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	actions := make([]models.AuditAction, 0, len(m.events))
+	for _, event := range m.events {
+		actions = append(actions, event.Action)
+	}
+	return actions
+}
+
+// MockAuditRepository implements repository.AuditRepository
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) List(ctx context.Context, filter *repository.AuditFilter) ([]models.AuditEvent, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AuditEvent), args.Error(1)
+}
+
+func TestAuditServiceRecord(t *testing.T) {
+	targetID := uuid.New()
+
+	t.Run("Success - Written With Request Metadata", func(t *testing.T) {
+		auditRepo := new(MockAuditRepository)
+		auditRepo.On("Create", mock.Anything, mock.MatchedBy(func(event *models.AuditEvent) bool {
+			return event.Action == models.AuditUserPurged &&
+				*event.TargetID == targetID &&
+				event.IP == "10.0.0.1" &&
+				event.RequestID == "req-1" &&
+				event.ID != uuid.Nil &&
+				!event.CreatedAt.IsZero()
+		})).Return(nil).Once()
+
+		service := NewAuditService(auditRepo)
+		ctx := context.WithValue(context.WithValue(context.Background(), CtxKeyIP, "10.0.0.1"), CtxKeyRequestID, "req-1")
+		service.Record(ctx, newAuditEvent(models.AuditUserPurged, uuid.New(), targetID))
+		service.Close()
+
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Repository Error Does Not Panic", func(t *testing.T) {
+		auditRepo := new(MockAuditRepository)
+		auditRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+
+		service := NewAuditService(auditRepo)
+		service.Record(context.Background(), newAuditEvent(models.AuditUserRestored, uuid.Nil, targetID))
+		service.Close()
+
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Queue Full - Dropped Without Blocking", func(t *testing.T) {
+		// không chạy worker để hàng đợi không được giải phóng
+		service := &auditService{queue: make(chan *models.AuditEvent, 1), done: make(chan struct{})}
+
+		finished := make(chan struct{})
+		go func() {
+			service.Record(context.Background(), newAuditEvent(models.AuditLoginFailed, uuid.Nil, targetID))
+			service.Record(context.Background(), newAuditEvent(models.AuditLoginFailed, uuid.Nil, targetID))
+			close(finished)
+		}()
+
+		select {
+		case <-finished:
+		case <-time.After(time.Second):
+			t.Fatal("Record blocked on a full queue")
+		}
+		assert.Len(t, service.queue, 1)
+	})
+
+	t.Run("Closed - Dropped", func(t *testing.T) {
+		auditRepo := new(MockAuditRepository)
+		service := NewAuditService(auditRepo)
+		service.Close()
+
+		service.Record(context.Background(), newAuditEvent(models.AuditLoginFailed, uuid.Nil, targetID))
+
+		auditRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuditServiceListEvents(t *testing.T) {
+	actorID := uuid.New()
+	createdAt := time.Date(2026, 1, 1, 12, 0, 0, 123456000, time.UTC)
+	events := []models.AuditEvent{
+		{ID: uuid.New(), ActorID: &actorID, Action: models.AuditRoleUpdated, CreatedAt: createdAt.Add(time.Minute)},
+		{ID: uuid.New(), ActorID: &actorID, Action: models.AuditUserDeactivated, CreatedAt: createdAt},
+		{ID: uuid.New(), ActorID: &actorID, Action: models.AuditUserRestored, CreatedAt: createdAt.Add(-time.Minute)},
+	}
+
+	t.Run("Success - Next Cursor", func(t *testing.T) {
+		auditRepo := new(MockAuditRepository)
+		auditRepo.On("List", mock.Anything, mock.MatchedBy(func(filter *repository.AuditFilter) bool {
+			return filter.Limit == 3 && *filter.ActorID == actorID && filter.Action == models.AuditRoleUpdated && filter.AfterCreatedAt == nil
+		})).Return(events, nil)
+
+		service := NewAuditService(auditRepo)
+		defer service.Close()
+
+		page, errList := service.ListEvents(context.Background(), &dto.AuditQuery{
+			ActorId: actorID.String(),
+			Action:  string(models.AuditRoleUpdated),
+			Limit:   2,
+		})
+
+		require.Nil(t, errList)
+		require.Len(t, page.Data, 2)
+		assert.Equal(t, events[0].ID, page.Data[0].Id)
+		require.NotEmpty(t, page.NextCursor)
+
+		cursorTime, cursorID, err := decodeAuditCursor(page.NextCursor)
+		require.NoError(t, err)
+		assert.True(t, createdAt.Equal(cursorTime))
+		assert.Equal(t, events[1].ID, cursorID)
+	})
+
+	t.Run("Success - Last Page", func(t *testing.T) {
+		auditRepo := new(MockAuditRepository)
+		auditRepo.On("List", mock.Anything, mock.MatchedBy(func(filter *repository.AuditFilter) bool {
+			return filter.AfterCreatedAt != nil && filter.AfterCreatedAt.Equal(createdAt) && filter.AfterID == events[1].ID
+		})).Return(events[2:], nil)
+
+		service := NewAuditService(auditRepo)
+		defer service.Close()
+
+		page, errList := service.ListEvents(context.Background(), &dto.AuditQuery{
+			Cursor: encodeAuditCursor(createdAt, events[1].ID),
+		})
+
+		require.Nil(t, errList)
+		require.Len(t, page.Data, 1)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("Error - Invalid Cursor", func(t *testing.T) {
+		auditRepo := new(MockAuditRepository)
+		service := NewAuditService(auditRepo)
+		defer service.Close()
+
+		_, errList := service.ListEvents(context.Background(), &dto.AuditQuery{Cursor: "not-a-cursor"})
+
+		assert.Equal(t, http.StatusBadRequest, errList.Status)
+		assert.Equal(t, ErrInvalidCursor, errList.Err.Error())
+		auditRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - Invalid Filter", func(t *testing.T) {
+		auditRepo := new(MockAuditRepository)
+		service := NewAuditService(auditRepo)
+		defer service.Close()
+
+		_, errList := service.ListEvents(context.Background(), &dto.AuditQuery{From: "yesterday"})
+
+		assert.Equal(t, http.StatusBadRequest, errList.Status)
+		auditRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUpdateUserRoleAudit(t *testing.T) {
+	accountID := uuid.New()
+	user := &models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser}
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	userRepo.On("Update", mock.Anything, mock.Anything).Return(&models.User{ID: user.ID, Role: models.RoleModerator}, nil)
+
+	rdRepo := new(MockRedisRepository)
+	rdRepo.On("RevokeAllRefreshTokens", mock.Anything, user.ID).Return(nil)
+	rdRepo.On("RevokeAccessTokensBefore", mock.Anything, user.ID, mock.Anything, mock.Anything).Return(nil)
+	rdRepo.On("PublishRevocation", mock.Anything, mock.Anything).Return(nil)
+
+	audit := new(MockAuditService)
+	service := NewUserService(userRepo, rdRepo, nil, nil, newTestConfig(), audit)
+	_, errUpdate := service.UpdateUserRole(context.Background(), &dto.UpdateRoleRequest{
+		AccountId: accountID,
+		UserId:    user.ID,
+		Role:      models.RoleModerator,
+	})
+
+	require.Nil(t, errUpdate)
+	require.Equal(t, []models.AuditAction{models.AuditRoleUpdated}, audit.Actions())
+	event := audit.events[0]
+	assert.Equal(t, accountID, *event.ActorID)
+	assert.Equal(t, user.ID, *event.TargetID)
+	assert.JSONEq(t, `{"role":"user"}`, string(event.Before))
+	assert.JSONEq(t, `{"role":"moderator"}`, string(event.After))
+}
+
+func TestLoginAudit(t *testing.T) {
+	t.Run("Unknown Email", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByEmail", mock.Anything, "ghost@example.com", true).Return(nil, &dto.ServiceResponse{Status: http.StatusNotFound, Err: errors.New(ErrUserNotFound)})
+
+		cfg := newTestConfig()
+		cfg.Security.Login.MaxAttempts = 0
+		cfg.Security.Login.IPMaxAttempts = 0
+		audit := new(MockAuditService)
+		service := NewAuthService(userRepo, nil, cfg, new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), audit)
+
+		_, errLogin := service.Login(context.Background(), &dto.LoginRequest{Email: "ghost@example.com", Password: "secret", IP: "10.0.0.2"})
+
+		require.NotNil(t, errLogin)
+		require.Equal(t, []models.AuditAction{models.AuditLoginFailed}, audit.Actions())
+		event := audit.events[0]
+		assert.Nil(t, event.ActorID)
+		assert.Nil(t, event.TargetID)
+		assert.Equal(t, "10.0.0.2", event.IP)
+		assert.JSONEq(t, `{"reason":"unknown_email","email":"ghost@example.com"}`, string(event.After))
+	})
+}
@@ -8,37 +8,188 @@ import (
 	"github.com/agris/user-service/config"
 	"github.com/agris/user-service/internal/cache"
 	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
 	"github.com/agris/user-service/internal/repository"
 	"github.com/agris/user-service/internal/utils"
 	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/agris/user-service/pkg/oidc"
 	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
 )
 
 type AuthService interface {
-	Login(ctx context.Context, request *dto.LoginRequest) (*dto.AuthResponse, *dto.ServiceResponse)
-	ValidateToken(token string) bool
+	Login(ctx context.Context, request *dto.LoginRequest) (*dto.LoginResponse, *dto.ServiceResponse)
+	LoginMFA(ctx context.Context, request *dto.MFALoginRequest) (*dto.AuthResponse, *dto.ServiceResponse)
+	RefreshToken(ctx context.Context, request *dto.RefreshTokenRequest) (*dto.AuthResponse, *dto.ServiceResponse)
+	Logout(ctx context.Context, claims *jwtMg.Claims, request *dto.LogoutRequest) *dto.ServiceResponse
+	LogoutAll(ctx context.Context, claims *jwtMg.Claims) *dto.ServiceResponse
+	VerifyAccessToken(ctx context.Context, token string) (*jwtMg.Claims, error)
+	ValidateToken(ctx context.Context, token string) bool
+	JWKS() *jwtMg.JWKS
+	UnlockAccount(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse
+	EnrollMFA(ctx context.Context, userID uuid.UUID) (*dto.MFAEnrollResponse, *dto.ServiceResponse)
+	ActivateMFA(ctx context.Context, userID uuid.UUID, request *dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, *dto.ServiceResponse)
+	DisableMFA(ctx context.Context, userID uuid.UUID, request *dto.MFACodeRequest) *dto.ServiceResponse
+	RequiresMFA(claims *jwtMg.Claims) bool
+	ListSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]dto.SessionResponse, *dto.ServiceResponse)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) *dto.ServiceResponse
+	AdminRevokeSession(ctx context.Context, accountID uuid.UUID, userID uuid.UUID, sessionID string) *dto.ServiceResponse
+	AdminRevokeAllSessions(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse
+	CreateAPIKey(ctx context.Context, claims *jwtMg.Claims, request *dto.CreateAPIKeyRequest) (*dto.CreateAPIKeyResponse, *dto.ServiceResponse)
+	ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]dto.APIKeyResponse, *dto.ServiceResponse)
+	RevokeAPIKey(ctx context.Context, userID uuid.UUID, keyID uuid.UUID) *dto.ServiceResponse
+	VerifyAPIKey(ctx context.Context, key string) (*jwtMg.Claims, error)
+	OIDCAuthorizeURL(ctx context.Context, provider string) (*dto.OIDCAuthorizeResponse, *dto.ServiceResponse)
+	OIDCCallback(ctx context.Context, request *dto.OIDCCallbackRequest) (*dto.LoginResponse, *dto.ServiceResponse)
 }
 
 type authService struct {
-	userRepo   repository.UserRepository
-	jwtManager *jwtMg.JWTManager
-	cfg        *config.Config
-	rdRepo     cache.RedisRepository
+	userRepo     repository.UserRepository
+	jwtManager   *jwtMg.JWTManager
+	cfg          *config.Config
+	rdRepo       cache.RedisRepository
+	attemptRepo  cache.LoginAttemptRepository
+	mfaRepo      cache.MFARepository
+	permRepo     repository.PermissionRepository
+	apiKeyRepo   repository.APIKeyRepository
+	identityRepo repository.IdentityRepository
+	oidcRepo     cache.OIDCStateRepository
+	audit        AuditService
+	// oidcProviders theo tên trong cấu hình security.oidc.providers
+	oidcProviders map[string]*oidc.Provider
 }
 
-func NewAuthService(userRepo repository.UserRepository, jwtManager *jwtMg.JWTManager, cfg *config.Config, rdRepo cache.RedisRepository) AuthService {
-	return &authService{userRepo: userRepo, jwtManager: jwtManager, cfg: cfg, rdRepo: rdRepo}
+func NewAuthService(userRepo repository.UserRepository, jwtManager *jwtMg.JWTManager, cfg *config.Config, rdRepo cache.RedisRepository, attemptRepo cache.LoginAttemptRepository, mfaRepo cache.MFARepository, permRepo repository.PermissionRepository, apiKeyRepo repository.APIKeyRepository, identityRepo repository.IdentityRepository, oidcRepo cache.OIDCStateRepository, audit AuditService) AuthService {
+	return &authService{
+		userRepo:      userRepo,
+		jwtManager:    jwtManager,
+		cfg:           cfg,
+		rdRepo:        rdRepo,
+		attemptRepo:   attemptRepo,
+		mfaRepo:       mfaRepo,
+		permRepo:      permRepo,
+		apiKeyRepo:    apiKeyRepo,
+		identityRepo:  identityRepo,
+		oidcRepo:      oidcRepo,
+		audit:         audit,
+		oidcProviders: newOIDCProviders(cfg.Security.OIDC.Providers),
+	}
 }
 
-func (s *authService) ValidateToken(token string) bool {
+func (s *authService) ValidateToken(ctx context.Context, token string) bool {
 	if token == "" {
 		return false
 	}
-	_, err := s.jwtManager.ValidateToken(token)
+	_, err := s.VerifyAccessToken(ctx, token)
 	return err == nil
 }
 
-func (s *authService) Login(ctx context.Context, request *dto.LoginRequest) (*dto.AuthResponse, *dto.ServiceResponse) {
+// JWKS công bố các public key đang hoạt động cho service khác
+func (s *authService) JWKS() *jwtMg.JWKS {
+	return s.jwtManager.JWKS()
+}
+
+// VerifyAccessToken kiểm tra chữ ký, hạn dùng và trạng thái thu hồi của access token
+func (s *authService) VerifyAccessToken(ctx context.Context, token string) (*jwtMg.Claims, error) {
+	claims, err := s.jwtManager.ValidateAccessToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, errRevoked := s.rdRepo.IsAccessTokenRevoked(ctx, claims.UserID, claims.ID, claims.SessionID, claims.IssuedAtTime())
+	if errRevoked != nil {
+		log.Error("[ERROR] : ", errRevoked.Error())
+		return nil, jwtMg.ErrInvalidToken
+	}
+	if revoked {
+		return nil, jwtMg.ErrRevokedToken
+	}
+
+	return claims, nil
+}
+
+func (s *authService) Logout(ctx context.Context, claims *jwtMg.Claims, request *dto.LogoutRequest) *dto.ServiceResponse {
+	if claims == nil {
+		return &dto.ServiceResponse{
+			Status: 401,
+			Err:    errors.New(ErrInvalidData),
+		}
+	}
+
+	if request != nil && request.RefreshToken != "" {
+		if err := s.rdRepo.RemoveRefreshToken(ctx, claims.UserID, request.RefreshToken); err != nil {
+			log.Error("[ERROR] : ", err.Error())
+			return &dto.ServiceResponse{
+				Status: 500,
+				Err:    errors.New(ErrInternalServerError),
+			}
+		}
+	}
+
+	// refresh token của phiên hiện tại cũng bị thu hồi dù client không gửi lên
+	if claims.SessionID != "" {
+		// các access token cũ hơn của phiên có thể hết hạn sau token đang dùng để đăng xuất
+		accessExpiresAt := claims.ExpiresAt.Time
+		session, err := s.rdRepo.GetSession(ctx, claims.UserID, claims.SessionID)
+		if err != nil {
+			log.Error("[ERROR] : ", err.Error())
+		} else if session != nil {
+			if err := s.rdRepo.RemoveRefreshToken(ctx, claims.UserID, session.RefreshToken); err != nil {
+				log.Error("[ERROR] : ", err.Error())
+			}
+			if err := s.rdRepo.DeleteSession(ctx, claims.UserID, session.ID); err != nil {
+				log.Error("[ERROR] : ", err.Error())
+			}
+			if session.AccessExpiresAt.After(accessExpiresAt) {
+				accessExpiresAt = session.AccessExpiresAt
+			}
+		}
+
+		if err := s.rdRepo.RevokeSessionAccessTokens(ctx, claims.SessionID, accessExpiresAt); err != nil {
+			log.Error("[ERROR] : ", err.Error())
+			return &dto.ServiceResponse{
+				Status: 500,
+				Err:    errors.New(ErrInternalServerError),
+			}
+		}
+	}
+
+	if err := s.rdRepo.DenyAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return &dto.ServiceResponse{
+			Status: 500,
+			Err:    errors.New(ErrInternalServerError),
+		}
+	}
+
+	publishRevocation(ctx, s.rdRepo, claims.UserID, claims.ID)
+	return nil
+}
+
+func (s *authService) LogoutAll(ctx context.Context, claims *jwtMg.Claims) *dto.ServiceResponse {
+	if claims == nil {
+		return &dto.ServiceResponse{
+			Status: 401,
+			Err:    errors.New(ErrInvalidData),
+		}
+	}
+
+	if err := revokeAllSessions(ctx, s.rdRepo, s.cfg, claims.UserID); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return &dto.ServiceResponse{
+			Status: 500,
+			Err:    errors.New(ErrInternalServerError),
+		}
+	}
+
+	if err := s.rdRepo.DenyAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+	}
+
+	return nil
+}
+
+func (s *authService) Login(ctx context.Context, request *dto.LoginRequest) (*dto.LoginResponse, *dto.ServiceResponse) {
 	// Validate input
 	if request.Email == "" || request.Password == "" {
 		response := dto.ServiceResponse{
@@ -48,13 +199,22 @@ func (s *authService) Login(ctx context.Context, request *dto.LoginRequest) (*dt
 		return nil, &response
 	}
 
+	if errBlocked := s.checkIPAllowed(ctx, request.IP); errBlocked != nil {
+		return nil, errBlocked
+	}
+
 	// Find user by email
 	user, err := s.userRepo.FindByEmail(ctx, request.Email, true)
 	if err != nil || user == nil {
+		if err != nil && err.Status == 404 {
+			s.recordLoginFailure(ctx, nil, request.IP)
+			s.auditLoginFailed(ctx, uuid.Nil, request.IP, map[string]any{"reason": "unknown_email", "email": request.Email})
+		}
 		return nil, err
 	}
 
 	if user.DeletedAt.Valid {
+		s.auditLoginFailed(ctx, user.ID, request.IP, map[string]any{"reason": "deactivated"})
 		response := dto.ServiceResponse{
 			Status: 403,
 			Err:    errors.New(ErrLockedAccount),
@@ -62,8 +222,16 @@ func (s *authService) Login(ctx context.Context, request *dto.LoginRequest) (*dt
 		return nil, &response
 	}
 
+	if errLocked := s.checkAccountLocked(ctx, user.ID); errLocked != nil {
+		return nil, errLocked
+	}
+
 	// Check password
 	if !utils.CheckPasswordHash(request.Password, user.PasswordHash) {
+		s.auditLoginFailed(ctx, user.ID, request.IP, map[string]any{"reason": "invalid_password"})
+		if errLocked := s.recordLoginFailure(ctx, &user.ID, request.IP); errLocked != nil {
+			return nil, errLocked
+		}
 		response := dto.ServiceResponse{
 			Status: 403,
 			Err:    errors.New(ErrPasswordNotMatch),
@@ -71,11 +239,120 @@ func (s *authService) Login(ctx context.Context, request *dto.LoginRequest) (*dt
 		return nil, &response
 	}
 
+	s.resetLoginFailures(ctx, user.ID)
+
+	if s.cfg.Security.EmailVerification.RequireForLogin && !user.IsEmailVerified() {
+		return nil, &dto.ServiceResponse{
+			Status: 403,
+			Err:    errors.New(ErrEmailNotVerified),
+		}
+	}
+
+	if user.IsMFAEnabled() {
+		return s.startMFAChallenge(ctx, user)
+	}
+
+	resp, errIssue := s.issueTokens(ctx, user, newSession(request.IP, request.UserAgent), false)
+	if errIssue != nil {
+		return nil, errIssue
+	}
+	s.auditLoginSucceeded(ctx, user.ID, request.IP, false)
+	return &dto.LoginResponse{AuthResponse: resp}, nil
+}
+
+func (s *authService) RefreshToken(ctx context.Context, request *dto.RefreshTokenRequest) (*dto.AuthResponse, *dto.ServiceResponse) {
+	if request == nil || request.RefreshToken == "" {
+		response := dto.ServiceResponse{
+			Status: 400,
+			Err:    errors.New(ErrInvalidData),
+		}
+		return nil, &response
+	}
+
+	claims, err := s.jwtManager.ValidateRefreshToken(request.RefreshToken)
+	if err != nil {
+		return nil, &dto.ServiceResponse{
+			Status: 401,
+			Err:    errors.New(ErrRefreshTokenInvalid),
+		}
+	}
+
+	rotated, errRotate := s.rdRepo.RotateRefreshToken(ctx, claims.UserID, request.RefreshToken, claims.ExpiresAt.Time)
+	if errRotate != nil {
+		log.Error("[ERROR] : ", errRotate.Error())
+		return nil, &dto.ServiceResponse{
+			Status: 500,
+			Err:    errors.New(ErrInternalServerError),
+		}
+	}
+
+	if !rotated {
+		// token đã bị xoay vòng trước đó => có thể bị đánh cắp, thu hồi toàn bộ phiên của user
+		used, errUsed := s.rdRepo.IsRefreshTokenUsed(ctx, claims.UserID, request.RefreshToken)
+		if errUsed != nil {
+			log.Error("[ERROR] : ", errUsed.Error())
+		}
+		if used {
+			log.Warnf("[SECURITY] : refresh token reuse detected for user %s", claims.UserID)
+			// access token đã cấp từ họ token bị lộ cũng phải bị thu hồi, không chỉ refresh token
+			if errRevoke := revokeAllSessions(ctx, s.rdRepo, s.cfg, claims.UserID); errRevoke != nil {
+				log.Error("[ERROR] : ", errRevoke.Error())
+			}
+			return nil, &dto.ServiceResponse{
+				Status: 401,
+				Err:    errors.New(ErrRefreshTokenReused),
+			}
+		}
+
+		return nil, &dto.ServiceResponse{
+			Status: 401,
+			Err:    errors.New(ErrRefreshTokenInvalid),
+		}
+	}
+
+	user, errUser := s.userRepo.FindByID(ctx, claims.UserID)
+	if errUser != nil || user == nil {
+		return nil, &dto.ServiceResponse{
+			Status: 401,
+			Err:    errors.New(ErrRefreshTokenInvalid),
+		}
+	}
+
+	// token cấp trước khi có phiên hoặc phiên đã hết hạn thì bắt đầu phiên mới
+	var session *cache.Session
+	if claims.SessionID != "" {
+		session, err = s.rdRepo.GetSession(ctx, user.ID, claims.SessionID)
+		if err != nil {
+			log.Error("[ERROR] : ", err.Error())
+		}
+	}
+	if session == nil {
+		session = newSession(request.IP, request.UserAgent)
+		if claims.SessionID != "" {
+			session.ID = claims.SessionID
+		}
+	} else {
+		session.IP = request.IP
+	}
+
+	// phiên đã qua MFA thì token mới vẫn giữ claim mfa
+	return s.issueTokens(ctx, user, session, claims.MFA)
+}
+
+// issueTokens tạo cặp access/refresh token mới và lưu refresh token vào redis
+func (s *authService) issueTokens(ctx context.Context, user *models.User, session *cache.Session, mfa bool) (*dto.AuthResponse, *dto.ServiceResponse) {
+	// quyền lấy lại mỗi lần cấp token để thay đổi vai trò có hiệu lực khi làm mới token
+	permissions, errPerm := s.permRepo.ListByRole(ctx, user.Role)
+	if errPerm != nil {
+		log.Error("[ERROR] : ", errPerm.Error())
+		return nil, internalError()
+	}
+
 	// expire refresh
 	expireRefresh := time.Now().Add(s.cfg.JWT.RefreshExpiry)
 	// Generate JWT token
-	accessToken, errAccess := s.jwtManager.GenerateAccessToken(user, s.cfg.JWT.AccessExpiry)
-	refreshToken, errRefresh := s.jwtManager.GenerateAccessToken(user, s.cfg.JWT.RefreshExpiry)
+	accessToken, errAccess := s.jwtManager.GenerateAccessToken(user, s.cfg.JWT.AccessExpiry, jwtMg.WithMFA(mfa), jwtMg.WithSessionID(session.ID), jwtMg.WithPermissions(permissions))
+	refreshToken, errRefresh := s.jwtManager.GenerateRefreshToken(user, jwtMg.WithMFA(mfa), jwtMg.WithSessionID(session.ID))
 
 	if errAccess != nil || errRefresh != nil {
 		response := dto.ServiceResponse{
@@ -104,5 +381,14 @@ func (s *authService) Login(ctx context.Context, request *dto.LoginRequest) (*dt
 		log.Error("[ERROR] : ", errRedis.Error())
 	}
 
+	session.LastRefreshAt = time.Now()
+	session.ExpiresAt = refreshToken.ExpiresAt
+	session.RefreshToken = refreshToken.Token
+	session.AccessJTI = accessToken.JTI
+	session.AccessExpiresAt = accessToken.ExpiresAt
+	if errSession := s.rdRepo.SaveSession(ctx, user.ID, session); errSession != nil {
+		log.Error("[ERROR] : ", errSession.Error())
+	}
+
 	return resp, nil
 }
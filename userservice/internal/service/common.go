@@ -11,4 +11,35 @@ const (
 	ErrLockedAccount        = "Tài khoản đã bị khóa"
 	ErrCantUpdateOwnAccount = "Không thể cập nhật tài khoản chính mình"
 	ErrInternalServerError  = "Máy chủ bị lỗi"
+	ErrRefreshTokenInvalid  = "Refresh token không hợp lệ"
+	ErrRefreshTokenReused   = "Refresh token đã được sử dụng, vui lòng đăng nhập lại"
+	ErrTooManyAttempts      = "Đăng nhập sai quá nhiều lần, vui lòng thử lại sau"
+	ErrResetTokenInvalid    = "Link đặt lại mật khẩu không hợp lệ hoặc đã hết hạn"
+	ErrVerifyTokenInvalid   = "Link xác minh email không hợp lệ hoặc đã hết hạn"
+	ErrEmailNotVerified     = "Email chưa được xác minh"
+	ErrResendTooSoon        = "Vui lòng chờ trước khi gửi lại email xác minh"
+	ErrMFAAlreadyEnabled    = "Xác thực hai bước đã được bật"
+	ErrMFANotEnabled        = "Xác thực hai bước chưa được bật"
+	ErrMFANotEnrolled       = "Chưa đăng ký xác thực hai bước hoặc yêu cầu đã hết hạn"
+	ErrMFACodeInvalid       = "Mã xác thực không đúng"
+	ErrMFAChallengeInvalid  = "Phiên xác thực hai bước không hợp lệ hoặc đã hết hạn"
+	ErrMFANotConfigured     = "Máy chủ chưa cấu hình khóa mã hóa cho xác thực hai bước"
+	ErrSessionNotFound      = "Phiên đăng nhập không tồn tại hoặc đã hết hạn"
+	ErrSamePassword         = "Mật khẩu mới phải khác mật khẩu hiện tại"
+	ErrUserNotDeactivated   = "Tài khoản không tồn tại hoặc chưa bị vô hiệu hóa"
+	ErrInvalidCursor        = "Con trỏ phân trang không hợp lệ"
+	ErrAPIKeyNotFound       = "API key không tồn tại hoặc đã bị thu hồi"
+	ErrAPIKeyScope          = "Không thể giao cho API key quyền mà tài khoản không có"
+	ErrAPIKeyExpiry         = "Thời điểm hết hạn của API key phải ở tương lai"
+	ErrAPIKeyMFARequired    = "Vui lòng đăng nhập bằng xác thực hai bước để tạo API key có quyền quản trị"
+	ErrOIDCProviderNotFound = "Nhà cung cấp đăng nhập không được hỗ trợ"
+	ErrOIDCStateInvalid     = "Phiên đăng nhập không hợp lệ hoặc đã hết hạn, vui lòng thử lại"
+	ErrOIDCFailed           = "Không thể xác thực với nhà cung cấp đăng nhập"
+	ErrOIDCEmailRequired    = "Nhà cung cấp đăng nhập không trả về email"
+	ErrOIDCEmailUnverified  = "Email đã được dùng cho tài khoản khác, cần xác minh email ở cả hai phía để liên kết"
+	ErrOIDCEmailNotVerified = "Email chưa được nhà cung cấp đăng nhập xác minh"
+	ErrTooManyUsers         = "Số lượng user trong một lần tra cứu vượt quá giới hạn"
 )
+
+// MaxBatchUsers là số id tối đa trong một lần GetPublicUsers
+const MaxBatchUsers = 100
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/agris/user-service/internal/dto"
+	"github.com/agris/user-service/internal/mailer"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// sendVerification tạo token mới (token cũ hết hiệu lực) và gửi link xác minh
+func (u *userService) sendVerification(ctx context.Context, user *models.User) error {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	policy := u.cfg.Security.EmailVerification
+	if err := u.verifyRepo.SaveVerificationToken(ctx, user.ID, utils.HashToken(token), policy.TokenTTL); err != nil {
+		return err
+	}
+
+	sendMailAsync(u.mailer, mailer.Message{
+		To:      user.Email,
+		Subject: "Xác minh email",
+		Body: fmt.Sprintf("Xin chào %s,\n\nNhấn vào link sau để xác minh email (hết hạn sau %s):\n%s",
+			user.Name, policy.TokenTTL, withToken(policy.URL, token)),
+	})
+	return nil
+}
+
+func (u *userService) VerifyEmail(ctx context.Context, token string) *dto.ServiceResponse {
+	if token == "" {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrVerifyTokenInvalid),
+		}
+	}
+
+	userID, ok, err := u.verifyRepo.ConsumeVerificationToken(ctx, utils.HashToken(token))
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    errors.New(ErrInternalServerError),
+		}
+	}
+	if !ok {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrVerifyTokenInvalid),
+		}
+	}
+
+	user, errFind := u.userRepo.FindByID(ctx, userID)
+	if errFind != nil || user == nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrVerifyTokenInvalid),
+		}
+	}
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if _, errUpdate := u.userRepo.Update(ctx, user); errUpdate != nil {
+		return errUpdate
+	}
+	return nil
+}
+
+// ResendVerification gửi lại email xác minh, tối đa một lần mỗi resend_interval.
+// Email không tồn tại hoặc đã xác minh vẫn trả về thành công để không lộ thông tin tài khoản.
+func (u *userService) ResendVerification(ctx context.Context, request *dto.ResendVerificationRequest) *dto.ServiceResponse {
+	if request == nil || request.Email == "" {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrInvalidData),
+		}
+	}
+
+	user, errFind := u.userRepo.FindByEmail(ctx, request.Email, false)
+	if errFind != nil || user == nil || user.IsEmailVerified() {
+		return nil
+	}
+
+	wait, err := u.verifyRepo.AcquireResendSlot(ctx, user.ID, u.cfg.Security.EmailVerification.ResendInterval)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    errors.New(ErrInternalServerError),
+		}
+	}
+	if wait > 0 {
+		return &dto.ServiceResponse{
+			Status:     http.StatusTooManyRequests,
+			Err:        errors.New(ErrResendTooSoon),
+			RetryAfter: wait,
+		}
+	}
+
+	if err := u.sendVerification(ctx, user); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    errors.New(ErrInternalServerError),
+		}
+	}
+	return nil
+}
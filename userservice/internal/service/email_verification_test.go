@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+	token := "verify-token"
+
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		verifyRepo := new(MockEmailVerificationRepository)
+		verifyRepo.On("ConsumeVerificationToken", mock.Anything, utils.HashToken(token)).Return(user.ID, true, nil)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(&models.User{ID: user.ID, Email: user.Email}, nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+			return u.IsEmailVerified()
+		})).Return(user, nil)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), verifyRepo, newMockMailer(), newTestConfig(), new(MockAuditService))
+		response := service.VerifyEmail(context.Background(), token)
+
+		assert.Nil(t, response)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Invalid Token", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		verifyRepo := new(MockEmailVerificationRepository)
+		verifyRepo.On("ConsumeVerificationToken", mock.Anything, utils.HashToken(token)).Return(uuid.Nil, false, nil)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), verifyRepo, newMockMailer(), newTestConfig(), new(MockAuditService))
+		response := service.VerifyEmail(context.Background(), token)
+
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusBadRequest, response.Status)
+		assert.Equal(t, ErrVerifyTokenInvalid, response.Err.Error())
+		userRepo.AssertNotCalled(t, "Update")
+	})
+}
+
+func TestResendVerification(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Security.EmailVerification.ResendInterval = time.Minute
+	user := &models.User{ID: uuid.New(), Name: "John Doe", Email: "john@example.com"}
+
+	t.Run("Success - Sends New Link", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		verifyRepo := new(MockEmailVerificationRepository)
+		mockMailer := newMockMailer()
+		userRepo.On("FindByEmail", mock.Anything, user.Email, false).Return(user, nil)
+		verifyRepo.On("AcquireResendSlot", mock.Anything, user.ID, time.Minute).Return(time.Duration(0), nil)
+		verifyRepo.On("SaveVerificationToken", mock.Anything, user.ID, mock.Anything, mock.Anything).Return(nil)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), verifyRepo, mockMailer, cfg, new(MockAuditService))
+		response := service.ResendVerification(context.Background(), &dto.ResendVerificationRequest{Email: user.Email})
+
+		assert.Nil(t, response)
+		select {
+		case msg := <-mockMailer.sent:
+			assert.Equal(t, user.Email, msg.To)
+		case <-time.After(time.Second):
+			t.Fatal("verification email was not sent")
+		}
+	})
+
+	t.Run("Error - Too Soon", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		verifyRepo := new(MockEmailVerificationRepository)
+		userRepo.On("FindByEmail", mock.Anything, user.Email, false).Return(user, nil)
+		verifyRepo.On("AcquireResendSlot", mock.Anything, user.ID, time.Minute).Return(40*time.Second, nil)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), verifyRepo, newMockMailer(), cfg, new(MockAuditService))
+		response := service.ResendVerification(context.Background(), &dto.ResendVerificationRequest{Email: user.Email})
+
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusTooManyRequests, response.Status)
+		assert.Equal(t, 40*time.Second, response.RetryAfter)
+		verifyRepo.AssertNotCalled(t, "SaveVerificationToken")
+	})
+
+	t.Run("Success - Already Verified", func(t *testing.T) {
+		now := time.Now()
+		userRepo := new(MockUserRepository)
+		verifyRepo := new(MockEmailVerificationRepository)
+		userRepo.On("FindByEmail", mock.Anything, user.Email, false).Return(&models.User{ID: user.ID, Email: user.Email, EmailVerifiedAt: &now}, nil)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), verifyRepo, newMockMailer(), cfg, new(MockAuditService))
+		response := service.ResendVerification(context.Background(), &dto.ResendVerificationRequest{Email: user.Email})
+
+		assert.Nil(t, response)
+		verifyRepo.AssertNotCalled(t, "AcquireResendSlot")
+	})
+}
+
+func TestLoginRequiresVerifiedEmail(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Security.EmailVerification.RequireForLogin = true
+	jwtManager, err := jwtMg.NewJWTManager(cfg)
+	assert.NoError(t, err)
+
+	hashedPassword, err := utils.HashPassword("password123")
+	assert.NoError(t, err)
+	user := &models.User{ID: uuid.New(), Email: "john@example.com", PasswordHash: hashedPassword, Role: models.RoleUser}
+
+	userRepo := new(MockUserRepository)
+	userRepo.On("FindByEmail", mock.Anything, user.Email, true).Return(user, nil)
+
+	service := NewAuthService(userRepo, jwtManager, cfg, new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+	response, errResponse := service.Login(context.Background(), &dto.LoginRequest{Email: user.Email, Password: "password123"})
+
+	assert.Nil(t, response)
+	assert.NotNil(t, errResponse)
+	assert.Equal(t, http.StatusForbidden, errResponse.Status)
+	assert.Equal(t, ErrEmailNotVerified, errResponse.Err.Error())
+}
@@ -2,4 +2,4 @@ package service
 
 import "github.com/google/wire"
 
-var Set = wire.NewSet(NewUserService, NewAuthService)
+var Set = wire.NewSet(NewUserService, NewAuthService, NewPasswordService, NewAuditService, NewUserEventService)
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+)
+
+// Redis lỗi thì không chặn đăng nhập, chỉ ghi log
+
+// checkIPAllowed chặn IP đã đăng nhập sai quá ip_max_attempts lần trong cửa sổ đếm
+func (s *authService) checkIPAllowed(ctx context.Context, ip string) *dto.ServiceResponse {
+	limit := s.cfg.Security.Login.IPMaxAttempts
+	if limit <= 0 || ip == "" {
+		return nil
+	}
+
+	count, ttl, err := s.attemptRepo.GetIPFailures(ctx, ip)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil
+	}
+	if count >= int64(limit) {
+		return tooManyAttempts(ttl)
+	}
+	return nil
+}
+
+func (s *authService) checkAccountLocked(ctx context.Context, userID uuid.UUID) *dto.ServiceResponse {
+	if s.cfg.Security.Login.MaxAttempts <= 0 {
+		return nil
+	}
+
+	remaining, err := s.attemptRepo.GetAccountLock(ctx, userID)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil
+	}
+	if remaining > 0 {
+		return tooManyAttempts(remaining)
+	}
+	return nil
+}
+
+// recordLoginFailure tăng bộ đếm của IP và tài khoản (nếu có).
+// Trả về 429 khi lần sai này làm tài khoản bị khóa.
+func (s *authService) recordLoginFailure(ctx context.Context, userID *uuid.UUID, ip string) *dto.ServiceResponse {
+	policy := s.cfg.Security.Login
+
+	if policy.IPMaxAttempts > 0 && ip != "" {
+		if _, err := s.attemptRepo.IncrementIPFailures(ctx, ip, policy.AttemptWindow); err != nil {
+			log.Error("[ERROR] : ", err.Error())
+		}
+	}
+
+	if policy.MaxAttempts <= 0 || userID == nil {
+		return nil
+	}
+
+	count, err := s.attemptRepo.IncrementAccountFailures(ctx, *userID, policy.AttemptWindow)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil
+	}
+	if count < int64(policy.MaxAttempts) {
+		return nil
+	}
+
+	level, err := s.attemptRepo.NextLockoutLevel(ctx, *userID, policy.LockoutResetAfter)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil
+	}
+	lockFor := lockoutDuration(policy.LockoutDuration, policy.MaxLockoutDuration, level)
+	if err := s.attemptRepo.LockAccount(ctx, *userID, lockFor); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil
+	}
+
+	event := newAuditEvent(models.AuditAccountLocked, uuid.Nil, *userID)
+	event.IP = ip
+	event.After = auditValues(map[string]any{"level": level, "duration": lockFor.String()})
+	s.audit.Record(ctx, event)
+	return tooManyAttempts(lockFor)
+}
+
+func (s *authService) resetLoginFailures(ctx context.Context, userID uuid.UUID) {
+	if s.cfg.Security.Login.MaxAttempts <= 0 {
+		return
+	}
+	if err := s.attemptRepo.ResetAccount(ctx, userID); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+	}
+}
+
+// UnlockAccount cho admin mở khóa tài khoản bị khóa do đăng nhập sai
+func (s *authService) UnlockAccount(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse {
+	if accountID == userID {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrCantUpdateOwnAccount),
+		}
+	}
+
+	user, errFind := s.userRepo.FindByID(ctx, userID)
+	if errFind != nil || user == nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrUserNotFound),
+		}
+	}
+
+	if err := s.attemptRepo.ResetAccount(ctx, userID); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    errors.New(ErrInternalServerError),
+		}
+	}
+
+	s.audit.Record(ctx, newAuditEvent(models.AuditAccountUnlocked, accountID, userID))
+	return nil
+}
+
+// lockoutDuration tăng gấp đôi thời gian khóa theo bậc, tối đa max
+func lockoutDuration(base time.Duration, max time.Duration, level int64) time.Duration {
+	if base <= 0 {
+		base = time.Minute
+	}
+	d := base
+	for i := int64(1); i < level; i++ {
+		d *= 2
+		if max > 0 && d >= max {
+			return max
+		}
+	}
+	if max > 0 && d > max {
+		return max
+	}
+	return d
+}
+
+func tooManyAttempts(retryAfter time.Duration) *dto.ServiceResponse {
+	return &dto.ServiceResponse{
+		Status:     http.StatusTooManyRequests,
+		Err:        errors.New(ErrTooManyAttempts),
+		RetryAfter: retryAfter,
+	}
+}
+
+func (s *authService) auditLoginSucceeded(ctx context.Context, userID uuid.UUID, ip string, mfa bool) {
+	event := newAuditEvent(models.AuditLoginSucceeded, userID, userID)
+	event.IP = ip
+	event.After = auditValues(map[string]any{"mfa": mfa})
+	s.audit.Record(ctx, event)
+}
+
+// auditLoginFailed ghi lần đăng nhập sai, userID là uuid.Nil khi email không tồn tại
+func (s *authService) auditLoginFailed(ctx context.Context, userID uuid.UUID, ip string, details map[string]any) {
+	event := newAuditEvent(models.AuditLoginFailed, uuid.Nil, userID)
+	event.IP = ip
+	event.After = auditValues(details)
+	s.audit.Record(ctx, event)
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/agris/user-service/internal/mailer"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const mailTimeout = 10 * time.Second
+
+// sendMailAsync gửi email ngoài request, lỗi chỉ được ghi log
+func sendMailAsync(m mailer.Mailer, msg mailer.Message) {
+	go func() {
+		ct, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+		if err := m.Send(ct, msg); err != nil {
+			log.Error("[ERROR] : ", err.Error())
+		}
+	}()
+}
+
+// withToken thêm token vào query "token" của link gửi cho người dùng
+func withToken(base string, token string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
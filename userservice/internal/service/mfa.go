@@ -0,0 +1,491 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/agris/user-service/pkg/totp"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMFAIssuer        = "Agris"
+	defaultMFAChallengeTTL  = 5 * time.Minute
+	defaultMFAEnrollmentTTL = 10 * time.Minute
+	defaultMFAMaxAttempts   = 5
+	defaultRecoveryCodes    = 10
+	// maxRecoveryCodeAttempts giới hạn số lần thử lại khi danh sách mã bị request khác đổi cùng lúc
+	maxRecoveryCodeAttempts = 3
+
+	// mfaSkew chấp nhận thêm mã của bước liền trước và liền sau để bù lệch đồng hồ
+	mfaSkew = 1
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EnrollMFA tạo secret mới chờ xác nhận, MFA chỉ bật sau khi ActivateMFA nhận được mã đúng
+func (s *authService) EnrollMFA(ctx context.Context, userID uuid.UUID) (*dto.MFAEnrollResponse, *dto.ServiceResponse) {
+	user, errFind := s.userRepo.FindByID(ctx, userID)
+	if errFind != nil || user == nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrUserNotFound),
+		}
+	}
+	if user.IsMFAEnabled() {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusConflict,
+			Err:    errors.New(ErrMFAAlreadyEnabled),
+		}
+	}
+	// không có khóa thì không đăng ký, TOTP secret không bao giờ được lưu dạng thô
+	if s.cfg.Security.MFA.EncryptionKey == "" {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusServiceUnavailable,
+			Err:    errors.New(ErrMFANotConfigured),
+		}
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+	sealed, err := s.sealMFASecret(secret)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	ttl := orDefault(s.cfg.Security.MFA.EnrollmentTTL, defaultMFAEnrollmentTTL)
+	if err := s.mfaRepo.SaveEnrollment(ctx, user.ID, sealed, ttl); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	issuer := s.cfg.Security.MFA.Issuer
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+	return &dto.MFAEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(issuer, user.Email, secret),
+		ExpiresIn:  int64(ttl.Seconds()),
+	}, nil
+}
+
+// ActivateMFA bật MFA khi mã TOTP khớp với secret đang chờ, trả về mã khôi phục (chỉ hiển thị một lần)
+func (s *authService) ActivateMFA(ctx context.Context, userID uuid.UUID, request *dto.MFACodeRequest) (*dto.MFARecoveryCodesResponse, *dto.ServiceResponse) {
+	if request == nil || request.Code == "" {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrInvalidData),
+		}
+	}
+
+	user, errFind := s.userRepo.FindByID(ctx, userID)
+	if errFind != nil || user == nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrUserNotFound),
+		}
+	}
+	if user.IsMFAEnabled() {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusConflict,
+			Err:    errors.New(ErrMFAAlreadyEnabled),
+		}
+	}
+
+	sealed, ok, err := s.mfaRepo.GetEnrollment(ctx, user.ID)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+	if !ok {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrMFANotEnrolled),
+		}
+	}
+
+	secret, err := s.openMFASecret(sealed)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+	step, valid := totp.Validate(secret, request.Code, time.Now(), mfaSkew)
+	if !valid {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrMFACodeInvalid),
+		}
+	}
+	if _, err := s.mfaRepo.MarkCodeUsed(ctx, user.ID, step, codeReuseWindow()); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+	}
+
+	codes, hashes, err := generateRecoveryCodes(s.recoveryCodeCount())
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	now := time.Now()
+	user.MFASecret = sealed
+	user.MFAEnabledAt = &now
+	user.MFARecoveryCodes = hashes
+	if _, errUpdate := s.userRepo.Update(ctx, user); errUpdate != nil {
+		return nil, errUpdate
+	}
+
+	if err := s.mfaRepo.DeleteEnrollment(ctx, user.ID); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+	}
+
+	s.audit.Record(ctx, newAuditEvent(models.AuditMFAEnabled, user.ID, user.ID))
+	return &dto.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableMFA tắt MFA, yêu cầu mã TOTP hoặc mã khôi phục còn hiệu lực
+func (s *authService) DisableMFA(ctx context.Context, userID uuid.UUID, request *dto.MFACodeRequest) *dto.ServiceResponse {
+	if request == nil || request.Code == "" {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrInvalidData),
+		}
+	}
+
+	user, errFind := s.userRepo.FindByID(ctx, userID)
+	if errFind != nil || user == nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrUserNotFound),
+		}
+	}
+	if !user.IsMFAEnabled() {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrMFANotEnabled),
+		}
+	}
+
+	valid, errVerify := s.verifyMFACode(ctx, user, request.Code)
+	if errVerify != nil {
+		return errVerify
+	}
+	if !valid {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrMFACodeInvalid),
+		}
+	}
+
+	user.MFASecret = ""
+	user.MFAEnabledAt = nil
+	user.MFARecoveryCodes = ""
+	if _, errUpdate := s.userRepo.Update(ctx, user); errUpdate != nil {
+		return errUpdate
+	}
+
+	s.audit.Record(ctx, newAuditEvent(models.AuditMFADisabled, user.ID, user.ID))
+	return nil
+}
+
+// RequiresMFA cho biết admin phải đăng nhập lại bằng MFA trước khi dùng quyền admin
+func (s *authService) RequiresMFA(claims *jwtMg.Claims) bool {
+	if !s.cfg.Security.MFA.RequireForAdmin {
+		return false
+	}
+	if claims == nil {
+		return true
+	}
+	return claims.Role == models.RoleAdmin && !claims.MFA
+}
+
+// startMFAChallenge là bước 1 của đăng nhập MFA, mật khẩu đã đúng nhưng chưa cấp token
+func (s *authService) startMFAChallenge(ctx context.Context, user *models.User) (*dto.LoginResponse, *dto.ServiceResponse) {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	ttl := orDefault(s.cfg.Security.MFA.ChallengeTTL, defaultMFAChallengeTTL)
+	if err := s.mfaRepo.SaveChallenge(ctx, utils.HashToken(token), user.ID, ttl); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	return &dto.LoginResponse{
+		MFARequired:  true,
+		MFAToken:     token,
+		MFAExpiresIn: int64(ttl.Seconds()),
+	}, nil
+}
+
+// LoginMFA là bước 2 của đăng nhập, đổi challenge token và mã TOTP lấy access/refresh token.
+// Mã sai được tính vào giới hạn đăng nhập sai của tài khoản và IP.
+func (s *authService) LoginMFA(ctx context.Context, request *dto.MFALoginRequest) (*dto.AuthResponse, *dto.ServiceResponse) {
+	if request == nil || request.MFAToken == "" || request.Code == "" {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrInvalidData),
+		}
+	}
+
+	if errBlocked := s.checkIPAllowed(ctx, request.IP); errBlocked != nil {
+		return nil, errBlocked
+	}
+
+	tokenHash := utils.HashToken(request.MFAToken)
+	userID, ok, err := s.mfaRepo.GetChallenge(ctx, tokenHash)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+	if !ok {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusUnauthorized,
+			Err:    errors.New(ErrMFAChallengeInvalid),
+		}
+	}
+
+	user, errFind := s.userRepo.FindByID(ctx, userID)
+	if errFind != nil || user == nil || !user.IsMFAEnabled() {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusUnauthorized,
+			Err:    errors.New(ErrMFAChallengeInvalid),
+		}
+	}
+
+	if errLocked := s.checkAccountLocked(ctx, user.ID); errLocked != nil {
+		return nil, errLocked
+	}
+
+	valid, errVerify := s.verifyMFACode(ctx, user, request.Code)
+	if errVerify != nil {
+		return nil, errVerify
+	}
+	if !valid {
+		s.auditLoginFailed(ctx, user.ID, request.IP, map[string]any{"reason": "invalid_mfa_code"})
+		return nil, s.recordMFAFailure(ctx, tokenHash, user.ID, request.IP)
+	}
+
+	consumed, err := s.mfaRepo.ConsumeChallenge(ctx, tokenHash)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+	if !consumed {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusUnauthorized,
+			Err:    errors.New(ErrMFAChallengeInvalid),
+		}
+	}
+
+	s.resetLoginFailures(ctx, user.ID)
+	resp, errIssue := s.issueTokens(ctx, user, newSession(request.IP, request.UserAgent), true)
+	if errIssue != nil {
+		return nil, errIssue
+	}
+	s.auditLoginSucceeded(ctx, user.ID, request.IP, true)
+	return resp, nil
+}
+
+// recordMFAFailure hủy challenge sau max_challenge_attempts lần sai
+func (s *authService) recordMFAFailure(ctx context.Context, tokenHash string, userID uuid.UUID, ip string) *dto.ServiceResponse {
+	ttl := orDefault(s.cfg.Security.MFA.ChallengeTTL, defaultMFAChallengeTTL)
+	maxAttempts := s.cfg.Security.MFA.MaxChallengeAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMFAMaxAttempts
+	}
+
+	failures, err := s.mfaRepo.IncrementChallengeFailures(ctx, tokenHash, ttl)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+	} else if failures >= int64(maxAttempts) {
+		if _, err := s.mfaRepo.ConsumeChallenge(ctx, tokenHash); err != nil {
+			log.Error("[ERROR] : ", err.Error())
+		}
+	}
+
+	if errLocked := s.recordLoginFailure(ctx, &userID, ip); errLocked != nil {
+		return errLocked
+	}
+	return &dto.ServiceResponse{
+		Status: http.StatusUnauthorized,
+		Err:    errors.New(ErrMFACodeInvalid),
+	}
+}
+
+// verifyMFACode nhận mã TOTP (mỗi mã chỉ dùng một lần) hoặc mã khôi phục
+func (s *authService) verifyMFACode(ctx context.Context, user *models.User, code string) (bool, *dto.ServiceResponse) {
+	secret, err := s.openMFASecret(user.MFASecret)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return false, internalError()
+	}
+
+	if step, ok := totp.Validate(secret, code, time.Now(), mfaSkew); ok {
+		fresh, err := s.mfaRepo.MarkCodeUsed(ctx, user.ID, step, codeReuseWindow())
+		if err != nil {
+			log.Error("[ERROR] : ", err.Error())
+			return false, internalError()
+		}
+		return fresh, nil
+	}
+
+	return s.useRecoveryCode(ctx, user, code)
+}
+
+// useRecoveryCode xóa mã khôi phục khỏi danh sách khi khớp. Cột được cập nhật có điều kiện
+// nên hai request dùng cùng một mã chỉ có một request thành công.
+func (s *authService) useRecoveryCode(ctx context.Context, user *models.User, code string) (bool, *dto.ServiceResponse) {
+	hash := utils.HashToken(normalizeRecoveryCode(code))
+	current := user.MFARecoveryCodes
+
+	for attempt := 0; attempt < maxRecoveryCodeAttempts; attempt++ {
+		remaining, count, found := removeRecoveryCode(current, hash)
+		if !found {
+			return false, nil
+		}
+
+		swapped, err := s.userRepo.SwapRecoveryCodes(ctx, user.ID, current, remaining)
+		if err != nil {
+			log.Error("[ERROR] : ", err.Error())
+			return false, internalError()
+		}
+		if swapped {
+			user.MFARecoveryCodes = remaining
+			event := newAuditEvent(models.AuditMFARecoveryCodeUsed, user.ID, user.ID)
+			event.After = auditValues(map[string]any{"remaining": count})
+			s.audit.Record(ctx, event)
+			return true, nil
+		}
+
+		// danh sách đã bị đổi bởi request khác, đọc lại để xem mã còn hợp lệ không
+		fresh, errFind := s.userRepo.FindByID(ctx, user.ID)
+		if errFind != nil {
+			return false, errFind
+		}
+		current = fresh.MFARecoveryCodes
+	}
+	return false, nil
+}
+
+// removeRecoveryCode trả về danh sách hash đã bỏ mã khớp và số mã còn lại
+func removeRecoveryCode(stored string, hash string) (string, int, bool) {
+	if stored == "" {
+		return "", 0, false
+	}
+
+	var hashes []string
+	if err := json.Unmarshal([]byte(stored), &hashes); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return "", 0, false
+	}
+
+	for i, candidate := range hashes {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(hash)) != 1 {
+			continue
+		}
+		rest := append(hashes[:i:i], hashes[i+1:]...)
+		remaining, err := json.Marshal(rest)
+		if err != nil {
+			log.Error("[ERROR] : ", err.Error())
+			return "", 0, false
+		}
+		return string(remaining), len(rest), true
+	}
+	return "", 0, false
+}
+
+func (s *authService) recoveryCodeCount() int {
+	if s.cfg.Security.MFA.RecoveryCodes > 0 {
+		return s.cfg.Security.MFA.RecoveryCodes
+	}
+	return defaultRecoveryCodes
+}
+
+func (s *authService) mfaKey() ([]byte, error) {
+	if s.cfg.Security.MFA.EncryptionKey == "" {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(s.cfg.Security.MFA.EncryptionKey)
+}
+
+func (s *authService) sealMFASecret(secret string) (string, error) {
+	key, err := s.mfaKey()
+	if err != nil {
+		return "", err
+	}
+	return utils.EncryptString(key, secret)
+}
+
+func (s *authService) openMFASecret(sealed string) (string, error) {
+	key, err := s.mfaKey()
+	if err != nil {
+		return "", err
+	}
+	return utils.DecryptString(key, sealed)
+}
+
+// generateRecoveryCodes trả về mã dạng xxxx-xxxx và mảng JSON các hash để lưu
+func generateRecoveryCodes(n int) ([]string, string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, "", err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+		hashes = append(hashes, utils.HashToken(raw))
+	}
+
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", err
+	}
+	return codes, string(data), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+	return strings.ToLower(code)
+}
+
+// codeReuseWindow là khoảng thời gian một mã TOTP còn được chấp nhận
+func codeReuseWindow() time.Duration {
+	return time.Duration(2*mfaSkew+1) * totp.Period * time.Second
+}
+
+func orDefault(d time.Duration, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
+
+func internalError() *dto.ServiceResponse {
+	return &dto.ServiceResponse{
+		Status: http.StatusInternalServerError,
+		Err:    errors.New(ErrInternalServerError),
+	}
+}
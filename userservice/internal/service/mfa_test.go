@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/config"
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/agris/user-service/pkg/totp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MockMFARepository implements cache.MFARepository
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) SaveEnrollment(ctx context.Context, userID uuid.UUID, secret string, ttl time.Duration) error {
+	args := m.Called(ctx, userID, secret, ttl)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) GetEnrollment(ctx context.Context, userID uuid.UUID) (string, bool, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Bool(1), args.Error(2)
+}
+
+func (m *MockMFARepository) DeleteEnrollment(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) SaveChallenge(ctx context.Context, tokenHash string, userID uuid.UUID, ttl time.Duration) error {
+	args := m.Called(ctx, tokenHash, userID, ttl)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) GetChallenge(ctx context.Context, tokenHash string) (uuid.UUID, bool, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(uuid.UUID), args.Bool(1), args.Error(2)
+}
+
+func (m *MockMFARepository) IncrementChallengeFailures(ctx context.Context, tokenHash string, ttl time.Duration) (int64, error) {
+	args := m.Called(ctx, tokenHash, ttl)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMFARepository) ConsumeChallenge(ctx context.Context, tokenHash string) (bool, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) MarkCodeUsed(ctx context.Context, userID uuid.UUID, step int64, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, userID, step, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func newMFATestConfig() *config.Config {
+	cfg := newTestConfig()
+	cfg.Security.Login = config.LoginProtectionConfig{
+		MaxAttempts:     3,
+		AttemptWindow:   15 * time.Minute,
+		LockoutDuration: time.Minute,
+	}
+	cfg.Security.MFA = config.MFAConfig{
+		Issuer:               "Agris",
+		ChallengeTTL:         5 * time.Minute,
+		MaxChallengeAttempts: 3,
+		EnrollmentTTL:        10 * time.Minute,
+		RecoveryCodes:        4,
+		EncryptionKey:        base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+	}
+	return cfg
+}
+
+// newMFAUser trả về user đã bật MFA cùng secret và mã khôi phục ở dạng thô
+func newMFAUser(t *testing.T, cfg *config.Config) (*models.User, string, []string) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	key, err := base64.StdEncoding.DecodeString(cfg.Security.MFA.EncryptionKey)
+	require.NoError(t, err)
+	sealed, err := utils.EncryptString(key, secret)
+	require.NoError(t, err)
+	codes, hashes, err := generateRecoveryCodes(2)
+	require.NoError(t, err)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	enabledAt := time.Now()
+	return &models.User{
+		ID:               uuid.New(),
+		Email:            "john@example.com",
+		PasswordHash:     string(hashedPassword),
+		Role:             models.RoleUser,
+		MFASecret:        sealed,
+		MFAEnabledAt:     &enabledAt,
+		MFARecoveryCodes: hashes,
+	}, secret, codes
+}
+
+func TestLoginWithMFAReturnsChallenge(t *testing.T) {
+	cfg := newMFATestConfig()
+	user, _, _ := newMFAUser(t, cfg)
+
+	userRepo := new(MockUserRepository)
+	attemptRepo := new(MockLoginAttemptRepository)
+	mfaRepo := new(MockMFARepository)
+	userRepo.On("FindByEmail", mock.Anything, user.Email, true).Return(user, nil)
+	attemptRepo.On("GetAccountLock", mock.Anything, user.ID).Return(time.Duration(0), nil)
+	attemptRepo.On("ResetAccount", mock.Anything, user.ID).Return(nil)
+	mfaRepo.On("SaveChallenge", mock.Anything, mock.Anything, user.ID, 5*time.Minute).Return(nil)
+
+	service := NewAuthService(userRepo, nil, cfg, new(MockRedisRepository), attemptRepo, mfaRepo, new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+	response, errResponse := service.Login(context.Background(), &dto.LoginRequest{
+		Email:    user.Email,
+		Password: "password123",
+	})
+
+	assert.Nil(t, errResponse)
+	assert.True(t, response.MFARequired)
+	assert.NotEmpty(t, response.MFAToken)
+	assert.Equal(t, int64(300), response.MFAExpiresIn)
+	assert.Nil(t, response.AuthResponse)
+	mfaRepo.AssertCalled(t, "SaveChallenge", mock.Anything, utils.HashToken(response.MFAToken), user.ID, 5*time.Minute)
+}
+
+func TestLoginMFA(t *testing.T) {
+	cfg := newMFATestConfig()
+	jwtManager, err := jwtMg.NewJWTManager(cfg)
+	require.NoError(t, err)
+
+	const challenge = "challenge-token"
+	challengeHash := utils.HashToken(challenge)
+
+	t.Run("Success - TOTP Code", func(t *testing.T) {
+		user, secret, _ := newMFAUser(t, cfg)
+		code, err := totp.Code(secret, time.Now())
+		require.NoError(t, err)
+
+		userRepo := new(MockUserRepository)
+		rdRepo := new(MockRedisRepository)
+		attemptRepo := new(MockLoginAttemptRepository)
+		mfaRepo := new(MockMFARepository)
+		mfaRepo.On("GetChallenge", mock.Anything, challengeHash).Return(user.ID, true, nil)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+		attemptRepo.On("GetAccountLock", mock.Anything, user.ID).Return(time.Duration(0), nil)
+		mfaRepo.On("MarkCodeUsed", mock.Anything, user.ID, mock.Anything, 90*time.Second).Return(true, nil)
+		mfaRepo.On("ConsumeChallenge", mock.Anything, challengeHash).Return(true, nil)
+		attemptRepo.On("ResetAccount", mock.Anything, user.ID).Return(nil)
+		rdRepo.On("SaveRefreshToken", mock.Anything, user.ID, mock.Anything, mock.Anything).Return(nil)
+		rdRepo.On("SaveSession", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+		service := NewAuthService(userRepo, jwtManager, cfg, rdRepo, attemptRepo, mfaRepo, new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response, errResponse := service.LoginMFA(context.Background(), &dto.MFALoginRequest{MFAToken: challenge, Code: code})
+
+		require.Nil(t, errResponse)
+		claims, err := jwtManager.ValidateAccessToken(response.AccessToken)
+		require.NoError(t, err)
+		assert.True(t, claims.MFA)
+		refreshClaims, err := jwtManager.ValidateRefreshToken(response.RefreshToken)
+		require.NoError(t, err)
+		assert.True(t, refreshClaims.MFA)
+		mfaRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - Recovery Code Used Once", func(t *testing.T) {
+		user, _, codes := newMFAUser(t, cfg)
+
+		userRepo := new(MockUserRepository)
+		rdRepo := new(MockRedisRepository)
+		attemptRepo := new(MockLoginAttemptRepository)
+		mfaRepo := new(MockMFARepository)
+		mfaRepo.On("GetChallenge", mock.Anything, challengeHash).Return(user.ID, true, nil)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+		attemptRepo.On("GetAccountLock", mock.Anything, user.ID).Return(time.Duration(0), nil)
+		userRepo.On("SwapRecoveryCodes", mock.Anything, user.ID, user.MFARecoveryCodes, mock.MatchedBy(func(next string) bool {
+			return !strings.Contains(next, utils.HashToken(normalizeRecoveryCode(codes[0]))) &&
+				strings.Contains(next, utils.HashToken(normalizeRecoveryCode(codes[1])))
+		})).Return(true, nil)
+		mfaRepo.On("ConsumeChallenge", mock.Anything, challengeHash).Return(true, nil)
+		attemptRepo.On("ResetAccount", mock.Anything, user.ID).Return(nil)
+		rdRepo.On("SaveRefreshToken", mock.Anything, user.ID, mock.Anything, mock.Anything).Return(nil)
+		rdRepo.On("SaveSession", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+		service := NewAuthService(userRepo, jwtManager, cfg, rdRepo, attemptRepo, mfaRepo, new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response, errResponse := service.LoginMFA(context.Background(), &dto.MFALoginRequest{
+			MFAToken: challenge,
+			Code:     strings.ToUpper(codes[0]),
+		})
+
+		assert.Nil(t, errResponse)
+		assert.NotNil(t, response)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Recovery Code Used Concurrently", func(t *testing.T) {
+		user, _, codes := newMFAUser(t, cfg)
+		// request khác đã dùng codes[0] sau khi user được đọc
+		used := *user
+		used.MFARecoveryCodes = `["` + utils.HashToken(normalizeRecoveryCode(codes[1])) + `"]`
+
+		userRepo := new(MockUserRepository)
+		attemptRepo := new(MockLoginAttemptRepository)
+		mfaRepo := new(MockMFARepository)
+		mfaRepo.On("GetChallenge", mock.Anything, challengeHash).Return(user.ID, true, nil)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil).Once()
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(&used, nil).Once()
+		attemptRepo.On("GetAccountLock", mock.Anything, user.ID).Return(time.Duration(0), nil)
+		userRepo.On("SwapRecoveryCodes", mock.Anything, user.ID, user.MFARecoveryCodes, mock.Anything).Return(false, nil)
+		mfaRepo.On("IncrementChallengeFailures", mock.Anything, challengeHash, 5*time.Minute).Return(int64(1), nil)
+		attemptRepo.On("IncrementAccountFailures", mock.Anything, user.ID, 15*time.Minute).Return(int64(1), nil)
+
+		service := NewAuthService(userRepo, jwtManager, cfg, new(MockRedisRepository), attemptRepo, mfaRepo, new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response, errResponse := service.LoginMFA(context.Background(), &dto.MFALoginRequest{MFAToken: challenge, Code: codes[0]})
+
+		assert.Nil(t, response)
+		assert.Equal(t, http.StatusUnauthorized, errResponse.Status)
+		assert.Equal(t, ErrMFACodeInvalid, errResponse.Err.Error())
+		userRepo.AssertNumberOfCalls(t, "SwapRecoveryCodes", 1)
+		mfaRepo.AssertNotCalled(t, "ConsumeChallenge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - Code Reused", func(t *testing.T) {
+		user, secret, _ := newMFAUser(t, cfg)
+		code, err := totp.Code(secret, time.Now())
+		require.NoError(t, err)
+
+		userRepo := new(MockUserRepository)
+		attemptRepo := new(MockLoginAttemptRepository)
+		mfaRepo := new(MockMFARepository)
+		mfaRepo.On("GetChallenge", mock.Anything, challengeHash).Return(user.ID, true, nil)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+		attemptRepo.On("GetAccountLock", mock.Anything, user.ID).Return(time.Duration(0), nil)
+		mfaRepo.On("MarkCodeUsed", mock.Anything, user.ID, mock.Anything, 90*time.Second).Return(false, nil)
+		mfaRepo.On("IncrementChallengeFailures", mock.Anything, challengeHash, 5*time.Minute).Return(int64(1), nil)
+		attemptRepo.On("IncrementAccountFailures", mock.Anything, user.ID, 15*time.Minute).Return(int64(1), nil)
+
+		service := NewAuthService(userRepo, jwtManager, cfg, new(MockRedisRepository), attemptRepo, mfaRepo, new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response, errResponse := service.LoginMFA(context.Background(), &dto.MFALoginRequest{MFAToken: challenge, Code: code})
+
+		assert.Nil(t, response)
+		assert.Equal(t, http.StatusUnauthorized, errResponse.Status)
+		assert.Equal(t, ErrMFACodeInvalid, errResponse.Err.Error())
+		mfaRepo.AssertNotCalled(t, "ConsumeChallenge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - Too Many Wrong Codes Drop Challenge", func(t *testing.T) {
+		user, _, _ := newMFAUser(t, cfg)
+
+		userRepo := new(MockUserRepository)
+		attemptRepo := new(MockLoginAttemptRepository)
+		mfaRepo := new(MockMFARepository)
+		mfaRepo.On("GetChallenge", mock.Anything, challengeHash).Return(user.ID, true, nil)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+		attemptRepo.On("GetAccountLock", mock.Anything, user.ID).Return(time.Duration(0), nil)
+		mfaRepo.On("IncrementChallengeFailures", mock.Anything, challengeHash, 5*time.Minute).Return(int64(3), nil)
+		mfaRepo.On("ConsumeChallenge", mock.Anything, challengeHash).Return(true, nil)
+		attemptRepo.On("IncrementAccountFailures", mock.Anything, user.ID, 15*time.Minute).Return(int64(2), nil)
+
+		service := NewAuthService(userRepo, jwtManager, cfg, new(MockRedisRepository), attemptRepo, mfaRepo, new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response, errResponse := service.LoginMFA(context.Background(), &dto.MFALoginRequest{MFAToken: challenge, Code: "abcdef"})
+
+		assert.Nil(t, response)
+		assert.Equal(t, http.StatusUnauthorized, errResponse.Status)
+		mfaRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Unknown Challenge", func(t *testing.T) {
+		mfaRepo := new(MockMFARepository)
+		mfaRepo.On("GetChallenge", mock.Anything, challengeHash).Return(uuid.Nil, false, nil)
+
+		service := NewAuthService(new(MockUserRepository), jwtManager, cfg, new(MockRedisRepository), new(MockLoginAttemptRepository), mfaRepo, new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response, errResponse := service.LoginMFA(context.Background(), &dto.MFALoginRequest{MFAToken: challenge, Code: "123456"})
+
+		assert.Nil(t, response)
+		assert.Equal(t, http.StatusUnauthorized, errResponse.Status)
+		assert.Equal(t, ErrMFAChallengeInvalid, errResponse.Err.Error())
+	})
+}
+
+func TestEnrollAndActivateMFA(t *testing.T) {
+	cfg := newMFATestConfig()
+
+	t.Run("Success", func(t *testing.T) {
+		user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+		userRepo := new(MockUserRepository)
+		mfaRepo := new(MockMFARepository)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+
+		var sealed string
+		mfaRepo.On("SaveEnrollment", mock.Anything, user.ID, mock.Anything, 10*time.Minute).
+			Run(func(args mock.Arguments) { sealed = args.String(2) }).Return(nil)
+
+		service := NewAuthService(userRepo, nil, cfg, new(MockRedisRepository), new(MockLoginAttemptRepository), mfaRepo, new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		enroll, errEnroll := service.EnrollMFA(context.Background(), user.ID)
+		require.Nil(t, errEnroll)
+		assert.True(t, strings.HasPrefix(enroll.OTPAuthURI, "otpauth://totp/Agris:john@example.com?"))
+		assert.Contains(t, enroll.OTPAuthURI, "secret="+enroll.Secret)
+		assert.NotContains(t, sealed, enroll.Secret)
+
+		code, err := totp.Code(enroll.Secret, time.Now())
+		require.NoError(t, err)
+		mfaRepo.On("GetEnrollment", mock.Anything, user.ID).Return(sealed, true, nil)
+		mfaRepo.On("MarkCodeUsed", mock.Anything, user.ID, mock.Anything, 90*time.Second).Return(true, nil)
+		mfaRepo.On("DeleteEnrollment", mock.Anything, user.ID).Return(nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+			return u.IsMFAEnabled() && u.MFASecret == sealed
+		})).Return(user, nil)
+
+		codes, errActivate := service.ActivateMFA(context.Background(), user.ID, &dto.MFACodeRequest{Code: code})
+		require.Nil(t, errActivate)
+		assert.Len(t, codes.RecoveryCodes, 4)
+		userRepo.AssertExpectations(t)
+		mfaRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Wrong Code", func(t *testing.T) {
+		user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+		userRepo := new(MockUserRepository)
+		mfaRepo := new(MockMFARepository)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+		mfaRepo.On("GetEnrollment", mock.Anything, user.ID).Return("JBSWY3DPEHPK3PXP", true, nil)
+
+		service := NewAuthService(userRepo, nil, cfg, new(MockRedisRepository), new(MockLoginAttemptRepository), mfaRepo, new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		codes, errActivate := service.ActivateMFA(context.Background(), user.ID, &dto.MFACodeRequest{Code: "abcdef"})
+
+		assert.Nil(t, codes)
+		assert.Equal(t, http.StatusBadRequest, errActivate.Status)
+		assert.Equal(t, ErrMFACodeInvalid, errActivate.Err.Error())
+		userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - Already Enabled", func(t *testing.T) {
+		user, _, _ := newMFAUser(t, cfg)
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+
+		service := NewAuthService(userRepo, nil, cfg, new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		enroll, errEnroll := service.EnrollMFA(context.Background(), user.ID)
+
+		assert.Nil(t, enroll)
+		assert.Equal(t, http.StatusConflict, errEnroll.Status)
+	})
+
+	t.Run("Error - No Encryption Key", func(t *testing.T) {
+		noKeyCfg := newMFATestConfig()
+		noKeyCfg.Security.MFA.EncryptionKey = ""
+		user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+		userRepo := new(MockUserRepository)
+		mfaRepo := new(MockMFARepository)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+
+		service := NewAuthService(userRepo, nil, noKeyCfg, new(MockRedisRepository), new(MockLoginAttemptRepository), mfaRepo, new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		enroll, errEnroll := service.EnrollMFA(context.Background(), user.ID)
+
+		assert.Nil(t, enroll)
+		assert.Equal(t, http.StatusServiceUnavailable, errEnroll.Status)
+		assert.Equal(t, ErrMFANotConfigured, errEnroll.Err.Error())
+		mfaRepo.AssertNotCalled(t, "SaveEnrollment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestDisableMFA(t *testing.T) {
+	cfg := newMFATestConfig()
+	user, secret, _ := newMFAUser(t, cfg)
+	code, err := totp.Code(secret, time.Now())
+	require.NoError(t, err)
+
+	userRepo := new(MockUserRepository)
+	mfaRepo := new(MockMFARepository)
+	userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	mfaRepo.On("MarkCodeUsed", mock.Anything, user.ID, mock.Anything, 90*time.Second).Return(true, nil)
+	userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+		return !u.IsMFAEnabled() && u.MFASecret == "" && u.MFARecoveryCodes == ""
+	})).Return(user, nil)
+
+	service := NewAuthService(userRepo, nil, cfg, new(MockRedisRepository), new(MockLoginAttemptRepository), mfaRepo, new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+	errDisable := service.DisableMFA(context.Background(), user.ID, &dto.MFACodeRequest{Code: code})
+
+	assert.Nil(t, errDisable)
+	userRepo.AssertExpectations(t)
+}
+
+func TestRequiresMFA(t *testing.T) {
+	cfg := newMFATestConfig()
+	service := NewAuthService(nil, nil, cfg, nil, nil, nil, nil, new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+	admin := &jwtMg.Claims{Role: models.RoleAdmin}
+	adminMFA := &jwtMg.Claims{Role: models.RoleAdmin, MFA: true}
+
+	assert.False(t, service.RequiresMFA(admin))
+
+	cfg.Security.MFA.RequireForAdmin = true
+	assert.True(t, service.RequiresMFA(admin))
+	assert.False(t, service.RequiresMFA(adminMFA))
+	assert.False(t, service.RequiresMFA(&jwtMg.Claims{Role: models.RoleUser}))
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agris/user-service/config"
+	"github.com/agris/user-service/internal/cache"
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/agris/user-service/pkg/oidc"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+)
+
+const defaultOIDCStateTTL = 10 * time.Minute
+
+func newOIDCProviders(cfg []config.OIDCProviderConfig) map[string]*oidc.Provider {
+	providers := make(map[string]*oidc.Provider, len(cfg))
+	for _, pc := range cfg {
+		providers[pc.Name] = oidc.NewProvider(oidc.Config{
+			Issuer:       pc.Issuer,
+			ClientID:     pc.ClientID,
+			ClientSecret: pc.ClientSecret,
+			RedirectURL:  pc.RedirectURL,
+			Scopes:       pc.Scopes,
+		}, nil)
+	}
+	return providers
+}
+
+// OIDCAuthorizeURL bắt đầu authorization code flow với PKCE.
+// state, nonce và code verifier được giữ ở redis, trình duyệt chỉ mang state và cookie chứa hash của state.
+func (s *authService) OIDCAuthorizeURL(ctx context.Context, providerName string) (*dto.OIDCAuthorizeResponse, *dto.ServiceResponse) {
+	provider, ok := s.oidcProviders[providerName]
+	if !ok {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrOIDCProviderNotFound),
+		}
+	}
+
+	state, errState := utils.GenerateToken()
+	nonce, errNonce := utils.GenerateToken()
+	verifier, errVerifier := utils.GenerateToken()
+	if err := errors.Join(errState, errNonce, errVerifier); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.S256Challenge(verifier))
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusBadGateway,
+			Err:    errors.New(ErrOIDCFailed),
+		}
+	}
+
+	ttl := orDefault(s.cfg.Security.OIDC.StateTTL, defaultOIDCStateTTL)
+	stateHash := utils.HashToken(state)
+	if err := s.oidcRepo.SaveState(ctx, stateHash, &cache.OIDCState{
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+	}, ttl); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+	return &dto.OIDCAuthorizeResponse{
+		AuthURL:   authURL,
+		StateHash: stateHash,
+		ExpiresIn: ttl,
+	}, nil
+}
+
+// OIDCCallback đổi code lấy id_token rồi đăng nhập vào tài khoản đã liên kết,
+// tài khoản cùng email đã xác minh, hoặc tài khoản mới. Tài khoản bật MFA vẫn phải qua bước MFA.
+func (s *authService) OIDCCallback(ctx context.Context, request *dto.OIDCCallbackRequest) (*dto.LoginResponse, *dto.ServiceResponse) {
+	if request == nil || request.State == "" {
+		return nil, badRequest(ErrOIDCStateInvalid)
+	}
+
+	// state phải khớp với cookie của trình duyệt đã bắt đầu đăng nhập,
+	// tránh kẻ tấn công gửi callback của chính họ để nạn nhân đăng nhập vào tài khoản của họ
+	stateHash := utils.HashToken(request.State)
+	if subtle.ConstantTimeCompare([]byte(stateHash), []byte(request.StateHash)) != 1 {
+		return nil, badRequest(ErrOIDCStateInvalid)
+	}
+
+	// state chỉ dùng được một lần kể cả khi provider báo lỗi
+	state, err := s.oidcRepo.ConsumeState(ctx, stateHash)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+	if state == nil || state.Provider != request.Provider {
+		return nil, badRequest(ErrOIDCStateInvalid)
+	}
+	provider, ok := s.oidcProviders[state.Provider]
+	if !ok {
+		return nil, badRequest(ErrOIDCStateInvalid)
+	}
+	if request.Error != "" || request.Code == "" {
+		return nil, oidcFailed()
+	}
+
+	token, err := provider.Exchange(ctx, request.Code, state.CodeVerifier)
+	if err != nil {
+		log.Warnf("[OIDC] : exchange failed provider=%s: %s", state.Provider, err.Error())
+		return nil, oidcFailed()
+	}
+	idToken, err := provider.VerifyIDToken(ctx, token.IDToken, state.Nonce)
+	if err != nil {
+		log.Warnf("[OIDC] : invalid id_token provider=%s: %s", state.Provider, err.Error())
+		return nil, oidcFailed()
+	}
+
+	user, errResolve := s.resolveOIDCUser(ctx, state.Provider, idToken)
+	if errResolve != nil {
+		return nil, errResolve
+	}
+
+	if user.DeletedAt.Valid {
+		s.auditLoginFailed(ctx, user.ID, request.IP, map[string]any{"reason": "deactivated", "provider": state.Provider})
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusForbidden,
+			Err:    errors.New(ErrLockedAccount),
+		}
+	}
+	if s.cfg.Security.EmailVerification.RequireForLogin && !user.IsEmailVerified() {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusForbidden,
+			Err:    errors.New(ErrEmailNotVerified),
+		}
+	}
+
+	if user.IsMFAEnabled() {
+		return s.startMFAChallenge(ctx, user)
+	}
+
+	resp, errIssue := s.issueTokens(ctx, user, newSession(request.IP, request.UserAgent), false)
+	if errIssue != nil {
+		return nil, errIssue
+	}
+	event := newAuditEvent(models.AuditLoginSucceeded, user.ID, user.ID)
+	event.IP = request.IP
+	event.After = auditValues(map[string]any{"mfa": false, "provider": state.Provider})
+	s.audit.Record(ctx, event)
+	return &dto.LoginResponse{AuthResponse: resp}, nil
+}
+
+// resolveOIDCUser tìm tài khoản theo (provider, sub), sau đó theo email.
+// Email provider chưa xác minh thì không tạo hay liên kết tài khoản. Chỉ liên kết với tài khoản có sẵn
+// khi email cũng đã được xác minh ở hệ thống, tránh trường hợp ai đó đăng ký trước bằng email của người khác.
+func (s *authService) resolveOIDCUser(ctx context.Context, providerName string, idToken *oidc.IDToken) (*models.User, *dto.ServiceResponse) {
+	identity, err := s.identityRepo.FindByProviderSubject(ctx, providerName, idToken.Subject)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+	if identity != nil {
+		// tài khoản bị vô hiệu hóa thì FindByID không trả về user
+		user, errFind := s.userRepo.FindByID(ctx, identity.UserID)
+		if errFind == nil && user != nil {
+			return user, nil
+		}
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusForbidden,
+			Err:    errors.New(ErrLockedAccount),
+		}
+	}
+
+	email := strings.TrimSpace(idToken.Email)
+	if email == "" {
+		return nil, badRequest(ErrOIDCEmailRequired)
+	}
+	if !bool(idToken.EmailVerified) {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusForbidden,
+			Err:    errors.New(ErrOIDCEmailNotVerified),
+		}
+	}
+
+	user, errFind := s.userRepo.FindByEmail(ctx, email, true)
+	if errFind != nil && errFind.Status != http.StatusNotFound {
+		return nil, errFind
+	}
+
+	if user != nil {
+		if user.DeletedAt.Valid {
+			return user, nil
+		}
+		if !user.IsEmailVerified() {
+			return nil, &dto.ServiceResponse{
+				Status: http.StatusConflict,
+				Err:    errors.New(ErrOIDCEmailUnverified),
+			}
+		}
+	} else {
+		if user, errFind = s.createOIDCUser(ctx, providerName, idToken, email); errFind != nil {
+			return nil, errFind
+		}
+	}
+
+	if err := s.identityRepo.Create(ctx, &models.UserIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  idToken.Subject,
+		Email:    email,
+	}); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	event := newAuditEvent(models.AuditIdentityLinked, user.ID, user.ID)
+	event.After = auditValues(map[string]any{"provider": providerName, "subject": idToken.Subject})
+	s.audit.Record(ctx, event)
+	return user, nil
+}
+
+// createOIDCUser tạo tài khoản với mật khẩu ngẫu nhiên không ai biết,
+// người dùng muốn đăng nhập bằng mật khẩu thì dùng chức năng quên mật khẩu
+func (s *authService) createOIDCUser(ctx context.Context, providerName string, idToken *oidc.IDToken, email string) (*models.User, *dto.ServiceResponse) {
+	password, err := utils.GenerateToken()
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	name := strings.TrimSpace(idToken.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	// resolveOIDCUser chỉ gọi khi provider đã xác minh email
+	now := time.Now()
+	user := &models.User{
+		ID:              uuid.New(),
+		Name:            name,
+		Email:           email,
+		PasswordHash:    hashedPassword,
+		Role:            models.RoleUser,
+		EmailVerifiedAt: &now,
+	}
+
+	userCreated, err := s.userRepo.Create(ctx, user)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	event := newAuditEvent(models.AuditUserRegistered, userCreated.ID, userCreated.ID)
+	event.After = auditValues(map[string]any{"name": userCreated.Name, "email": userCreated.Email, "role": userCreated.Role, "provider": providerName})
+	s.audit.Record(ctx, event)
+	return userCreated, nil
+}
+
+func oidcFailed() *dto.ServiceResponse {
+	return &dto.ServiceResponse{
+		Status: http.StatusUnauthorized,
+		Err:    errors.New(ErrOIDCFailed),
+	}
+}
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/config"
+	"github.com/agris/user-service/internal/cache"
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/agris/user-service/pkg/oidc/oidctest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockIdentityRepository implements repository.IdentityRepository
+type MockIdentityRepository struct {
+	mu         sync.Mutex
+	identities []models.UserIdentity
+}
+
+func (m *MockIdentityRepository) FindByProviderSubject(ctx context.Context, provider string, subject string) (*models.UserIdentity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, identity := range m.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.identities = append(m.identities, *identity)
+	return nil
+}
+
+// MockOIDCStateRepository implements cache.OIDCStateRepository
+type MockOIDCStateRepository struct {
+	mu     sync.Mutex
+	states map[string]cache.OIDCState
+}
+
+func (m *MockOIDCStateRepository) SaveState(ctx context.Context, stateHash string, state *cache.OIDCState, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.states == nil {
+		m.states = make(map[string]cache.OIDCState)
+	}
+	m.states[stateHash] = *state
+	return nil
+}
+
+func (m *MockOIDCStateRepository) ConsumeState(ctx context.Context, stateHash string) (*cache.OIDCState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.states[stateHash]
+	if !ok {
+		return nil, nil
+	}
+	delete(m.states, stateHash)
+	return &state, nil
+}
+
+type oidcTestEnv struct {
+	server       *oidctest.Server
+	service      AuthService
+	jwtManager   *jwtMg.JWTManager
+	userRepo     *MockUserRepository
+	identityRepo *MockIdentityRepository
+	audit        *MockAuditService
+}
+
+// newOIDCTestEnv chạy provider giả và authService cấu hình provider "mock" trỏ tới nó
+func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
+	server := oidctest.NewServer("agris-client", "agris-secret")
+	t.Cleanup(server.Close)
+
+	cfg := newTestConfig()
+	cfg.Security.OIDC.Providers = []config.OIDCProviderConfig{{
+		Name:         "mock",
+		Issuer:       server.Issuer(),
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  "http://localhost:8005/users/oidc/mock/callback",
+	}}
+	jwtManager, err := jwtMg.NewJWTManager(cfg)
+	require.NoError(t, err)
+
+	rdRepo := new(MockRedisRepository)
+	rdRepo.On("SaveRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	rdRepo.On("SaveSession", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	env := &oidcTestEnv{
+		server:       server,
+		jwtManager:   jwtManager,
+		userRepo:     new(MockUserRepository),
+		identityRepo: new(MockIdentityRepository),
+		audit:        new(MockAuditService),
+	}
+	env.service = NewAuthService(env.userRepo, jwtManager, cfg, rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), env.identityRepo, new(MockOIDCStateRepository), env.audit)
+	return env
+}
+
+// login mô phỏng trình duyệt: lấy URL đăng nhập, provider tự đồng ý và chuyển hướng về callback
+func (env *oidcTestEnv) login(t *testing.T) (*dto.OIDCCallbackRequest, *dto.LoginResponse, *dto.ServiceResponse) {
+	authorize, errURL := env.service.OIDCAuthorizeURL(context.Background(), "mock")
+	require.Nil(t, errURL)
+
+	callback, err := env.server.Authorize(authorize.AuthURL)
+	require.NoError(t, err)
+
+	request := &dto.OIDCCallbackRequest{
+		Provider:  "mock",
+		Code:      callback.Query().Get("code"),
+		State:     callback.Query().Get("state"),
+		StateHash: authorize.StateHash,
+		IP:        "10.0.0.3",
+	}
+	response, errCallback := env.service.OIDCCallback(context.Background(), request)
+	return request, response, errCallback
+}
+
+func TestOIDCLogin(t *testing.T) {
+	identity := oidctest.Identity{Subject: "sub-1", Email: "oidc@example.com", EmailVerified: true, Name: "OIDC User"}
+	notFound := &dto.ServiceResponse{Status: http.StatusNotFound, Err: errors.New(ErrUserNotFound)}
+
+	t.Run("Success - Creates Account", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.server.SetIdentity(identity)
+		env.userRepo.On("FindByEmail", mock.Anything, identity.Email, true).Return(nil, notFound)
+		created := &models.User{}
+		env.userRepo.On("Create", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
+			return user.Email == identity.Email && user.Name == identity.Name && user.Role == models.RoleUser &&
+				user.IsEmailVerified() && user.PasswordHash != ""
+		})).Run(func(args mock.Arguments) {
+			*created = *args.Get(1).(*models.User)
+		}).Return(created, nil)
+
+		_, response, errLogin := env.login(t)
+
+		require.Nil(t, errLogin)
+		require.NotNil(t, response.AuthResponse)
+		claims, err := env.jwtManager.ValidateAccessToken(response.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, identity.Email, claims.Email)
+		require.Len(t, env.identityRepo.identities, 1)
+		assert.Equal(t, claims.UserID, env.identityRepo.identities[0].UserID)
+		assert.Equal(t, []models.AuditAction{models.AuditUserRegistered, models.AuditIdentityLinked, models.AuditLoginSucceeded}, env.audit.Actions())
+	})
+
+	t.Run("Success - Existing Identity", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.server.SetIdentity(identity)
+		user := &models.User{ID: uuid.New(), Email: "renamed@example.com", Role: models.RoleUser}
+		env.identityRepo.identities = []models.UserIdentity{{ID: uuid.New(), UserID: user.ID, Provider: "mock", Subject: identity.Subject}}
+		env.userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+
+		_, response, errLogin := env.login(t)
+
+		require.Nil(t, errLogin)
+		assert.Equal(t, user.ID, response.User.Id)
+		env.userRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything, mock.Anything)
+		assert.Equal(t, []models.AuditAction{models.AuditLoginSucceeded}, env.audit.Actions())
+	})
+
+	t.Run("Success - Links Verified Email", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.server.SetIdentity(identity)
+		verifiedAt := time.Now()
+		user := &models.User{ID: uuid.New(), Email: identity.Email, Role: models.RoleUser, EmailVerifiedAt: &verifiedAt}
+		env.userRepo.On("FindByEmail", mock.Anything, identity.Email, true).Return(user, nil)
+
+		_, response, errLogin := env.login(t)
+
+		require.Nil(t, errLogin)
+		assert.Equal(t, user.ID, response.User.Id)
+		require.Len(t, env.identityRepo.identities, 1)
+		assert.Equal(t, user.ID, env.identityRepo.identities[0].UserID)
+		env.userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - Unverified Email Not Linked", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.server.SetIdentity(oidctest.Identity{Subject: "sub-2", Email: identity.Email, EmailVerified: false})
+		verifiedAt := time.Now()
+		user := &models.User{ID: uuid.New(), Email: identity.Email, Role: models.RoleUser, EmailVerifiedAt: &verifiedAt}
+		env.userRepo.On("FindByEmail", mock.Anything, identity.Email, true).Return(user, nil)
+
+		_, _, errLogin := env.login(t)
+
+		require.NotNil(t, errLogin)
+		assert.Equal(t, http.StatusForbidden, errLogin.Status)
+		assert.Equal(t, ErrOIDCEmailNotVerified, errLogin.Err.Error())
+		assert.Empty(t, env.identityRepo.identities)
+	})
+
+	t.Run("Error - Unverified Email Not Created", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.server.SetIdentity(oidctest.Identity{Subject: "sub-3", Email: "new@example.com", EmailVerified: false})
+
+		_, _, errLogin := env.login(t)
+
+		require.NotNil(t, errLogin)
+		assert.Equal(t, http.StatusForbidden, errLogin.Status)
+		env.userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		assert.Empty(t, env.identityRepo.identities)
+	})
+
+	t.Run("Error - Local Email Unverified", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.server.SetIdentity(identity)
+		user := &models.User{ID: uuid.New(), Email: identity.Email, Role: models.RoleUser}
+		env.userRepo.On("FindByEmail", mock.Anything, identity.Email, true).Return(user, nil)
+
+		_, _, errLogin := env.login(t)
+
+		require.NotNil(t, errLogin)
+		assert.Equal(t, http.StatusConflict, errLogin.Status)
+		assert.Empty(t, env.identityRepo.identities)
+	})
+
+	t.Run("Error - Deactivated Account", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.server.SetIdentity(identity)
+		user := &models.User{ID: uuid.New(), Email: identity.Email, Role: models.RoleUser}
+		env.identityRepo.identities = []models.UserIdentity{{ID: uuid.New(), UserID: user.ID, Provider: "mock", Subject: identity.Subject}}
+		env.userRepo.On("FindByID", mock.Anything, user.ID).Return(nil, notFound)
+
+		_, _, errLogin := env.login(t)
+
+		require.NotNil(t, errLogin)
+		assert.Equal(t, http.StatusForbidden, errLogin.Status)
+		assert.Equal(t, ErrLockedAccount, errLogin.Err.Error())
+	})
+
+	t.Run("Success - MFA Challenge", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.server.SetIdentity(identity)
+		enabledAt := time.Now()
+		user := &models.User{ID: uuid.New(), Email: identity.Email, Role: models.RoleUser, MFASecret: "secret", MFAEnabledAt: &enabledAt}
+		env.identityRepo.identities = []models.UserIdentity{{ID: uuid.New(), UserID: user.ID, Provider: "mock", Subject: identity.Subject}}
+		env.userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+		mfaRepo := new(MockMFARepository)
+		mfaRepo.On("SaveChallenge", mock.Anything, mock.Anything, user.ID, mock.Anything).Return(nil)
+		env.service.(*authService).mfaRepo = mfaRepo
+
+		_, response, errLogin := env.login(t)
+
+		require.Nil(t, errLogin)
+		assert.True(t, response.MFARequired)
+		assert.Nil(t, response.AuthResponse)
+	})
+
+	t.Run("Error - State Replayed", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.server.SetIdentity(identity)
+		user := &models.User{ID: uuid.New(), Email: identity.Email, Role: models.RoleUser}
+		env.identityRepo.identities = []models.UserIdentity{{ID: uuid.New(), UserID: user.ID, Provider: "mock", Subject: identity.Subject}}
+		env.userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+
+		request, _, errLogin := env.login(t)
+		require.Nil(t, errLogin)
+
+		_, errReplay := env.service.OIDCCallback(context.Background(), request)
+		require.NotNil(t, errReplay)
+		assert.Equal(t, http.StatusBadRequest, errReplay.Status)
+		assert.Equal(t, ErrOIDCStateInvalid, errReplay.Err.Error())
+	})
+
+	t.Run("Error - State Cookie Mismatch", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		env.server.SetIdentity(identity)
+		// state của kẻ tấn công, trình duyệt nạn nhân mang cookie của một lần đăng nhập khác hoặc không có cookie
+		attacker, errURL := env.service.OIDCAuthorizeURL(context.Background(), "mock")
+		require.Nil(t, errURL)
+		victim, errURL := env.service.OIDCAuthorizeURL(context.Background(), "mock")
+		require.Nil(t, errURL)
+		callback, err := env.server.Authorize(attacker.AuthURL)
+		require.NoError(t, err)
+
+		for _, stateHash := range []string{victim.StateHash, ""} {
+			_, errCallback := env.service.OIDCCallback(context.Background(), &dto.OIDCCallbackRequest{
+				Provider:  "mock",
+				Code:      callback.Query().Get("code"),
+				State:     callback.Query().Get("state"),
+				StateHash: stateHash,
+			})
+
+			require.NotNil(t, errCallback)
+			assert.Equal(t, http.StatusBadRequest, errCallback.Status)
+			assert.Equal(t, ErrOIDCStateInvalid, errCallback.Err.Error())
+		}
+		env.userRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - Provider Denied", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+		authorize, errURL := env.service.OIDCAuthorizeURL(context.Background(), "mock")
+		require.Nil(t, errURL)
+		callback, err := env.server.Authorize(authorize.AuthURL)
+		require.NoError(t, err)
+
+		_, errCallback := env.service.OIDCCallback(context.Background(), &dto.OIDCCallbackRequest{
+			Provider:  "mock",
+			State:     callback.Query().Get("state"),
+			StateHash: authorize.StateHash,
+			Error:     "access_denied",
+		})
+
+		require.NotNil(t, errCallback)
+		assert.Equal(t, http.StatusUnauthorized, errCallback.Status)
+	})
+
+	t.Run("Error - Unknown Provider", func(t *testing.T) {
+		env := newOIDCTestEnv(t)
+
+		_, errURL := env.service.OIDCAuthorizeURL(context.Background(), "unknown")
+
+		require.NotNil(t, errURL)
+		assert.Equal(t, http.StatusNotFound, errURL.Status)
+	})
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/agris/user-service/config"
+	"github.com/agris/user-service/internal/cache"
+	"github.com/agris/user-service/internal/dto"
+	"github.com/agris/user-service/internal/mailer"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/repository"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+)
+
+type PasswordService interface {
+	ForgotPassword(ctx context.Context, request *dto.ForgotPasswordRequest) *dto.ServiceResponse
+	ResetPassword(ctx context.Context, request *dto.ResetPasswordRequest) *dto.ServiceResponse
+	ChangePassword(ctx context.Context, userID uuid.UUID, currentSessionID string, request *dto.ChangePasswordRequest) *dto.ServiceResponse
+	ForceResetPassword(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse
+}
+
+type passwordService struct {
+	userRepo  repository.UserRepository
+	rdRepo    cache.RedisRepository
+	resetRepo cache.PasswordResetRepository
+	mailer    mailer.Mailer
+	cfg       *config.Config
+	audit     AuditService
+}
+
+func NewPasswordService(userRepo repository.UserRepository, rdRepo cache.RedisRepository, resetRepo cache.PasswordResetRepository, mailer mailer.Mailer, cfg *config.Config, audit AuditService) PasswordService {
+	return &passwordService{userRepo: userRepo, rdRepo: rdRepo, resetRepo: resetRepo, mailer: mailer, cfg: cfg, audit: audit}
+}
+
+// ForgotPassword gửi link đặt lại mật khẩu, mỗi tài khoản tối đa một email mỗi request_interval.
+// Email không tồn tại, đang trong thời gian chờ hay gửi lỗi đều trả về thành công
+// để không lộ tài khoản nào đã đăng ký.
+func (p *passwordService) ForgotPassword(ctx context.Context, request *dto.ForgotPasswordRequest) *dto.ServiceResponse {
+	if request == nil || request.Email == "" {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrInvalidData),
+		}
+	}
+
+	user, errFind := p.userRepo.FindByEmail(ctx, request.Email, false)
+	if errFind != nil || user == nil {
+		return nil
+	}
+
+	if interval := p.cfg.Security.PasswordReset.RequestInterval; interval > 0 {
+		acquired, err := p.resetRepo.AcquireSendSlot(ctx, user.ID, interval)
+		if err != nil {
+			log.Error("[ERROR] : ", err.Error())
+			return nil
+		}
+		if !acquired {
+			return nil
+		}
+	}
+
+	if err := p.sendResetLink(ctx, user); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+	}
+	return nil
+}
+
+// sendResetLink lưu hash của token mới và gửi link đặt lại mật khẩu.
+// Email được gửi nền để thời gian phản hồi không cho biết email có tồn tại hay không.
+func (p *passwordService) sendResetLink(ctx context.Context, user *models.User) error {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := p.cfg.Security.PasswordReset.TokenTTL
+	if err := p.resetRepo.SaveResetToken(ctx, user.ID, utils.HashToken(token), ttl); err != nil {
+		return err
+	}
+
+	sendMailAsync(p.mailer, mailer.Message{
+		To:      user.Email,
+		Subject: "Đặt lại mật khẩu",
+		Body: fmt.Sprintf("Xin chào %s,\n\nNhấn vào link sau để đặt lại mật khẩu (hết hạn sau %s):\n%s\n\nNếu bạn không yêu cầu, hãy bỏ qua email này.",
+			user.Name, ttl, withToken(p.cfg.Security.PasswordReset.URL, token)),
+	})
+	return nil
+}
+
+// ResetPassword dùng token một lần để đổi mật khẩu và thu hồi mọi phiên đăng nhập
+func (p *passwordService) ResetPassword(ctx context.Context, request *dto.ResetPasswordRequest) *dto.ServiceResponse {
+	if request == nil || request.Token == "" || request.NewPassword == "" {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrInvalidData),
+		}
+	}
+
+	userID, ok, err := p.resetRepo.ConsumeResetToken(ctx, utils.HashToken(request.Token))
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    errors.New(ErrInternalServerError),
+		}
+	}
+	if !ok {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrResetTokenInvalid),
+		}
+	}
+
+	user, errFind := p.userRepo.FindByID(ctx, userID)
+	if errFind != nil || user == nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrResetTokenInvalid),
+		}
+	}
+
+	hashedPassword, err := utils.HashPassword(request.NewPassword)
+	if err != nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    errors.New(ErrHashPassword),
+		}
+	}
+
+	user.PasswordHash = hashedPassword
+	if _, errUpdate := p.userRepo.Update(ctx, user); errUpdate != nil {
+		return errUpdate
+	}
+	p.audit.Record(ctx, newAuditEvent(models.AuditPasswordReset, uuid.Nil, user.ID))
+
+	if err := revokeAllSessions(ctx, p.rdRepo, p.cfg, user.ID); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    errors.New(ErrInternalServerError),
+		}
+	}
+
+	return nil
+}
+
+// ChangePassword đổi mật khẩu khi biết mật khẩu hiện tại.
+// Phiên đang dùng được giữ lại, các phiên khác bị thu hồi.
+func (p *passwordService) ChangePassword(ctx context.Context, userID uuid.UUID, currentSessionID string, request *dto.ChangePasswordRequest) *dto.ServiceResponse {
+	if request == nil || request.CurrentPassword == "" || request.NewPassword == "" {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrInvalidData),
+		}
+	}
+
+	user, errFind := p.userRepo.FindByID(ctx, userID)
+	if errFind != nil || user == nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrUserNotFound),
+		}
+	}
+
+	if !utils.CheckPasswordHash(request.CurrentPassword, user.PasswordHash) {
+		return &dto.ServiceResponse{
+			Status: http.StatusForbidden,
+			Err:    errors.New(ErrPasswordNotMatch),
+		}
+	}
+	if request.CurrentPassword == request.NewPassword {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrSamePassword),
+		}
+	}
+
+	hashedPassword, err := utils.HashPassword(request.NewPassword)
+	if err != nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    errors.New(ErrHashPassword),
+		}
+	}
+
+	user.PasswordHash = hashedPassword
+	if _, errUpdate := p.userRepo.Update(ctx, user); errUpdate != nil {
+		return errUpdate
+	}
+	p.audit.Record(ctx, newAuditEvent(models.AuditPasswordChanged, user.ID, user.ID))
+
+	if err := revokeOtherSessions(ctx, p.rdRepo, user.ID, currentSessionID); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    errors.New(ErrInternalServerError),
+		}
+	}
+
+	return nil
+}
+
+// ForceResetPassword cho admin vô hiệu hóa mật khẩu hiện tại của user.
+// Mọi phiên bị thu hồi và user phải đặt mật khẩu mới qua link được gửi tới email.
+func (p *passwordService) ForceResetPassword(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse {
+	if errSelf := checkNotSelf(accountID, userID); errSelf != nil {
+		return errSelf
+	}
+
+	user, errFind := p.userRepo.FindByID(ctx, userID)
+	if errFind != nil || user == nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrUserNotFound),
+		}
+	}
+
+	// mật khẩu ngẫu nhiên không ai biết để mật khẩu cũ không còn dùng được
+	randomPassword, err := utils.GenerateToken()
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return internalError()
+	}
+	hashedPassword, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    errors.New(ErrHashPassword),
+		}
+	}
+
+	user.PasswordHash = hashedPassword
+	if _, errUpdate := p.userRepo.Update(ctx, user); errUpdate != nil {
+		return errUpdate
+	}
+
+	if err := revokeAllSessions(ctx, p.rdRepo, p.cfg, user.ID); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return internalError()
+	}
+
+	if err := p.sendResetLink(ctx, user); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return internalError()
+	}
+
+	p.audit.Record(ctx, newAuditEvent(models.AuditPasswordResetForced, accountID, userID))
+	return nil
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/internal/cache"
+	"github.com/agris/user-service/internal/dto"
+	"github.com/agris/user-service/internal/mailer"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPasswordResetRepository implements cache.PasswordResetRepository
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) SaveResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, ttl time.Duration) error {
+	args := m.Called(ctx, userID, tokenHash, ttl)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) AcquireSendSlot(ctx context.Context, userID uuid.UUID, interval time.Duration) (bool, error) {
+	args := m.Called(ctx, userID, interval)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) ConsumeResetToken(ctx context.Context, tokenHash string) (uuid.UUID, bool, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(uuid.UUID), args.Bool(1), args.Error(2)
+}
+
+// MockMailer ghi lại email đã gửi
+type MockMailer struct {
+	sent chan mailer.Message
+}
+
+func newMockMailer() *MockMailer {
+	return &MockMailer{sent: make(chan mailer.Message, 1)}
+}
+
+func (m *MockMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+func TestForgotPassword(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Security.PasswordReset.TokenTTL = 30 * time.Minute
+	cfg.Security.PasswordReset.URL = "http://localhost:3000/reset-password"
+	cfg.Security.PasswordReset.RequestInterval = time.Minute
+	user := &models.User{ID: uuid.New(), Name: "John Doe", Email: "john@example.com"}
+
+	t.Run("Success - Sends Link", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		resetRepo := new(MockPasswordResetRepository)
+		mockMailer := newMockMailer()
+		var savedHash string
+		userRepo.On("FindByEmail", mock.Anything, user.Email, false).Return(user, nil)
+		resetRepo.On("AcquireSendSlot", mock.Anything, user.ID, time.Minute).Return(true, nil)
+		resetRepo.On("SaveResetToken", mock.Anything, user.ID, mock.Anything, 30*time.Minute).
+			Run(func(args mock.Arguments) { savedHash = args.String(2) }).
+			Return(nil)
+
+		service := NewPasswordService(userRepo, new(MockRedisRepository), resetRepo, mockMailer, cfg, new(MockAuditService))
+		response := service.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: user.Email})
+		assert.Nil(t, response)
+
+		select {
+		case msg := <-mockMailer.sent:
+			assert.Equal(t, user.Email, msg.To)
+			i := strings.Index(msg.Body, "token=")
+			assert.NotEqual(t, -1, i)
+			token := strings.Fields(msg.Body[i+len("token="):])[0]
+			// chỉ hash của token được lưu
+			assert.Equal(t, utils.HashToken(token), savedHash)
+		case <-time.After(time.Second):
+			t.Fatal("reset email was not sent")
+		}
+	})
+
+	t.Run("Success - Cooldown Skips Email", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		resetRepo := new(MockPasswordResetRepository)
+		userRepo.On("FindByEmail", mock.Anything, user.Email, false).Return(user, nil)
+		resetRepo.On("AcquireSendSlot", mock.Anything, user.ID, time.Minute).Return(false, nil)
+
+		service := NewPasswordService(userRepo, new(MockRedisRepository), resetRepo, newMockMailer(), cfg, new(MockAuditService))
+		response := service.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: user.Email})
+
+		// trả về như khi gửi thành công để không lộ email đã đăng ký
+		assert.Nil(t, response)
+		resetRepo.AssertNotCalled(t, "SaveResetToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success - Send Failure Is Hidden", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		resetRepo := new(MockPasswordResetRepository)
+		userRepo.On("FindByEmail", mock.Anything, user.Email, false).Return(user, nil)
+		resetRepo.On("AcquireSendSlot", mock.Anything, user.ID, time.Minute).Return(true, nil)
+		resetRepo.On("SaveResetToken", mock.Anything, user.ID, mock.Anything, 30*time.Minute).Return(errors.New("redis down"))
+
+		service := NewPasswordService(userRepo, new(MockRedisRepository), resetRepo, newMockMailer(), cfg, new(MockAuditService))
+		response := service.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: user.Email})
+
+		assert.Nil(t, response)
+		resetRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - Unknown Email", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		resetRepo := new(MockPasswordResetRepository)
+		userRepo.On("FindByEmail", mock.Anything, "unknown@example.com", false).Return(nil, &dto.ServiceResponse{Status: http.StatusNotFound})
+
+		service := NewPasswordService(userRepo, new(MockRedisRepository), resetRepo, newMockMailer(), cfg, new(MockAuditService))
+		response := service.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: "unknown@example.com"})
+
+		assert.Nil(t, response)
+		resetRepo.AssertNotCalled(t, "SaveResetToken")
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	cfg := newTestConfig()
+	user := &models.User{ID: uuid.New(), Email: "john@example.com", PasswordHash: "old-hash"}
+	token := "reset-token"
+
+	t.Run("Success - Revokes Sessions", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		rdRepo := new(MockRedisRepository)
+		resetRepo := new(MockPasswordResetRepository)
+		resetRepo.On("ConsumeResetToken", mock.Anything, utils.HashToken(token)).Return(user.ID, true, nil)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(&models.User{ID: user.ID, Email: user.Email, PasswordHash: "old-hash"}, nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+			return utils.CheckPasswordHash("newPassword123", u.PasswordHash)
+		})).Return(user, nil)
+		rdRepo.On("RevokeAllRefreshTokens", mock.Anything, user.ID).Return(nil)
+		rdRepo.On("RevokeAccessTokensBefore", mock.Anything, user.ID, mock.Anything, cfg.JWT.AccessExpiry).Return(nil)
+		rdRepo.On("PublishRevocation", mock.Anything, mock.Anything).Return(nil)
+
+		service := NewPasswordService(userRepo, rdRepo, resetRepo, newMockMailer(), cfg, new(MockAuditService))
+		response := service.ResetPassword(context.Background(), &dto.ResetPasswordRequest{Token: token, NewPassword: "newPassword123"})
+
+		assert.Nil(t, response)
+		userRepo.AssertExpectations(t)
+		rdRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Token Used Or Expired", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		resetRepo := new(MockPasswordResetRepository)
+		resetRepo.On("ConsumeResetToken", mock.Anything, utils.HashToken(token)).Return(uuid.Nil, false, nil)
+
+		service := NewPasswordService(userRepo, new(MockRedisRepository), resetRepo, newMockMailer(), cfg, new(MockAuditService))
+		response := service.ResetPassword(context.Background(), &dto.ResetPasswordRequest{Token: token, NewPassword: "newPassword123"})
+
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusBadRequest, response.Status)
+		assert.Equal(t, ErrResetTokenInvalid, response.Err.Error())
+		userRepo.AssertNotCalled(t, "Update")
+	})
+
+	t.Run("Error - Redis Fails", func(t *testing.T) {
+		resetRepo := new(MockPasswordResetRepository)
+		resetRepo.On("ConsumeResetToken", mock.Anything, utils.HashToken(token)).Return(uuid.Nil, false, errors.New("redis down"))
+
+		service := NewPasswordService(new(MockUserRepository), new(MockRedisRepository), resetRepo, newMockMailer(), cfg, new(MockAuditService))
+		response := service.ResetPassword(context.Background(), &dto.ResetPasswordRequest{Token: token, NewPassword: "newPassword123"})
+
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusInternalServerError, response.Status)
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	cfg := newTestConfig()
+	hashed, err := utils.HashPassword("oldPassword123")
+	assert.NoError(t, err)
+	userID := uuid.New()
+	newUser := func() *models.User {
+		return &models.User{ID: userID, Email: "john@example.com", PasswordHash: hashed}
+	}
+	accessExpiresAt := time.Now().Add(time.Hour)
+
+	t.Run("Success - Keeps Current Session", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		rdRepo := new(MockRedisRepository)
+		userRepo.On("FindByID", mock.Anything, userID).Return(newUser(), nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+			return utils.CheckPasswordHash("newPassword123", u.PasswordHash)
+		})).Return(newUser(), nil)
+		rdRepo.On("ListSessions", mock.Anything, userID).Return([]cache.Session{
+			{ID: "current", RefreshToken: "current-refresh", AccessJTI: "jti-current"},
+			{ID: "other", RefreshToken: "other-refresh", AccessJTI: "jti-other", AccessExpiresAt: accessExpiresAt},
+		}, nil)
+		rdRepo.On("RemoveRefreshToken", mock.Anything, userID, "other-refresh").Return(nil)
+		rdRepo.On("DeleteSession", mock.Anything, userID, "other").Return(nil)
+		rdRepo.On("RevokeSessionAccessTokens", mock.Anything, "other", accessExpiresAt).Return(nil)
+		rdRepo.On("PublishRevocation", mock.Anything, mock.Anything).Return(nil)
+
+		service := NewPasswordService(userRepo, rdRepo, new(MockPasswordResetRepository), newMockMailer(), cfg, new(MockAuditService))
+		response := service.ChangePassword(context.Background(), userID, "current", &dto.ChangePasswordRequest{
+			CurrentPassword: "oldPassword123",
+			NewPassword:     "newPassword123",
+		})
+
+		assert.Nil(t, response)
+		userRepo.AssertExpectations(t)
+		rdRepo.AssertExpectations(t)
+		rdRepo.AssertNotCalled(t, "DeleteSession", mock.Anything, userID, "current")
+	})
+
+	t.Run("Error - Wrong Current Password", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, userID).Return(newUser(), nil)
+
+		service := NewPasswordService(userRepo, new(MockRedisRepository), new(MockPasswordResetRepository), newMockMailer(), cfg, new(MockAuditService))
+		response := service.ChangePassword(context.Background(), userID, "current", &dto.ChangePasswordRequest{
+			CurrentPassword: "wrongPassword",
+			NewPassword:     "newPassword123",
+		})
+
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusForbidden, response.Status)
+		assert.Equal(t, ErrPasswordNotMatch, response.Err.Error())
+		userRepo.AssertNotCalled(t, "Update")
+	})
+
+	t.Run("Error - Same Password", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, userID).Return(newUser(), nil)
+
+		service := NewPasswordService(userRepo, new(MockRedisRepository), new(MockPasswordResetRepository), newMockMailer(), cfg, new(MockAuditService))
+		response := service.ChangePassword(context.Background(), userID, "current", &dto.ChangePasswordRequest{
+			CurrentPassword: "oldPassword123",
+			NewPassword:     "oldPassword123",
+		})
+
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusBadRequest, response.Status)
+		assert.Equal(t, ErrSamePassword, response.Err.Error())
+	})
+}
+
+func TestForceResetPassword(t *testing.T) {
+	cfg := newTestConfig()
+	adminID := uuid.New()
+	hashed, err := utils.HashPassword("oldPassword123")
+	assert.NoError(t, err)
+	user := &models.User{ID: uuid.New(), Name: "John Doe", Email: "john@example.com", PasswordHash: hashed}
+
+	t.Run("Success - Invalidates Password And Sends Link", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		rdRepo := new(MockRedisRepository)
+		resetRepo := new(MockPasswordResetRepository)
+		mockMailer := newMockMailer()
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(&models.User{ID: user.ID, Name: user.Name, Email: user.Email, PasswordHash: hashed}, nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+			return !utils.CheckPasswordHash("oldPassword123", u.PasswordHash)
+		})).Return(user, nil)
+		rdRepo.On("RevokeAllRefreshTokens", mock.Anything, user.ID).Return(nil)
+		rdRepo.On("RevokeAccessTokensBefore", mock.Anything, user.ID, mock.Anything, cfg.JWT.AccessExpiry).Return(nil)
+		rdRepo.On("PublishRevocation", mock.Anything, mock.Anything).Return(nil)
+		resetRepo.On("SaveResetToken", mock.Anything, user.ID, mock.Anything, mock.Anything).Return(nil)
+
+		service := NewPasswordService(userRepo, rdRepo, resetRepo, mockMailer, cfg, new(MockAuditService))
+		response := service.ForceResetPassword(context.Background(), adminID, user.ID)
+
+		assert.Nil(t, response)
+		userRepo.AssertExpectations(t)
+		rdRepo.AssertExpectations(t)
+		select {
+		case msg := <-mockMailer.sent:
+			assert.Equal(t, user.Email, msg.To)
+		case <-time.After(time.Second):
+			t.Fatal("reset email was not sent")
+		}
+	})
+
+	t.Run("Error - Own Account", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+
+		service := NewPasswordService(userRepo, new(MockRedisRepository), new(MockPasswordResetRepository), newMockMailer(), cfg, new(MockAuditService))
+		response := service.ForceResetPassword(context.Background(), adminID, adminID)
+
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusBadRequest, response.Status)
+		assert.Equal(t, ErrCantUpdateOwnAccount, response.Err.Error())
+		userRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/pkg/jwtMg"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginIssuesPermissions(t *testing.T) {
+	cfg := newTestConfig()
+	jwtManager, err := jwtMg.NewJWTManager(cfg)
+	require.NoError(t, err)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &models.User{
+		ID:           uuid.New(),
+		Email:        "moderator@example.com",
+		PasswordHash: string(hashedPassword),
+		Role:         models.RoleModerator,
+	}
+
+	newMocks := func() (*MockUserRepository, *MockRedisRepository, *MockLoginAttemptRepository) {
+		userRepo := new(MockUserRepository)
+		rdRepo := new(MockRedisRepository)
+		attemptRepo := new(MockLoginAttemptRepository)
+		attemptRepo.On("GetIPFailures", mock.Anything, mock.Anything).Return(int64(0), time.Duration(0), nil)
+		userRepo.On("FindByEmail", mock.Anything, user.Email, true).Return(user, nil)
+		attemptRepo.On("GetAccountLock", mock.Anything, user.ID).Return(time.Duration(0), nil)
+		attemptRepo.On("ResetAccount", mock.Anything, user.ID).Return(nil)
+		return userRepo, rdRepo, attemptRepo
+	}
+	request := &dto.LoginRequest{Email: user.Email, Password: "password123"}
+
+	t.Run("Success - Permissions Of Role In Access Token", func(t *testing.T) {
+		userRepo, rdRepo, attemptRepo := newMocks()
+		rdRepo.On("SaveRefreshToken", mock.Anything, user.ID, mock.Anything, mock.Anything).Return(nil)
+		rdRepo.On("SaveSession", mock.Anything, user.ID, mock.Anything).Return(nil)
+		permRepo := &MockPermissionRepository{permissions: map[models.Role][]models.Permission{
+			models.RoleModerator: {models.PermRatingsModerate},
+		}}
+
+		service := NewAuthService(userRepo, jwtManager, cfg, rdRepo, attemptRepo, new(MockMFARepository), permRepo, new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response, errLogin := service.Login(context.Background(), request)
+		require.Nil(t, errLogin)
+
+		claims, err := jwtManager.ValidateAccessToken(response.AccessToken)
+		require.NoError(t, err)
+		assert.True(t, claims.HasPermission(models.PermRatingsModerate))
+		assert.False(t, claims.HasPermission(models.PermUsersWrite))
+	})
+
+	t.Run("Error - Permission Lookup Fails", func(t *testing.T) {
+		userRepo, rdRepo, attemptRepo := newMocks()
+		permRepo := &MockPermissionRepository{err: errors.New("db down")}
+
+		service := NewAuthService(userRepo, jwtManager, cfg, rdRepo, attemptRepo, new(MockMFARepository), permRepo, new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response, errLogin := service.Login(context.Background(), request)
+
+		assert.Nil(t, response)
+		assert.Equal(t, http.StatusInternalServerError, errLogin.Status)
+		rdRepo.AssertNotCalled(t, "SaveRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
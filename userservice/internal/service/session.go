@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/agris/user-service/config"
+	"github.com/agris/user-service/internal/cache"
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+)
+
+// revokeAllSessions thu hồi mọi refresh token và access token đã cấp cho user
+func revokeAllSessions(ctx context.Context, rdRepo cache.RedisRepository, cfg *config.Config, userID uuid.UUID) error {
+	if err := rdRepo.RevokeAllRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	// access token không có trong redis, nên chặn theo thời điểm cấp
+	if err := rdRepo.RevokeAccessTokensBefore(ctx, userID, time.Now(), cfg.JWT.AccessExpiry); err != nil {
+		return err
+	}
+
+	publishRevocation(ctx, rdRepo, userID, "")
+	return nil
+}
+
+// publishRevocation báo cho các service đang cache kết quả xác thực.
+// Token đã bị thu hồi trong redis nên lỗi publish chỉ được ghi log.
+func publishRevocation(ctx context.Context, rdRepo cache.RedisRepository, userID uuid.UUID, jti string) {
+	event := cache.RevocationEvent{
+		UserID:    userID,
+		JTI:       jti,
+		RevokedAt: time.Now(),
+	}
+	if err := rdRepo.PublishRevocation(ctx, event); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+	}
+}
+
+// maxUserAgentLength giới hạn user-agent lưu trong phiên
+const maxUserAgentLength = 255
+
+func newSession(ip string, userAgent string) *cache.Session {
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return &cache.Session{
+		ID:        uuid.NewString(),
+		IP:        ip,
+		UserAgent: userAgent,
+		CreatedAt: time.Now(),
+	}
+}
+
+// ListSessions liệt kê các phiên đăng nhập còn hiệu lực, mới dùng gần nhất đứng đầu
+func (s *authService) ListSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) ([]dto.SessionResponse, *dto.ServiceResponse) {
+	sessions, err := s.rdRepo.ListSessions(ctx, userID)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return nil, internalError()
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastRefreshAt.After(sessions[j].LastRefreshAt)
+	})
+
+	response := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, dto.SessionResponse{
+			Id:            session.ID,
+			UserAgent:     session.UserAgent,
+			IP:            session.IP,
+			CreatedAt:     session.CreatedAt,
+			LastRefreshAt: session.LastRefreshAt,
+			ExpiresAt:     session.ExpiresAt,
+			Current:       currentSessionID != "" && session.ID == currentSessionID,
+		})
+	}
+	return response, nil
+}
+
+// RevokeSession thu hồi refresh token và mọi access token của một phiên
+func (s *authService) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) *dto.ServiceResponse {
+	session, err := s.rdRepo.GetSession(ctx, userID, sessionID)
+	if err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return internalError()
+	}
+	if session == nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrSessionNotFound),
+		}
+	}
+
+	if err := endSession(ctx, s.rdRepo, userID, session); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return internalError()
+	}
+	return nil
+}
+
+// AdminRevokeSession cho admin thu hồi một phiên của user khác
+func (s *authService) AdminRevokeSession(ctx context.Context, accountID uuid.UUID, userID uuid.UUID, sessionID string) *dto.ServiceResponse {
+	if errRevoke := s.RevokeSession(ctx, userID, sessionID); errRevoke != nil {
+		return errRevoke
+	}
+
+	event := newAuditEvent(models.AuditSessionRevoked, accountID, userID)
+	event.Before = auditValues(map[string]any{"session_id": sessionID})
+	s.audit.Record(ctx, event)
+	return nil
+}
+
+// AdminRevokeAllSessions cho admin đăng xuất user khỏi mọi thiết bị, ví dụ khi tài khoản bị lộ
+func (s *authService) AdminRevokeAllSessions(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse {
+	user, errFind := s.userRepo.FindByID(ctx, userID)
+	if errFind != nil || user == nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrUserNotFound),
+		}
+	}
+
+	if err := revokeAllSessions(ctx, s.rdRepo, s.cfg, userID); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return internalError()
+	}
+
+	s.audit.Record(ctx, newAuditEvent(models.AuditSessionsRevoked, accountID, userID))
+	return nil
+}
+
+// endSession thu hồi refresh token, mọi access token và xóa thông tin của một phiên
+func endSession(ctx context.Context, rdRepo cache.RedisRepository, userID uuid.UUID, session *cache.Session) error {
+	if session.RefreshToken != "" {
+		if err := rdRepo.RemoveRefreshToken(ctx, userID, session.RefreshToken); err != nil {
+			return err
+		}
+	}
+	if err := rdRepo.DeleteSession(ctx, userID, session.ID); err != nil {
+		return err
+	}
+	if session.AccessJTI != "" {
+		// mọi access token của phiên mang cùng sid, token cấp sau cùng là token hết hạn muộn nhất
+		if err := rdRepo.RevokeSessionAccessTokens(ctx, session.ID, session.AccessExpiresAt); err != nil {
+			return err
+		}
+		publishRevocation(ctx, rdRepo, userID, session.AccessJTI)
+	}
+	return nil
+}
+
+// revokeOtherSessions kết thúc mọi phiên của user trừ phiên hiện tại
+func revokeOtherSessions(ctx context.Context, rdRepo cache.RedisRepository, userID uuid.UUID, currentSessionID string) error {
+	sessions, err := rdRepo.ListSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := range sessions {
+		if sessions[i].ID == currentSessionID {
+			continue
+		}
+		if err := endSession(ctx, rdRepo, userID, &sessions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
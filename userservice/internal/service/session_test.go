@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/internal/cache"
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListSessions(t *testing.T) {
+	userID := uuid.New()
+	now := time.Now()
+	rdRepo := new(MockRedisRepository)
+	rdRepo.On("ListSessions", mock.Anything, userID).Return([]cache.Session{
+		{ID: "old", UserAgent: "Firefox", LastRefreshAt: now.Add(-time.Hour), RefreshToken: "secret"},
+		{ID: "new", UserAgent: "Chrome", LastRefreshAt: now},
+	}, nil)
+
+	service := NewAuthService(new(MockUserRepository), nil, newTestConfig(), rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+	sessions, errList := service.ListSessions(context.Background(), userID, "old")
+
+	require.Nil(t, errList)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "new", sessions[0].Id)
+	assert.False(t, sessions[0].Current)
+	assert.Equal(t, "old", sessions[1].Id)
+	assert.True(t, sessions[1].Current)
+}
+
+func TestRevokeSession(t *testing.T) {
+	userID := uuid.New()
+	accessExpiresAt := time.Now().Add(time.Hour)
+	session := &cache.Session{
+		ID:              "session-1",
+		RefreshToken:    "refresh-token",
+		AccessJTI:       "jti-1",
+		AccessExpiresAt: accessExpiresAt,
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		rdRepo := new(MockRedisRepository)
+		rdRepo.On("GetSession", mock.Anything, userID, "session-1").Return(session, nil)
+		rdRepo.On("RemoveRefreshToken", mock.Anything, userID, "refresh-token").Return(nil)
+		rdRepo.On("DeleteSession", mock.Anything, userID, "session-1").Return(nil)
+		rdRepo.On("RevokeSessionAccessTokens", mock.Anything, "session-1", accessExpiresAt).Return(nil)
+		rdRepo.On("PublishRevocation", mock.Anything, mock.MatchedBy(func(event cache.RevocationEvent) bool {
+			return event.UserID == userID && event.JTI == "jti-1"
+		})).Return(nil)
+
+		service := NewAuthService(new(MockUserRepository), nil, newTestConfig(), rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		errRevoke := service.RevokeSession(context.Background(), userID, "session-1")
+
+		assert.Nil(t, errRevoke)
+		rdRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Not Found", func(t *testing.T) {
+		rdRepo := new(MockRedisRepository)
+		rdRepo.On("GetSession", mock.Anything, userID, "missing").Return(nil, nil)
+
+		service := NewAuthService(new(MockUserRepository), nil, newTestConfig(), rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		errRevoke := service.RevokeSession(context.Background(), userID, "missing")
+
+		assert.Equal(t, http.StatusNotFound, errRevoke.Status)
+		assert.Equal(t, ErrSessionNotFound, errRevoke.Err.Error())
+		rdRepo.AssertNotCalled(t, "DeleteSession", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminRevokeAllSessions(t *testing.T) {
+	adminID := uuid.New()
+	user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		rdRepo := new(MockRedisRepository)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+		rdRepo.On("RevokeAllRefreshTokens", mock.Anything, user.ID).Return(nil)
+		rdRepo.On("RevokeAccessTokensBefore", mock.Anything, user.ID, mock.Anything, time.Hour).Return(nil)
+		rdRepo.On("PublishRevocation", mock.Anything, mock.Anything).Return(nil)
+
+		service := NewAuthService(userRepo, nil, newTestConfig(), rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		errRevoke := service.AdminRevokeAllSessions(context.Background(), adminID, user.ID)
+
+		assert.Nil(t, errRevoke)
+		rdRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - User Not Found", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		rdRepo := new(MockRedisRepository)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(nil, &dto.ServiceResponse{Status: http.StatusNotFound})
+
+		service := NewAuthService(userRepo, nil, newTestConfig(), rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		errRevoke := service.AdminRevokeAllSessions(context.Background(), adminID, user.ID)
+
+		assert.Equal(t, http.StatusNotFound, errRevoke.Status)
+		rdRepo.AssertNotCalled(t, "RevokeAllRefreshTokens", mock.Anything, mock.Anything)
+	})
+}
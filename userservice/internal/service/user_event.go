@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/agris/user-service/config"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/internal/repository"
+)
+
+const (
+	defaultUserEventPollInterval = time.Second
+	defaultUserEventBatchSize    = 500
+)
+
+type UserEventService interface {
+	Watch(ctx context.Context, afterSeq int64, fromNow bool, send func(*models.UserEvent) error) error
+}
+
+type userEventService struct {
+	eventRepo    repository.UserEventRepository
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewUserEventService(eventRepo repository.UserEventRepository, cfg *config.Config) UserEventService {
+	batchSize := cfg.UserEvents.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultUserEventBatchSize
+	}
+	return &userEventService{
+		eventRepo:    eventRepo,
+		pollInterval: orDefault(cfg.UserEvents.PollInterval, defaultUserEventPollInterval),
+		batchSize:    batchSize,
+	}
+}
+
+// Watch gửi các sự kiện có seq lớn hơn afterSeq rồi tiếp tục chờ sự kiện mới tới khi ctx bị hủy.
+// fromNow bỏ qua lịch sử, dùng khi consumer chưa có checkpoint.
+// Sự kiện được đọc từ bảng user_events nên mọi instance đều thấy thay đổi của nhau.
+func (s *userEventService) Watch(ctx context.Context, afterSeq int64, fromNow bool, send func(*models.UserEvent) error) error {
+	if fromNow {
+		latest, err := s.eventRepo.LatestSeq(ctx)
+		if err != nil {
+			return err
+		}
+		afterSeq = latest
+	}
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		events, err := s.eventRepo.ListAfter(ctx, afterSeq, s.batchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		for i := range events {
+			if err := send(&events[i]); err != nil {
+				return err
+			}
+			afterSeq = events[i].Seq
+		}
+		// còn sự kiện chờ gửi thì đọc tiếp ngay
+		if len(events) == s.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
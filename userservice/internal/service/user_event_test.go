@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	models "github.com/agris/user-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockUserEventRepository implements repository.UserEventRepository
+type MockUserEventRepository struct {
+	mu     sync.Mutex
+	events []models.UserEvent
+}
+
+func (m *MockUserEventRepository) append(eventType models.UserEventType) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, models.UserEvent{Seq: int64(len(m.events) + 1), UserID: uuid.New(), Type: eventType})
+}
+
+func (m *MockUserEventRepository) ListAfter(ctx context.Context, afterSeq int64, limit int) ([]models.UserEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var events []models.UserEvent
+	for _, event := range m.events {
+		if event.Seq > afterSeq && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (m *MockUserEventRepository) LatestSeq(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(m.events)), nil
+}
+
+func newTestUserEventService(repo *MockUserEventRepository) UserEventService {
+	cfg := newTestConfig()
+	cfg.UserEvents.PollInterval = 10 * time.Millisecond
+	cfg.UserEvents.BatchSize = 2
+	return NewUserEventService(repo, cfg)
+}
+
+// collect chạy Watch tới khi nhận đủ want sự kiện rồi hủy stream
+func collect(t *testing.T, svc UserEventService, afterSeq int64, fromNow bool, want int) []int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var seqs []int64
+	err := svc.Watch(ctx, afterSeq, fromNow, func(event *models.UserEvent) error {
+		seqs = append(seqs, event.Seq)
+		if len(seqs) == want {
+			cancel()
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, seqs, want, "watch stopped before receiving every event")
+	return seqs
+}
+
+func TestWatchUserEvents(t *testing.T) {
+	t.Run("Success - Resumes After Seq", func(t *testing.T) {
+		repo := new(MockUserEventRepository)
+		for i := 0; i < 5; i++ {
+			repo.append(models.UserEventUpdated)
+		}
+
+		seqs := collect(t, newTestUserEventService(repo), 2, false, 3)
+
+		assert.Equal(t, []int64{3, 4, 5}, seqs)
+	})
+
+	t.Run("Success - Delivers New Events", func(t *testing.T) {
+		repo := new(MockUserEventRepository)
+		repo.append(models.UserEventCreated)
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			repo.append(models.UserEventRoleChanged)
+		}()
+
+		seqs := collect(t, newTestUserEventService(repo), 0, false, 2)
+
+		assert.Equal(t, []int64{1, 2}, seqs)
+	})
+
+	t.Run("Success - From Now Skips History", func(t *testing.T) {
+		repo := new(MockUserEventRepository)
+		repo.append(models.UserEventCreated)
+		repo.append(models.UserEventUpdated)
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			repo.append(models.UserEventDeleted)
+		}()
+
+		seqs := collect(t, newTestUserEventService(repo), 0, true, 1)
+
+		assert.Equal(t, []int64{3}, seqs)
+	})
+
+	t.Run("Error - Send Failed", func(t *testing.T) {
+		repo := new(MockUserEventRepository)
+		repo.append(models.UserEventCreated)
+		sendErr := errors.New("stream closed")
+
+		err := newTestUserEventService(repo).Watch(context.Background(), 0, false, func(*models.UserEvent) error {
+			return sendErr
+		})
+
+		assert.ErrorIs(t, err, sendErr)
+	})
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+)
+
+// DeactivateUser xóa mềm tài khoản, Login sẽ trả về ErrLockedAccount
+func (u *userService) DeactivateUser(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse {
+	if errSelf := checkNotSelf(accountID, userID); errSelf != nil {
+		return errSelf
+	}
+
+	user, errFind := u.userRepo.FindByID(ctx, userID)
+	if errFind != nil || user == nil {
+		return &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrUserNotFound),
+		}
+	}
+
+	if err := u.userRepo.Delete(ctx, userID); err != nil {
+		return internalError()
+	}
+
+	if errRevoke := u.revokeUserTokens(ctx, userID); errRevoke != nil {
+		return errRevoke
+	}
+
+	u.audit.Record(ctx, newAuditEvent(models.AuditUserDeactivated, accountID, userID))
+	return nil
+}
+
+// RestoreUser kích hoạt lại tài khoản đã bị vô hiệu hóa
+func (u *userService) RestoreUser(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse {
+	if errSelf := checkNotSelf(accountID, userID); errSelf != nil {
+		return errSelf
+	}
+
+	if errRestore := u.userRepo.Restore(ctx, userID); errRestore != nil {
+		if errRestore.Status == http.StatusNotFound {
+			return &dto.ServiceResponse{
+				Status: http.StatusNotFound,
+				Err:    errors.New(ErrUserNotDeactivated),
+			}
+		}
+		return errRestore
+	}
+
+	// token cũ có thể vẫn còn hạn nếu bị cấp trước khi vô hiệu hóa, thu hồi để user đăng nhập lại
+	if errRevoke := u.revokeUserTokens(ctx, userID); errRevoke != nil {
+		return errRevoke
+	}
+
+	u.audit.Record(ctx, newAuditEvent(models.AuditUserRestored, accountID, userID))
+	return nil
+}
+
+// PurgeUser xóa vĩnh viễn tài khoản, không thể khôi phục
+func (u *userService) PurgeUser(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse {
+	if errSelf := checkNotSelf(accountID, userID); errSelf != nil {
+		return errSelf
+	}
+
+	if errDelete := u.userRepo.HardDelete(ctx, userID); errDelete != nil {
+		if errDelete.Status == http.StatusNotFound {
+			return &dto.ServiceResponse{
+				Status: http.StatusNotFound,
+				Err:    errors.New(ErrUserNotFound),
+			}
+		}
+		return errDelete
+	}
+
+	if errRevoke := u.revokeUserTokens(ctx, userID); errRevoke != nil {
+		return errRevoke
+	}
+
+	u.audit.Record(ctx, newAuditEvent(models.AuditUserPurged, accountID, userID))
+	return nil
+}
+
+func (u *userService) revokeUserTokens(ctx context.Context, userID uuid.UUID) *dto.ServiceResponse {
+	if err := revokeAllSessions(ctx, u.rdRepo, u.cfg, userID); err != nil {
+		log.Error("[ERROR] : ", err.Error())
+		return internalError()
+	}
+	return nil
+}
+
+// checkNotSelf áp dụng BR-005: admin không thể tự thao tác trên tài khoản của mình
+func checkNotSelf(accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse {
+	if accountID == userID {
+		return &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrCantUpdateOwnAccount),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/agris/user-service/internal/dto"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func expectRevokeAll(rdRepo *MockRedisRepository, userID uuid.UUID) {
+	rdRepo.On("RevokeAllRefreshTokens", mock.Anything, userID).Return(nil)
+	rdRepo.On("RevokeAccessTokensBefore", mock.Anything, userID, mock.Anything, mock.Anything).Return(nil)
+	rdRepo.On("PublishRevocation", mock.Anything, mock.Anything).Return(nil)
+}
+
+func TestDeactivateUser(t *testing.T) {
+	adminID := uuid.New()
+	user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+
+	t.Run("Success - Revokes Tokens", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		rdRepo := new(MockRedisRepository)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+		userRepo.On("Delete", mock.Anything, user.ID).Return(nil)
+		expectRevokeAll(rdRepo, user.ID)
+
+		service := NewUserService(userRepo, rdRepo, new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
+		response := service.DeactivateUser(context.Background(), adminID, user.ID)
+
+		assert.Nil(t, response)
+		userRepo.AssertExpectations(t)
+		rdRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Own Account", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
+		response := service.DeactivateUser(context.Background(), adminID, adminID)
+
+		assert.Equal(t, http.StatusBadRequest, response.Status)
+		assert.Equal(t, ErrCantUpdateOwnAccount, response.Err.Error())
+		userRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - User Not Found", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(nil, &dto.ServiceResponse{Status: http.StatusNotFound})
+
+		service := NewUserService(userRepo, new(MockRedisRepository), new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
+		response := service.DeactivateUser(context.Background(), adminID, user.ID)
+
+		assert.Equal(t, http.StatusNotFound, response.Status)
+		userRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRestoreUser(t *testing.T) {
+	adminID := uuid.New()
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		rdRepo := new(MockRedisRepository)
+		userRepo.On("Restore", mock.Anything, userID).Return(nil)
+		expectRevokeAll(rdRepo, userID)
+
+		service := NewUserService(userRepo, rdRepo, new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
+		response := service.RestoreUser(context.Background(), adminID, userID)
+
+		assert.Nil(t, response)
+		rdRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Not Deactivated", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("Restore", mock.Anything, userID).Return(&dto.ServiceResponse{Status: http.StatusNotFound})
+
+		service := NewUserService(userRepo, new(MockRedisRepository), new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
+		response := service.RestoreUser(context.Background(), adminID, userID)
+
+		assert.Equal(t, http.StatusNotFound, response.Status)
+		assert.Equal(t, ErrUserNotDeactivated, response.Err.Error())
+	})
+}
+
+func TestPurgeUser(t *testing.T) {
+	adminID := uuid.New()
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		rdRepo := new(MockRedisRepository)
+		userRepo.On("HardDelete", mock.Anything, userID).Return(nil)
+		expectRevokeAll(rdRepo, userID)
+
+		service := NewUserService(userRepo, rdRepo, new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
+		response := service.PurgeUser(context.Background(), adminID, userID)
+
+		assert.Nil(t, response)
+		rdRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Own Account", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
+		response := service.PurgeUser(context.Background(), adminID, adminID)
+
+		assert.Equal(t, http.StatusBadRequest, response.Status)
+		userRepo.AssertNotCalled(t, "HardDelete", mock.Anything, mock.Anything)
+	})
+}
@@ -3,28 +3,46 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/agris/user-service/config"
+	"github.com/agris/user-service/internal/cache"
 	"github.com/agris/user-service/internal/dto"
+	"github.com/agris/user-service/internal/mailer"
 	models "github.com/agris/user-service/internal/model"
 	"github.com/agris/user-service/internal/repository"
+	"github.com/agris/user-service/internal/utils"
+	"github.com/gofiber/fiber/v2/log"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
 	CreateUser(ctx context.Context, userRequest *dto.UserRequest) (*dto.RegisterUserRequest, error)
 	GetCurrentUser(ctx context.Context, userID uuid.UUID) (*dto.GetUserResponse, *dto.ServiceResponse)
+	GetPublicUsers(ctx context.Context, userIDs []uuid.UUID) ([]dto.PublicUserResponse, *dto.ServiceResponse)
 	ListUsers(ctx context.Context, pageRequest *dto.PageRequest) (*dto.PageResponse, error)
 	UpdateUserRole(ctx context.Context, updateAccount *dto.UpdateRoleRequest) (*dto.UpdateRoleResponse, *dto.ServiceResponse)
+	VerifyEmail(ctx context.Context, token string) *dto.ServiceResponse
+	ResendVerification(ctx context.Context, request *dto.ResendVerificationRequest) *dto.ServiceResponse
+	UpdateProfile(ctx context.Context, userID uuid.UUID, request *dto.UpdateProfileRequest) (*dto.GetUserResponse, *dto.ServiceResponse)
+	DeactivateUser(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse
+	RestoreUser(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse
+	PurgeUser(ctx context.Context, accountID uuid.UUID, userID uuid.UUID) *dto.ServiceResponse
 }
 
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo   repository.UserRepository
+	rdRepo     cache.RedisRepository
+	verifyRepo cache.EmailVerificationRepository
+	mailer     mailer.Mailer
+	cfg        *config.Config
+	audit      AuditService
 }
 
-func NewUserService(userRepo repository.UserRepository) UserService {
-	return &userService{userRepo: userRepo}
+func NewUserService(userRepo repository.UserRepository, rdRepo cache.RedisRepository, verifyRepo cache.EmailVerificationRepository, mailer mailer.Mailer, cfg *config.Config, audit AuditService) UserService {
+	return &userService{userRepo: userRepo, rdRepo: rdRepo, verifyRepo: verifyRepo, mailer: mailer, cfg: cfg, audit: audit}
 }
 
 func (u *userService) UpdateUserRole(ctx context.Context, updateAccount *dto.UpdateRoleRequest) (*dto.UpdateRoleResponse, *dto.ServiceResponse) {
@@ -54,6 +72,7 @@ func (u *userService) UpdateUserRole(ctx context.Context, updateAccount *dto.Upd
 	}
 
 	// update
+	previousRole := user.Role
 	user.Role = updateAccount.Role
 
 	// save
@@ -62,6 +81,17 @@ func (u *userService) UpdateUserRole(ctx context.Context, updateAccount *dto.Upd
 		return nil, errUpdated
 	}
 
+	event := newAuditEvent(models.AuditRoleUpdated, updateAccount.AccountId, user.ID)
+	event.Before = auditValues(map[string]any{"role": previousRole})
+	event.After = auditValues(map[string]any{"role": userUpdated.Role})
+	u.audit.Record(ctx, event)
+
+	// quyền nằm trong claim perms của access token, thu hồi để token cũ không còn giữ quyền trước đó.
+	// Thu hồi cả khi role không đổi để gọi lại sau lỗi thu hồi vẫn có tác dụng.
+	if errRevoke := u.revokeUserTokens(ctx, user.ID); errRevoke != nil {
+		return nil, errRevoke
+	}
+
 	// response
 	response := dto.UpdateRoleResponse{
 		Id:        userUpdated.ID,
@@ -87,7 +117,7 @@ func (u *userService) CreateUser(ctx context.Context, req *dto.UserRequest) (*dt
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.PasswordHash), bcrypt.DefaultCost)
+	hashedPassword, err := utils.HashPassword(req.PasswordHash)
 	if err != nil {
 		return nil, errors.New(ErrHashPassword)
 	}
@@ -97,7 +127,7 @@ func (u *userService) CreateUser(ctx context.Context, req *dto.UserRequest) (*dt
 		ID:           uuid.New(),
 		Name:         req.Name,
 		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 		Role:         models.RoleUser,
 	}
 
@@ -107,6 +137,15 @@ func (u *userService) CreateUser(ctx context.Context, req *dto.UserRequest) (*dt
 		return nil, err
 	}
 
+	event := newAuditEvent(models.AuditUserRegistered, userCreated.ID, userCreated.ID)
+	event.After = auditValues(map[string]any{"name": userCreated.Name, "email": userCreated.Email, "role": userCreated.Role})
+	u.audit.Record(ctx, event)
+
+	// tài khoản đã được tạo, lỗi gửi email xác minh để người dùng tự gửi lại
+	if errSend := u.sendVerification(ctx, userCreated); errSend != nil {
+		log.Error("[ERROR] : ", errSend.Error())
+	}
+
 	response := dto.RegisterUserRequest{
 		Id:         userCreated.ID,
 		Name:       userCreated.Name,
@@ -139,11 +178,135 @@ func (u *userService) GetCurrentUser(ctx context.Context, userID uuid.UUID) (*dt
 		}
 	}
 
+	return toUserResponse(user), nil
+}
+
+// GetPublicUsers trả về thông tin công khai của nhiều user cho service khác (vd: tên người đánh giá).
+// id trùng được gộp lại, id không tồn tại hoặc đã bị vô hiệu hóa thì không có trong kết quả.
+func (u *userService) GetPublicUsers(ctx context.Context, userIDs []uuid.UUID) ([]dto.PublicUserResponse, *dto.ServiceResponse) {
+	if len(userIDs) > MaxBatchUsers {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrTooManyUsers),
+		}
+	}
+
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	ids := make([]uuid.UUID, 0, len(userIDs))
+	for _, id := range userIDs {
+		if id == uuid.Nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	users, err := u.userRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusInternalServerError,
+			Err:    errors.New(ErrInternalServerError),
+		}
+	}
+
+	response := make([]dto.PublicUserResponse, 0, len(users))
+	for _, user := range users {
+		response = append(response, dto.PublicUserResponse{Id: user.ID, Name: user.Name})
+	}
+	return response, nil
+}
+
+// UpdateProfile cập nhật tên/email của chính user.
+// Đổi email thì phải xác minh lại địa chỉ mới.
+func (u *userService) UpdateProfile(ctx context.Context, userID uuid.UUID, request *dto.UpdateProfileRequest) (*dto.GetUserResponse, *dto.ServiceResponse) {
+	if request == nil || (request.Name == nil && request.Email == nil) {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusBadRequest,
+			Err:    errors.New(ErrInvalidData),
+		}
+	}
+
+	user, errFind := u.userRepo.FindByID(ctx, userID)
+	if errFind != nil {
+		return nil, errFind
+	}
+	if user == nil {
+		return nil, &dto.ServiceResponse{
+			Status: http.StatusNotFound,
+			Err:    errors.New(ErrUserNotFound),
+		}
+	}
+
+	before := map[string]any{"name": user.Name, "email": user.Email}
+	if request.Name != nil {
+		user.Name = *request.Name
+	}
+
+	// đổi chữ hoa/thường vẫn được ghi, nhưng cùng một địa chỉ nên không phải xác minh lại
+	oldEmail := user.Email
+	emailChanged := false
+	if request.Email != nil {
+		email := strings.TrimSpace(*request.Email)
+		if email != user.Email {
+			taken, err := u.userRepo.EmailTaken(ctx, email, user.ID)
+			if err != nil {
+				log.Error("[ERROR] : ", err.Error())
+				return nil, internalError()
+			}
+			if taken {
+				return nil, &dto.ServiceResponse{
+					Status: http.StatusConflict,
+					Err:    errors.New(ErrEmailExists),
+				}
+			}
+			emailChanged = !strings.EqualFold(email, oldEmail)
+			user.Email = email
+			if emailChanged {
+				user.EmailVerifiedAt = nil
+			}
+		}
+	}
+
+	userUpdated, errUpdate := u.userRepo.Update(ctx, user)
+	if errUpdate != nil {
+		// trường hợp hai request cùng đổi sang một email
+		if errUpdate.Status == http.StatusConflict {
+			return nil, &dto.ServiceResponse{
+				Status: http.StatusConflict,
+				Err:    errors.New(ErrEmailExists),
+			}
+		}
+		return nil, errUpdate
+	}
+
+	event := newAuditEvent(models.AuditProfileUpdated, user.ID, user.ID)
+	event.Before = auditValues(before)
+	event.After = auditValues(map[string]any{"name": userUpdated.Name, "email": userUpdated.Email})
+	u.audit.Record(ctx, event)
+
+	if emailChanged {
+		if errSend := u.sendVerification(ctx, userUpdated); errSend != nil {
+			log.Error("[ERROR] : ", errSend.Error())
+		}
+		sendMailAsync(u.mailer, mailer.Message{
+			To:      oldEmail,
+			Subject: "Email tài khoản đã thay đổi",
+			Body: fmt.Sprintf("Xin chào %s,\n\nEmail đăng nhập của bạn đã được đổi thành %s.\nNếu không phải bạn thực hiện, hãy liên hệ quản trị viên ngay.",
+				userUpdated.Name, userUpdated.Email),
+		})
+	}
+
+	return toUserResponse(userUpdated), nil
+}
+
+func toUserResponse(user *models.User) *dto.GetUserResponse {
 	response := dto.GetUserResponse{
-		Id:         user.ID,
-		Name:       user.Name,
-		Email:      user.Email,
-		Created_at: user.CreatedAt,
+		Id:            user.ID,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		MFAEnabled:    user.IsMFAEnabled(),
+		Created_at:    user.CreatedAt,
 	}
 
 	if !user.DeletedAt.Valid {
@@ -152,7 +315,7 @@ func (u *userService) GetCurrentUser(ctx context.Context, userID uuid.UUID) (*dt
 		response.Status = dto.StatusInactive
 	}
 
-	return &response, nil
+	return &response
 }
 
 func (s *userService) ListUsers(ctx context.Context, pageRequest *dto.PageRequest) (*dto.PageResponse, error) {
@@ -7,14 +7,30 @@ import (
 	"testing"
 	"time"
 
+	"github.com/agris/user-service/config"
+	"github.com/agris/user-service/internal/cache"
 	"github.com/agris/user-service/internal/dto"
 	models "github.com/agris/user-service/internal/model"
+	"github.com/agris/user-service/pkg/jwtMg"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// MockPermissionRepository implements repository.PermissionRepository
+type MockPermissionRepository struct {
+	permissions map[models.Role][]models.Permission
+	err         error
+}
+
+func (m *MockPermissionRepository) ListByRole(ctx context.Context, role models.Role) ([]models.Permission, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.permissions[role], nil
+}
+
 // MockUserRepository implements repository.UserRepository
 type MockUserRepository struct {
 	mock.Mock
@@ -28,6 +44,14 @@ func (m *MockUserRepository) FindByID(ctx context.Context, userID uuid.UUID) (*m
 	return args.Get(0).(*models.User), nil
 }
 
+func (m *MockUserRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.User, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
 func (m *MockUserRepository) FindByEmail(ctx context.Context, email string, delete bool) (*models.User, *dto.ServiceResponse) {
 	args := m.Called(ctx, email, delete)
 	if args.Get(0) == nil {
@@ -52,6 +76,16 @@ func (m *MockUserRepository) Update(ctx context.Context, user *models.User) (*mo
 	return args.Get(0).(*models.User), nil
 }
 
+func (m *MockUserRepository) EmailTaken(ctx context.Context, email string, excludeID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, email, excludeID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) SwapRecoveryCodes(ctx context.Context, id uuid.UUID, current string, next string) (bool, error) {
+	args := m.Called(ctx, id, current, next)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockUserRepository) List(ctx context.Context, pageRequest *dto.PageRequest) (*dto.PageResponse, error) {
 	args := m.Called(ctx, pageRequest)
 	if args.Get(0) == nil {
@@ -65,6 +99,173 @@ func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) Restore(ctx context.Context, id uuid.UUID) *dto.ServiceResponse {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*dto.ServiceResponse)
+}
+
+func (m *MockUserRepository) HardDelete(ctx context.Context, id uuid.UUID) *dto.ServiceResponse {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*dto.ServiceResponse)
+}
+
+// MockRedisRepository implements cache.RedisRepository
+type MockRedisRepository struct {
+	mock.Mock
+}
+
+func (m *MockRedisRepository) SaveRefreshToken(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, token, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockRedisRepository) RotateRefreshToken(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) (bool, error) {
+	args := m.Called(ctx, userID, token, expiresAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRedisRepository) IsRefreshTokenUsed(ctx context.Context, userID uuid.UUID, token string) (bool, error) {
+	args := m.Called(ctx, userID, token)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRedisRepository) RemoveRefreshToken(ctx context.Context, userID uuid.UUID, token string) error {
+	args := m.Called(ctx, userID, token)
+	return args.Error(0)
+}
+
+func (m *MockRedisRepository) RevokeAllRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRedisRepository) DenyAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockRedisRepository) RevokeAccessTokensBefore(ctx context.Context, userID uuid.UUID, at time.Time, ttl time.Duration) error {
+	args := m.Called(ctx, userID, at, ttl)
+	return args.Error(0)
+}
+
+func (m *MockRedisRepository) RevokeSessionAccessTokens(ctx context.Context, sessionID string, expiresAt time.Time) error {
+	args := m.Called(ctx, sessionID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockRedisRepository) IsAccessTokenRevoked(ctx context.Context, userID uuid.UUID, jti string, sessionID string, issuedAt time.Time) (bool, error) {
+	args := m.Called(ctx, userID, jti, sessionID, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRedisRepository) PublishRevocation(ctx context.Context, event cache.RevocationEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockRedisRepository) SaveSession(ctx context.Context, userID uuid.UUID, session *cache.Session) error {
+	args := m.Called(ctx, userID, session)
+	return args.Error(0)
+}
+
+func (m *MockRedisRepository) GetSession(ctx context.Context, userID uuid.UUID, sessionID string) (*cache.Session, error) {
+	args := m.Called(ctx, userID, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cache.Session), args.Error(1)
+}
+
+func (m *MockRedisRepository) ListSessions(ctx context.Context, userID uuid.UUID) ([]cache.Session, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]cache.Session), args.Error(1)
+}
+
+func (m *MockRedisRepository) DeleteSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+// MockLoginAttemptRepository implements cache.LoginAttemptRepository
+type MockLoginAttemptRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptRepository) IncrementAccountFailures(ctx context.Context, userID uuid.UUID, window time.Duration) (int64, error) {
+	args := m.Called(ctx, userID, window)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) IncrementIPFailures(ctx context.Context, ip string, window time.Duration) (int64, error) {
+	args := m.Called(ctx, ip, window)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) GetIPFailures(ctx context.Context, ip string) (int64, time.Duration, error) {
+	args := m.Called(ctx, ip)
+	return args.Get(0).(int64), args.Get(1).(time.Duration), args.Error(2)
+}
+
+func (m *MockLoginAttemptRepository) NextLockoutLevel(ctx context.Context, userID uuid.UUID, resetAfter time.Duration) (int64, error) {
+	args := m.Called(ctx, userID, resetAfter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) LockAccount(ctx context.Context, userID uuid.UUID, lockFor time.Duration) error {
+	args := m.Called(ctx, userID, lockFor)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptRepository) GetAccountLock(ctx context.Context, userID uuid.UUID) (time.Duration, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) ResetAccount(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockEmailVerificationRepository implements cache.EmailVerificationRepository
+type MockEmailVerificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationRepository) SaveVerificationToken(ctx context.Context, userID uuid.UUID, tokenHash string, ttl time.Duration) error {
+	args := m.Called(ctx, userID, tokenHash, ttl)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationRepository) ConsumeVerificationToken(ctx context.Context, tokenHash string) (uuid.UUID, bool, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(uuid.UUID), args.Bool(1), args.Error(2)
+}
+
+func (m *MockEmailVerificationRepository) AcquireResendSlot(ctx context.Context, userID uuid.UUID, interval time.Duration) (time.Duration, error) {
+	args := m.Called(ctx, userID, interval)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func newTestConfig() *config.Config {
+	return &config.Config{
+		JWT: config.JWTConfig{
+			Secret:        "test-secret",
+			AccessExpiry:  time.Hour,
+			RefreshExpiry: 24 * time.Hour,
+		},
+	}
+}
+
 // === TESTS ===
 
 func TestCreateUser(t *testing.T) {
@@ -185,7 +386,9 @@ func TestCreateUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockUserRepository)
-			service := NewUserService(mockRepo)
+			verifyRepo := new(MockEmailVerificationRepository)
+			mockMailer := newMockMailer()
+			service := NewUserService(mockRepo, new(MockRedisRepository), verifyRepo, mockMailer, newTestConfig(), new(MockAuditService))
 
 			if tt.shouldCallFindBy {
 				if tt.existingUser != nil {
@@ -203,11 +406,21 @@ func TestCreateUser(t *testing.T) {
 				})).Return(tt.createdUser, tt.createErr)
 			}
 
+			if tt.expectSuccess {
+				verifyRepo.On("SaveVerificationToken", mock.Anything, tt.createdUser.ID, mock.Anything, mock.Anything).Return(nil)
+			}
+
 			result, err := service.CreateUser(context.Background(), tt.request)
 
 			if tt.expectSuccess {
 				assert.NoError(t, err)
 				assert.NotNil(t, result)
+				select {
+				case msg := <-mockMailer.sent:
+					assert.Equal(t, tt.createdUser.Email, msg.To)
+				case <-time.After(time.Second):
+					t.Fatal("verification email was not sent")
+				}
 				assert.Equal(t, tt.createdUser.ID, result.Id)
 				assert.Equal(t, tt.createdUser.Email, result.Email)
 				assert.Equal(t, tt.createdUser.Name, result.Name)
@@ -276,7 +489,7 @@ func TestGetCurrentUser(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockUserRepository)
-			service := NewUserService(mockRepo)
+			service := NewUserService(mockRepo, new(MockRedisRepository), new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
 
 			if tt.shouldCallRepo {
 				mockRepo.On("FindByID", mock.Anything, tt.userID).
@@ -309,6 +522,51 @@ func TestGetCurrentUser(t *testing.T) {
 	}
 }
 
+func TestGetPublicUsers(t *testing.T) {
+	newService := func(repo *MockUserRepository) UserService {
+		return NewUserService(repo, new(MockRedisRepository), new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
+	}
+
+	t.Run("Success - Deduplicates Ids", func(t *testing.T) {
+		first, second := uuid.New(), uuid.New()
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByIDs", mock.Anything, []uuid.UUID{first, second}).
+			Return([]models.User{{ID: first, Name: "First", Email: "first@example.com"}}, nil)
+
+		result, response := newService(mockRepo).GetPublicUsers(context.Background(), []uuid.UUID{first, second, first, uuid.Nil})
+
+		assert.Nil(t, response)
+		assert.Equal(t, []dto.PublicUserResponse{{Id: first, Name: "First"}}, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Too Many Ids", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		ids := make([]uuid.UUID, MaxBatchUsers+1)
+		for i := range ids {
+			ids[i] = uuid.New()
+		}
+
+		result, response := newService(mockRepo).GetPublicUsers(context.Background(), ids)
+
+		assert.Nil(t, result)
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusBadRequest, response.Status)
+		mockRepo.AssertNotCalled(t, "FindByIDs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - Repository Failure", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByIDs", mock.Anything, mock.Anything).Return(nil, errors.New("db down"))
+
+		result, response := newService(mockRepo).GetPublicUsers(context.Background(), []uuid.UUID{uuid.New()})
+
+		assert.Nil(t, result)
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusInternalServerError, response.Status)
+	})
+}
+
 func TestUpdateUserRole(t *testing.T) {
 	userID := uuid.New()
 	accountID := uuid.New()
@@ -405,7 +663,9 @@ func TestUpdateUserRole(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockUserRepository)
-			service := NewUserService(mockRepo)
+			rdRepo := new(MockRedisRepository)
+			cfg := newTestConfig()
+			service := NewUserService(mockRepo, rdRepo, new(MockEmailVerificationRepository), newMockMailer(), cfg, new(MockAuditService))
 
 			if tt.shouldCallFind {
 				if tt.mockFindErr != nil {
@@ -421,6 +681,12 @@ func TestUpdateUserRole(t *testing.T) {
 				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
 					return u.ID == tt.request.UserId && u.Role == tt.request.Role
 				})).Return(tt.mockUpdatedUser, tt.mockUpdateResp)
+				// token đang có vẫn mang quyền cũ nên bị thu hồi
+				rdRepo.On("RevokeAllRefreshTokens", mock.Anything, tt.request.UserId).Return(nil)
+				rdRepo.On("RevokeAccessTokensBefore", mock.Anything, tt.request.UserId, mock.Anything, cfg.JWT.AccessExpiry).Return(nil)
+				rdRepo.On("PublishRevocation", mock.Anything, mock.MatchedBy(func(event cache.RevocationEvent) bool {
+					return event.UserID == tt.request.UserId
+				})).Return(nil)
 			}
 
 			result, response := service.UpdateUserRole(context.Background(), tt.request)
@@ -439,6 +705,7 @@ func TestUpdateUserRole(t *testing.T) {
 			}
 
 			mockRepo.AssertExpectations(t)
+			rdRepo.AssertExpectations(t)
 		})
 	}
 }
@@ -545,7 +812,7 @@ func TestListUsers(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockUserRepository)
-			service := NewUserService(mockRepo)
+			service := NewUserService(mockRepo, new(MockRedisRepository), new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
 
 			if tt.shouldCallRepo {
 				mockRepo.On("List", mock.Anything, mock.MatchedBy(func(req *dto.PageRequest) bool {
@@ -586,3 +853,627 @@ func TestPasswordHashing(t *testing.T) {
 	err = bcrypt.CompareHashAndPassword(hashedPassword, []byte(password))
 	assert.NoError(t, err)
 }
+
+func TestRefreshToken(t *testing.T) {
+	cfg := newTestConfig()
+	jwtManager, err := jwtMg.NewJWTManager(cfg)
+	assert.NoError(t, err)
+	user := &models.User{
+		ID:    uuid.New(),
+		Email: "john@example.com",
+		Role:  models.RoleUser,
+	}
+	refreshToken, err := jwtManager.GenerateRefreshToken(user)
+	assert.NoError(t, err)
+	accessToken, err := jwtManager.GenerateAccessToken(user, cfg.JWT.AccessExpiry)
+	assert.NoError(t, err)
+	sessionRefreshToken, err := jwtManager.GenerateRefreshToken(user, jwtMg.WithSessionID("session-1"))
+	assert.NoError(t, err)
+	sessionCreatedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name           string
+		request        *dto.RefreshTokenRequest
+		setup          func(rdRepo *MockRedisRepository, userRepo *MockUserRepository)
+		expectedErr    string
+		expectedStatus int
+	}{
+		{
+			name:    "Success - Rotate Token",
+			request: &dto.RefreshTokenRequest{RefreshToken: refreshToken.Token},
+			setup: func(rdRepo *MockRedisRepository, userRepo *MockUserRepository) {
+				rdRepo.On("RotateRefreshToken", mock.Anything, user.ID, refreshToken.Token, mock.Anything).Return(true, nil)
+				userRepo.On("FindByID", mock.Anything, user.ID).Return(user, (*dto.ServiceResponse)(nil))
+				rdRepo.On("SaveRefreshToken", mock.Anything, user.ID, mock.MatchedBy(func(token string) bool {
+					return token != refreshToken.Token
+				}), mock.Anything).Return(nil)
+				rdRepo.On("SaveSession", mock.Anything, user.ID, mock.Anything).Return(nil)
+			},
+		},
+		{
+			name:    "Success - Keeps Session",
+			request: &dto.RefreshTokenRequest{RefreshToken: sessionRefreshToken.Token, IP: "10.0.0.2"},
+			setup: func(rdRepo *MockRedisRepository, userRepo *MockUserRepository) {
+				rdRepo.On("RotateRefreshToken", mock.Anything, user.ID, sessionRefreshToken.Token, mock.Anything).Return(true, nil)
+				userRepo.On("FindByID", mock.Anything, user.ID).Return(user, (*dto.ServiceResponse)(nil))
+				rdRepo.On("GetSession", mock.Anything, user.ID, "session-1").Return(&cache.Session{
+					ID:        "session-1",
+					UserAgent: "Firefox",
+					IP:        "10.0.0.1",
+					CreatedAt: sessionCreatedAt,
+				}, nil)
+				rdRepo.On("SaveRefreshToken", mock.Anything, user.ID, mock.Anything, mock.Anything).Return(nil)
+				rdRepo.On("SaveSession", mock.Anything, user.ID, mock.MatchedBy(func(session *cache.Session) bool {
+					return session.ID == "session-1" && session.UserAgent == "Firefox" && session.IP == "10.0.0.2" &&
+						session.CreatedAt.Equal(sessionCreatedAt) && session.RefreshToken != sessionRefreshToken.Token
+				})).Return(nil)
+			},
+		},
+		{
+			name:           "Error - Empty Token",
+			request:        &dto.RefreshTokenRequest{},
+			setup:          func(rdRepo *MockRedisRepository, userRepo *MockUserRepository) {},
+			expectedErr:    ErrInvalidData,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Error - Malformed Token",
+			request:        &dto.RefreshTokenRequest{RefreshToken: "not-a-jwt"},
+			setup:          func(rdRepo *MockRedisRepository, userRepo *MockUserRepository) {},
+			expectedErr:    ErrRefreshTokenInvalid,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Error - Access Token Used As Refresh Token",
+			request:        &dto.RefreshTokenRequest{RefreshToken: accessToken.Token},
+			setup:          func(rdRepo *MockRedisRepository, userRepo *MockUserRepository) {},
+			expectedErr:    ErrRefreshTokenInvalid,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:    "Error - Reused Token Revokes Family",
+			request: &dto.RefreshTokenRequest{RefreshToken: refreshToken.Token},
+			setup: func(rdRepo *MockRedisRepository, userRepo *MockUserRepository) {
+				rdRepo.On("RotateRefreshToken", mock.Anything, user.ID, refreshToken.Token, mock.Anything).Return(false, nil)
+				rdRepo.On("IsRefreshTokenUsed", mock.Anything, user.ID, refreshToken.Token).Return(true, nil)
+				rdRepo.On("RevokeAllRefreshTokens", mock.Anything, user.ID).Return(nil)
+				rdRepo.On("RevokeAccessTokensBefore", mock.Anything, user.ID, mock.Anything, cfg.JWT.AccessExpiry).Return(nil)
+				rdRepo.On("PublishRevocation", mock.Anything, mock.MatchedBy(func(event cache.RevocationEvent) bool {
+					return event.UserID == user.ID && event.JTI == ""
+				})).Return(nil)
+			},
+			expectedErr:    ErrRefreshTokenReused,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:    "Error - Unknown Token",
+			request: &dto.RefreshTokenRequest{RefreshToken: refreshToken.Token},
+			setup: func(rdRepo *MockRedisRepository, userRepo *MockUserRepository) {
+				rdRepo.On("RotateRefreshToken", mock.Anything, user.ID, refreshToken.Token, mock.Anything).Return(false, nil)
+				rdRepo.On("IsRefreshTokenUsed", mock.Anything, user.ID, refreshToken.Token).Return(false, nil)
+			},
+			expectedErr:    ErrRefreshTokenInvalid,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(MockUserRepository)
+			rdRepo := new(MockRedisRepository)
+			tt.setup(rdRepo, userRepo)
+
+			service := NewAuthService(userRepo, jwtManager, cfg, rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+			result, response := service.RefreshToken(context.Background(), tt.request)
+
+			if tt.expectedErr != "" {
+				assert.Nil(t, result)
+				assert.NotNil(t, response)
+				assert.Equal(t, tt.expectedStatus, response.Status)
+				assert.Contains(t, response.Err.Error(), tt.expectedErr)
+				rdRepo.AssertNotCalled(t, "SaveRefreshToken")
+			} else {
+				assert.Nil(t, response)
+				assert.NotNil(t, result)
+				assert.NotEmpty(t, result.AccessToken)
+				assert.NotEqual(t, refreshToken.Token, result.RefreshToken)
+				assert.Equal(t, user.ID, result.User.Id)
+			}
+
+			rdRepo.AssertExpectations(t)
+			userRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestVerifyAccessToken(t *testing.T) {
+	cfg := newTestConfig()
+	jwtManager, err := jwtMg.NewJWTManager(cfg)
+	assert.NoError(t, err)
+	user := &models.User{
+		ID:    uuid.New(),
+		Email: "john@example.com",
+		Role:  models.RoleUser,
+	}
+	accessToken, err := jwtManager.GenerateAccessToken(user, cfg.JWT.AccessExpiry)
+	assert.NoError(t, err)
+	refreshToken, err := jwtManager.GenerateRefreshToken(user)
+	assert.NoError(t, err)
+
+	// AuthMiddleware.Authorize (/users/me), AuthInterceptor và RPC Authenticate
+	// đều xác thực bearer token qua VerifyAccessToken
+	tests := []struct {
+		name        string
+		token       string
+		revoked     bool
+		revokedErr  error
+		shouldCheck bool
+		expectedErr error
+	}{
+		{
+			name:        "Success - Valid Token",
+			token:       accessToken.Token,
+			shouldCheck: true,
+		},
+		{
+			name:        "Error - Denylisted Token",
+			token:       accessToken.Token,
+			revoked:     true,
+			shouldCheck: true,
+			expectedErr: jwtMg.ErrRevokedToken,
+		},
+		{
+			name:        "Error - Redis Unavailable",
+			token:       accessToken.Token,
+			revokedErr:  errors.New("redis down"),
+			shouldCheck: true,
+			expectedErr: jwtMg.ErrInvalidToken,
+		},
+		{
+			name:        "Error - Refresh Token Used As Bearer",
+			token:       refreshToken.Token,
+			shouldCheck: false,
+			expectedErr: jwtMg.ErrTokenType,
+		},
+		{
+			name:        "Error - Malformed Token",
+			token:       "not-a-jwt",
+			shouldCheck: false,
+			expectedErr: jwtMg.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rdRepo := new(MockRedisRepository)
+			if tt.shouldCheck {
+				rdRepo.On("IsAccessTokenRevoked", mock.Anything, user.ID, accessToken.JTI, "", mock.Anything).
+					Return(tt.revoked, tt.revokedErr)
+			}
+
+			service := NewAuthService(new(MockUserRepository), jwtManager, cfg, rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+			claims, err := service.VerifyAccessToken(context.Background(), tt.token)
+
+			if tt.expectedErr != nil {
+				assert.Nil(t, claims)
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, user.ID, claims.UserID)
+			}
+
+			rdRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Error - Revoked Session", func(t *testing.T) {
+		sessionToken, err := jwtManager.GenerateAccessToken(user, cfg.JWT.AccessExpiry, jwtMg.WithSessionID("session-1"))
+		assert.NoError(t, err)
+
+		rdRepo := new(MockRedisRepository)
+		rdRepo.On("IsAccessTokenRevoked", mock.Anything, user.ID, sessionToken.JTI, "session-1", mock.Anything).Return(true, nil)
+
+		service := NewAuthService(new(MockUserRepository), jwtManager, cfg, rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		claims, err := service.VerifyAccessToken(context.Background(), sessionToken.Token)
+
+		assert.Nil(t, claims)
+		assert.ErrorIs(t, err, jwtMg.ErrRevokedToken)
+		rdRepo.AssertExpectations(t)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	cfg := newTestConfig()
+	jwtManager, err := jwtMg.NewJWTManager(cfg)
+	assert.NoError(t, err)
+	user := &models.User{
+		ID:    uuid.New(),
+		Email: "john@example.com",
+		Role:  models.RoleUser,
+	}
+	accessToken, err := jwtManager.GenerateAccessToken(user, cfg.JWT.AccessExpiry)
+	assert.NoError(t, err)
+	claims, err := jwtManager.ValidateAccessToken(accessToken.Token)
+	assert.NoError(t, err)
+
+	t.Run("Success - Current Session", func(t *testing.T) {
+		rdRepo := new(MockRedisRepository)
+		rdRepo.On("RemoveRefreshToken", mock.Anything, user.ID, "refresh-token").Return(nil)
+		rdRepo.On("DenyAccessToken", mock.Anything, accessToken.JTI, mock.Anything).Return(nil)
+		rdRepo.On("PublishRevocation", mock.Anything, mock.MatchedBy(func(event cache.RevocationEvent) bool {
+			return event.UserID == user.ID && event.JTI == accessToken.JTI
+		})).Return(nil)
+
+		service := NewAuthService(new(MockUserRepository), jwtManager, cfg, rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response := service.Logout(context.Background(), claims, &dto.LogoutRequest{RefreshToken: "refresh-token"})
+
+		assert.Nil(t, response)
+		rdRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - Without Refresh Token", func(t *testing.T) {
+		rdRepo := new(MockRedisRepository)
+		rdRepo.On("DenyAccessToken", mock.Anything, accessToken.JTI, mock.Anything).Return(nil)
+		rdRepo.On("PublishRevocation", mock.Anything, mock.MatchedBy(func(event cache.RevocationEvent) bool {
+			return event.UserID == user.ID && event.JTI == accessToken.JTI
+		})).Return(nil)
+
+		service := NewAuthService(new(MockUserRepository), jwtManager, cfg, rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response := service.Logout(context.Background(), claims, &dto.LogoutRequest{})
+
+		assert.Nil(t, response)
+		rdRepo.AssertNotCalled(t, "RemoveRefreshToken")
+		rdRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - Revokes Whole Session", func(t *testing.T) {
+		sessionToken, err := jwtManager.GenerateAccessToken(user, cfg.JWT.AccessExpiry, jwtMg.WithSessionID("session-1"))
+		assert.NoError(t, err)
+		sessionClaims, err := jwtManager.ValidateAccessToken(sessionToken.Token)
+		assert.NoError(t, err)
+		// access token cấp sau cùng cho phiên hết hạn muộn hơn token dùng để đăng xuất
+		latestExpiresAt := sessionClaims.ExpiresAt.Time.Add(time.Minute)
+
+		rdRepo := new(MockRedisRepository)
+		rdRepo.On("GetSession", mock.Anything, user.ID, "session-1").Return(&cache.Session{
+			ID:              "session-1",
+			RefreshToken:    "refresh-token",
+			AccessExpiresAt: latestExpiresAt,
+		}, nil)
+		rdRepo.On("RemoveRefreshToken", mock.Anything, user.ID, "refresh-token").Return(nil)
+		rdRepo.On("DeleteSession", mock.Anything, user.ID, "session-1").Return(nil)
+		rdRepo.On("RevokeSessionAccessTokens", mock.Anything, "session-1", latestExpiresAt).Return(nil)
+		rdRepo.On("DenyAccessToken", mock.Anything, sessionToken.JTI, mock.Anything).Return(nil)
+		rdRepo.On("PublishRevocation", mock.Anything, mock.Anything).Return(nil)
+
+		service := NewAuthService(new(MockUserRepository), jwtManager, cfg, rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response := service.Logout(context.Background(), sessionClaims, &dto.LogoutRequest{})
+
+		assert.Nil(t, response)
+		rdRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Denylist Fails", func(t *testing.T) {
+		rdRepo := new(MockRedisRepository)
+		rdRepo.On("DenyAccessToken", mock.Anything, accessToken.JTI, mock.Anything).Return(errors.New("redis down"))
+
+		service := NewAuthService(new(MockUserRepository), jwtManager, cfg, rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response := service.Logout(context.Background(), claims, &dto.LogoutRequest{})
+
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusInternalServerError, response.Status)
+	})
+
+	t.Run("Success - All Sessions", func(t *testing.T) {
+		rdRepo := new(MockRedisRepository)
+		rdRepo.On("RevokeAllRefreshTokens", mock.Anything, user.ID).Return(nil)
+		rdRepo.On("RevokeAccessTokensBefore", mock.Anything, user.ID, mock.Anything, cfg.JWT.AccessExpiry).Return(nil)
+		rdRepo.On("DenyAccessToken", mock.Anything, accessToken.JTI, mock.Anything).Return(nil)
+		rdRepo.On("PublishRevocation", mock.Anything, mock.MatchedBy(func(event cache.RevocationEvent) bool {
+			return event.UserID == user.ID && event.JTI == ""
+		})).Return(nil)
+
+		service := NewAuthService(new(MockUserRepository), jwtManager, cfg, rdRepo, new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response := service.LogoutAll(context.Background(), claims)
+
+		assert.Nil(t, response)
+		rdRepo.AssertExpectations(t)
+	})
+}
+
+func TestLogin(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Security.Login = config.LoginProtectionConfig{
+		MaxAttempts:        3,
+		IPMaxAttempts:      10,
+		AttemptWindow:      15 * time.Minute,
+		LockoutDuration:    time.Minute,
+		MaxLockoutDuration: time.Hour,
+		LockoutResetAfter:  24 * time.Hour,
+	}
+	jwtManager, err := jwtMg.NewJWTManager(cfg)
+	assert.NoError(t, err)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &models.User{
+		ID:           uuid.New(),
+		Email:        "john@example.com",
+		PasswordHash: string(hashedPassword),
+		Role:         models.RoleUser,
+	}
+	const ip = "10.0.0.1"
+
+	tests := []struct {
+		name               string
+		password           string
+		setup              func(userRepo *MockUserRepository, rdRepo *MockRedisRepository, attemptRepo *MockLoginAttemptRepository)
+		expectedStatus     int
+		expectedErr        string
+		expectedRetryAfter time.Duration
+	}{
+		{
+			name:     "Success - Resets Failures",
+			password: "password123",
+			setup: func(userRepo *MockUserRepository, rdRepo *MockRedisRepository, attemptRepo *MockLoginAttemptRepository) {
+				attemptRepo.On("GetIPFailures", mock.Anything, ip).Return(int64(2), 10*time.Minute, nil)
+				userRepo.On("FindByEmail", mock.Anything, user.Email, true).Return(user, nil)
+				attemptRepo.On("GetAccountLock", mock.Anything, user.ID).Return(time.Duration(0), nil)
+				attemptRepo.On("ResetAccount", mock.Anything, user.ID).Return(nil)
+				rdRepo.On("SaveRefreshToken", mock.Anything, user.ID, mock.Anything, mock.Anything).Return(nil)
+				rdRepo.On("SaveSession", mock.Anything, user.ID, mock.MatchedBy(func(session *cache.Session) bool {
+					return session.ID != "" && session.IP == ip && session.AccessJTI != ""
+				})).Return(nil)
+				rdRepo.On("SaveSession", mock.Anything, user.ID, mock.Anything).Return(nil)
+			},
+		},
+		{
+			name:     "Error - Wrong Password Below Limit",
+			password: "wrong",
+			setup: func(userRepo *MockUserRepository, rdRepo *MockRedisRepository, attemptRepo *MockLoginAttemptRepository) {
+				attemptRepo.On("GetIPFailures", mock.Anything, ip).Return(int64(0), time.Duration(0), nil)
+				userRepo.On("FindByEmail", mock.Anything, user.Email, true).Return(user, nil)
+				attemptRepo.On("GetAccountLock", mock.Anything, user.ID).Return(time.Duration(0), nil)
+				attemptRepo.On("IncrementIPFailures", mock.Anything, ip, 15*time.Minute).Return(int64(1), nil)
+				attemptRepo.On("IncrementAccountFailures", mock.Anything, user.ID, 15*time.Minute).Return(int64(2), nil)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedErr:    ErrPasswordNotMatch,
+		},
+		{
+			name:     "Error - Wrong Password Locks Account",
+			password: "wrong",
+			setup: func(userRepo *MockUserRepository, rdRepo *MockRedisRepository, attemptRepo *MockLoginAttemptRepository) {
+				attemptRepo.On("GetIPFailures", mock.Anything, ip).Return(int64(0), time.Duration(0), nil)
+				userRepo.On("FindByEmail", mock.Anything, user.Email, true).Return(user, nil)
+				attemptRepo.On("GetAccountLock", mock.Anything, user.ID).Return(time.Duration(0), nil)
+				attemptRepo.On("IncrementIPFailures", mock.Anything, ip, 15*time.Minute).Return(int64(3), nil)
+				attemptRepo.On("IncrementAccountFailures", mock.Anything, user.ID, 15*time.Minute).Return(int64(3), nil)
+				attemptRepo.On("NextLockoutLevel", mock.Anything, user.ID, 24*time.Hour).Return(int64(3), nil)
+				attemptRepo.On("LockAccount", mock.Anything, user.ID, 4*time.Minute).Return(nil)
+			},
+			expectedStatus:     http.StatusTooManyRequests,
+			expectedErr:        ErrTooManyAttempts,
+			expectedRetryAfter: 4 * time.Minute,
+		},
+		{
+			name:     "Error - Account Locked",
+			password: "password123",
+			setup: func(userRepo *MockUserRepository, rdRepo *MockRedisRepository, attemptRepo *MockLoginAttemptRepository) {
+				attemptRepo.On("GetIPFailures", mock.Anything, ip).Return(int64(0), time.Duration(0), nil)
+				userRepo.On("FindByEmail", mock.Anything, user.Email, true).Return(user, nil)
+				attemptRepo.On("GetAccountLock", mock.Anything, user.ID).Return(30*time.Second, nil)
+			},
+			expectedStatus:     http.StatusTooManyRequests,
+			expectedErr:        ErrTooManyAttempts,
+			expectedRetryAfter: 30 * time.Second,
+		},
+		{
+			name:     "Error - IP Blocked",
+			password: "password123",
+			setup: func(userRepo *MockUserRepository, rdRepo *MockRedisRepository, attemptRepo *MockLoginAttemptRepository) {
+				attemptRepo.On("GetIPFailures", mock.Anything, ip).Return(int64(10), 5*time.Minute, nil)
+			},
+			expectedStatus:     http.StatusTooManyRequests,
+			expectedErr:        ErrTooManyAttempts,
+			expectedRetryAfter: 5 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(MockUserRepository)
+			rdRepo := new(MockRedisRepository)
+			attemptRepo := new(MockLoginAttemptRepository)
+			tt.setup(userRepo, rdRepo, attemptRepo)
+
+			service := NewAuthService(userRepo, jwtManager, cfg, rdRepo, attemptRepo, new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+			response, errResponse := service.Login(context.Background(), &dto.LoginRequest{
+				Email:    user.Email,
+				Password: tt.password,
+				IP:       ip,
+			})
+
+			if tt.expectedErr != "" {
+				assert.Nil(t, response)
+				assert.NotNil(t, errResponse)
+				assert.Equal(t, tt.expectedStatus, errResponse.Status)
+				assert.Equal(t, tt.expectedErr, errResponse.Err.Error())
+				assert.Equal(t, tt.expectedRetryAfter, errResponse.RetryAfter)
+			} else {
+				assert.Nil(t, errResponse)
+				assert.NotNil(t, response)
+			}
+			userRepo.AssertExpectations(t)
+			rdRepo.AssertExpectations(t)
+			attemptRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	assert.Equal(t, time.Minute, lockoutDuration(time.Minute, time.Hour, 1))
+	assert.Equal(t, 2*time.Minute, lockoutDuration(time.Minute, time.Hour, 2))
+	assert.Equal(t, 32*time.Minute, lockoutDuration(time.Minute, time.Hour, 6))
+	assert.Equal(t, time.Hour, lockoutDuration(time.Minute, time.Hour, 7))
+	assert.Equal(t, time.Hour, lockoutDuration(time.Minute, time.Hour, 100))
+}
+
+func TestUnlockAccount(t *testing.T) {
+	adminID := uuid.New()
+	user := &models.User{ID: uuid.New(), Email: "john@example.com", Role: models.RoleUser}
+
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		attemptRepo := new(MockLoginAttemptRepository)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+		attemptRepo.On("ResetAccount", mock.Anything, user.ID).Return(nil)
+
+		service := NewAuthService(userRepo, nil, newTestConfig(), new(MockRedisRepository), attemptRepo, new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response := service.UnlockAccount(context.Background(), adminID, user.ID)
+
+		assert.Nil(t, response)
+		attemptRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - Own Account", func(t *testing.T) {
+		service := NewAuthService(new(MockUserRepository), nil, newTestConfig(), new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response := service.UnlockAccount(context.Background(), adminID, adminID)
+
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusBadRequest, response.Status)
+		assert.Equal(t, ErrCantUpdateOwnAccount, response.Err.Error())
+	})
+
+	t.Run("Error - User Not Found", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, user.ID).Return(nil, &dto.ServiceResponse{Status: http.StatusNotFound})
+
+		service := NewAuthService(userRepo, nil, newTestConfig(), new(MockRedisRepository), new(MockLoginAttemptRepository), new(MockMFARepository), new(MockPermissionRepository), new(MockAPIKeyRepository), new(MockIdentityRepository), new(MockOIDCStateRepository), new(MockAuditService))
+		response := service.UnlockAccount(context.Background(), adminID, user.ID)
+
+		assert.NotNil(t, response)
+		assert.Equal(t, http.StatusNotFound, response.Status)
+	})
+}
+
+func TestUpdateProfile(t *testing.T) {
+	userID := uuid.New()
+	verifiedAt := time.Now()
+	newUser := func() *models.User {
+		return &models.User{ID: userID, Name: "John Doe", Email: "john@example.com", EmailVerifiedAt: &verifiedAt}
+	}
+	strPtr := func(s string) *string { return &s }
+
+	t.Run("Success - Update Name", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		verifyRepo := new(MockEmailVerificationRepository)
+		userRepo.On("FindByID", mock.Anything, userID).Return(newUser(), nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+			return u.Name == "Johnny Doe" && u.IsEmailVerified()
+		})).Return(&models.User{ID: userID, Name: "Johnny Doe", Email: "john@example.com", EmailVerifiedAt: &verifiedAt}, nil)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), verifyRepo, newMockMailer(), newTestConfig(), new(MockAuditService))
+		response, errUpdate := service.UpdateProfile(context.Background(), userID, &dto.UpdateProfileRequest{Name: strPtr("Johnny Doe")})
+
+		assert.Nil(t, errUpdate)
+		assert.Equal(t, "Johnny Doe", response.Name)
+		assert.True(t, response.EmailVerified)
+		verifyRepo.AssertNotCalled(t, "SaveVerificationToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success - Change Email Requires Verification", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		verifyRepo := new(MockEmailVerificationRepository)
+		mockMailer := newMockMailer()
+		userRepo.On("FindByID", mock.Anything, userID).Return(newUser(), nil)
+		userRepo.On("EmailTaken", mock.Anything, "new@example.com", userID).Return(false, nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+			return u.Email == "new@example.com" && !u.IsEmailVerified()
+		})).Return(&models.User{ID: userID, Name: "John Doe", Email: "new@example.com"}, nil)
+		verifyRepo.On("SaveVerificationToken", mock.Anything, userID, mock.Anything, mock.Anything).Return(nil)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), verifyRepo, mockMailer, newTestConfig(), new(MockAuditService))
+		response, errUpdate := service.UpdateProfile(context.Background(), userID, &dto.UpdateProfileRequest{Email: strPtr("new@example.com")})
+
+		assert.Nil(t, errUpdate)
+		assert.Equal(t, "new@example.com", response.Email)
+		assert.False(t, response.EmailVerified)
+		verifyRepo.AssertExpectations(t)
+
+		// link xác minh tới email mới, thông báo tới email cũ
+		recipients := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			select {
+			case msg := <-mockMailer.sent:
+				recipients[msg.To] = true
+			case <-time.After(time.Second):
+				t.Fatal("email was not sent")
+			}
+		}
+		assert.True(t, recipients["new@example.com"])
+		assert.True(t, recipients["john@example.com"])
+	})
+
+	t.Run("Success - Change Email Case", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		verifyRepo := new(MockEmailVerificationRepository)
+		mockMailer := newMockMailer()
+		userRepo.On("FindByID", mock.Anything, userID).Return(newUser(), nil)
+		userRepo.On("EmailTaken", mock.Anything, "John@Example.com", userID).Return(false, nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+			return u.Email == "John@Example.com" && u.IsEmailVerified()
+		})).Return(&models.User{ID: userID, Name: "John Doe", Email: "John@Example.com", EmailVerifiedAt: &verifiedAt}, nil)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), verifyRepo, mockMailer, newTestConfig(), new(MockAuditService))
+		response, errUpdate := service.UpdateProfile(context.Background(), userID, &dto.UpdateProfileRequest{Email: strPtr(" John@Example.com ")})
+
+		assert.Nil(t, errUpdate)
+		assert.Equal(t, "John@Example.com", response.Email)
+		assert.True(t, response.EmailVerified)
+		userRepo.AssertExpectations(t)
+		verifyRepo.AssertNotCalled(t, "SaveVerificationToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success - Same Email Not Checked", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, userID).Return(newUser(), nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+			return u.Email == "john@example.com" && u.IsEmailVerified()
+		})).Return(newUser(), nil)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
+		_, errUpdate := service.UpdateProfile(context.Background(), userID, &dto.UpdateProfileRequest{Email: strPtr("john@example.com ")})
+
+		assert.Nil(t, errUpdate)
+		userRepo.AssertNotCalled(t, "EmailTaken", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - Email Taken", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, userID).Return(newUser(), nil)
+		userRepo.On("EmailTaken", mock.Anything, "taken@example.com", userID).Return(true, nil)
+
+		service := NewUserService(userRepo, new(MockRedisRepository), new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
+		response, errUpdate := service.UpdateProfile(context.Background(), userID, &dto.UpdateProfileRequest{Email: strPtr("taken@example.com")})
+
+		assert.Nil(t, response)
+		assert.Equal(t, http.StatusConflict, errUpdate.Status)
+		assert.Equal(t, ErrEmailExists, errUpdate.Err.Error())
+		userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - Unique Constraint On Update", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		userRepo.On("FindByID", mock.Anything, userID).Return(newUser(), nil)
+		userRepo.On("EmailTaken", mock.Anything, "race@example.com", userID).Return(false, nil)
+		userRepo.On("Update", mock.Anything, mock.Anything).Return(nil, &dto.ServiceResponse{Status: http.StatusConflict, Err: errors.New("duplicate")})
+
+		service := NewUserService(userRepo, new(MockRedisRepository), new(MockEmailVerificationRepository), newMockMailer(), newTestConfig(), new(MockAuditService))
+		response, errUpdate := service.UpdateProfile(context.Background(), userID, &dto.UpdateProfileRequest{Email: strPtr("race@example.com")})
+
+		assert.Nil(t, response)
+		assert.Equal(t, http.StatusConflict, errUpdate.Status)
+		assert.Equal(t, ErrEmailExists, errUpdate.Err.Error())
+	})
+}
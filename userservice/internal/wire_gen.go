@@ -12,6 +12,7 @@ import (
 	"github.com/agris/user-service/internal/cache"
 	"github.com/agris/user-service/internal/database"
 	"github.com/agris/user-service/internal/handler"
+	"github.com/agris/user-service/internal/mailer"
 	"github.com/agris/user-service/internal/middleware"
 	"github.com/agris/user-service/internal/repository"
 	"github.com/agris/user-service/internal/router"
@@ -40,32 +41,59 @@ func New() (*fiber.App, error) {
 		return nil, err
 	}
 	userRepository := repository.NewUserRepository(db, client)
-	jwtManager := jwtMg.NewJWTManager(configConfig)
+	jwtManager, err := jwtMg.NewJWTManager(configConfig)
+	if err != nil {
+		return nil, err
+	}
 	redisRepository := cache.NewRedisRepository(client)
-	authService := service.NewAuthService(userRepository, jwtManager, configConfig, redisRepository)
+	loginAttemptRepository := cache.NewLoginAttemptRepository(client)
+	mfaRepository := cache.NewMFARepository(client)
+	permissionRepository := repository.NewPermissionRepository(db)
+	apiKeyRepository := repository.NewAPIKeyRepository(db)
+	identityRepository := repository.NewIdentityRepository(db)
+	oidcStateRepository := cache.NewOIDCStateRepository(client)
+	auditRepository := repository.NewAuditRepository(db)
+	auditService := service.NewAuditService(auditRepository)
+	authService := service.NewAuthService(userRepository, jwtManager, configConfig, redisRepository, loginAttemptRepository, mfaRepository, permissionRepository, apiKeyRepository, identityRepository, oidcStateRepository, auditService)
 	authHandler := handler.NewAuthHandler(authService)
-	userService := service.NewUserService(userRepository)
+	emailVerificationRepository := cache.NewEmailVerificationRepository(client)
+	mailerMailer, err := mailer.NewMailer(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	userService := service.NewUserService(userRepository, redisRepository, emailVerificationRepository, mailerMailer, configConfig, auditService)
 	userHandler := handler.NewUserHandler(userService)
-	middlewareMiddleware := middleware.NewMiddleware(jwtManager)
-	routerHandler := router.NewRouterHandler(authHandler, userHandler, middlewareMiddleware)
-	app := NewServer(routerHandler)
+	passwordResetRepository := cache.NewPasswordResetRepository(client)
+	passwordService := service.NewPasswordService(userRepository, redisRepository, passwordResetRepository, mailerMailer, configConfig, auditService)
+	passwordHandler := handler.NewPasswordHandler(passwordService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	middlewareMiddleware := middleware.NewMiddleware(authService)
+	routerHandler := router.NewRouterHandler(authHandler, userHandler, passwordHandler, auditHandler, middlewareMiddleware)
+	app := NewServer(routerHandler, auditService, configConfig)
 	return app, nil
 }
 
 // server.go:
 
-func NewServer(router2 *router.RouterHandler) *fiber.App {
+func NewServer(router2 *router.RouterHandler, auditService service.AuditService, cfg *config.Config) *fiber.App {
 	app := fiber.New(fiber.Config{
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 5 * time.Second,
 		JSONDecoder:  json.Unmarshal,
 		JSONEncoder:  json.Marshal,
+		ProxyHeader:  cfg.Server.ProxyHeader,
 	})
 
 	app.Use(logger.New())
 	app.Use(cors.New())
 	recoverConfig := recover2.ConfigDefault
 	app.Use(recover2.New(recoverConfig))
+	app.Use(middleware.RequestMeta())
+
+	app.Hooks().OnShutdown(func() error {
+		auditService.Close()
+		return nil
+	})
 	router2.
 		InitRouter(app)
 
@@ -11,15 +11,80 @@ import (
 var (
 	ErrInvalidToken = errors.New("invalid token")
 	ErrExpiredToken = errors.New("token has expired")
+	ErrRevokedToken = errors.New("token has been revoked")
+	ErrTokenType    = errors.New("unexpected token type")
+)
+
+type TokenType string
+
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+	// TokenTypeAPIKey đánh dấu claims dựng từ API key, không phải JWT
+	TokenTypeAPIKey TokenType = "api_key"
 )
 
 type Claims struct {
 	UserID uuid.UUID   `json:"user_id"`
 	Email  string      `json:"email"`
+	Name   string      `json:"name"`
 	Role   models.Role `json:"role"`
+	// EmailVerified cho service khác kiểm tra mà không cần gọi lại userservice
+	EmailVerified bool `json:"email_verified"`
+	// MFA cho biết phiên đăng nhập đã qua bước xác thực TOTP
+	MFA bool `json:"mfa,omitempty"`
+	// SessionID giữ nguyên qua các lần làm mới token của cùng một lần đăng nhập
+	SessionID string `json:"sid,omitempty"`
+	// Permissions là quyền của Role tại thời điểm cấp token
+	Permissions []models.Permission `json:"perms,omitempty"`
+	TokenType   TokenType           `json:"token_type"`
+	// IssuedAtMs là thời điểm cấp theo mili giây để so với mốc thu hồi tokens_revoked_at, iat chỉ có độ chính xác giây
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// IssuedAtTime trả về thời điểm cấp token, token cấp trước khi có iat_ms dùng iat
+func (c *Claims) IssuedAtTime() time.Time {
+	if c.IssuedAtMs > 0 {
+		return time.UnixMilli(c.IssuedAtMs)
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
+// TokenOption bổ sung claim khi tạo token
+type TokenOption func(*Claims)
+
+// WithMFA đánh dấu token được cấp sau khi đã xác thực MFA
+func WithMFA(mfa bool) TokenOption {
+	return func(c *Claims) {
+		c.MFA = mfa
+	}
+}
+
+func WithSessionID(sessionID string) TokenOption {
+	return func(c *Claims) {
+		c.SessionID = sessionID
+	}
+}
+
+func WithPermissions(permissions []models.Permission) TokenOption {
+	return func(c *Claims) {
+		c.Permissions = permissions
+	}
+}
+
+func (c *Claims) HasPermission(permission models.Permission) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
 type TokenInfo struct {
 	Token     string
 	JTI       string
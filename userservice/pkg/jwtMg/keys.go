@@ -0,0 +1,150 @@
+package jwtMg
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/agris/user-service/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrKeyNotFound    = errors.New("signing key not found")
+	ErrUnsupportedKey = errors.New("unsupported key type, only RSA and Ed25519 are allowed")
+)
+
+// signingKey là một cặp khóa có kid, private key chỉ có ở khóa đang dùng để ký
+type signingKey struct {
+	id         string
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+}
+
+func loadSigningKeys(cfg []config.JWTKeyConfig) (map[string]*signingKey, error) {
+	keys := make(map[string]*signingKey, len(cfg))
+	for _, kc := range cfg {
+		if kc.ID == "" {
+			return nil, errors.New("jwt key id is required")
+		}
+		if _, ok := keys[kc.ID]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id %q", kc.ID)
+		}
+
+		key, err := loadSigningKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("load jwt key %q: %w", kc.ID, err)
+		}
+		keys[kc.ID] = key
+	}
+	return keys, nil
+}
+
+func loadSigningKey(kc config.JWTKeyConfig) (*signingKey, error) {
+	key := &signingKey{id: kc.ID}
+
+	privatePEM, err := readPEM(kc.PrivateKey, kc.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if privatePEM != nil {
+		signer, err := parsePrivateKey(privatePEM)
+		if err != nil {
+			return nil, err
+		}
+		key.privateKey = signer
+		key.publicKey = signer.Public()
+	}
+
+	publicPEM, err := readPEM(kc.PublicKey, kc.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if publicPEM != nil && key.publicKey == nil {
+		publicKey, err := parsePublicKey(publicPEM)
+		if err != nil {
+			return nil, err
+		}
+		key.publicKey = publicKey
+	}
+
+	if key.publicKey == nil {
+		return nil, errors.New("either private or public key is required")
+	}
+
+	switch key.publicKey.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	return key, nil
+}
+
+// readPEM ưu tiên PEM khai báo trực tiếp trong config, sau đó tới file
+func readPEM(inline, file string) ([]byte, error) {
+	var data []byte
+	switch {
+	case inline != "":
+		data = []byte(inline)
+	case file != "":
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		data = b
+	default:
+		return nil, nil
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM data")
+	}
+	return block.Bytes, nil
+}
+
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case ed25519.PrivateKey:
+			return k, nil
+		default:
+			return nil, ErrUnsupportedKey
+		}
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(der)
+	if err != nil {
+		return nil, ErrUnsupportedKey
+	}
+	return key, nil
+}
+
+func parsePublicKey(der []byte) (crypto.PublicKey, error) {
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		switch k := key.(type) {
+		case *rsa.PublicKey, ed25519.PublicKey:
+			return k, nil
+		default:
+			return nil, ErrUnsupportedKey
+		}
+	}
+
+	key, err := x509.ParsePKCS1PublicKey(der)
+	if err != nil {
+		return nil, ErrUnsupportedKey
+	}
+	return key, nil
+}
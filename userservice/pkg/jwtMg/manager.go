@@ -2,11 +2,12 @@ package jwtMg
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/agris/user-service/config"
 
-	"github.com/agris/user-service/internal/model"
+	models "github.com/agris/user-service/internal/model"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
@@ -15,26 +16,70 @@ type JWTManager struct {
 	secretKey     string
 	accessExpiry  time.Duration
 	refreshExpiry time.Duration
+	// keys chứa mọi khóa bất đối xứng được chấp nhận, activeKey là khóa dùng để ký
+	keys      map[string]*signingKey
+	activeKey *signingKey
+	// acceptHS256 cho phép xác thực token HS256 không có kid bằng secret
+	acceptHS256 bool
 }
 
-func NewJWTManager(config *config.Config) *JWTManager {
-	return &JWTManager{
+func NewJWTManager(config *config.Config) (*JWTManager, error) {
+	keys, err := loadSigningKeys(config.JWT.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := &JWTManager{
 		secretKey:     config.JWT.Secret,
 		accessExpiry:  config.JWT.AccessExpiry,
 		refreshExpiry: config.JWT.RefreshExpiry,
+		keys:          keys,
+	}
+
+	if config.JWT.ActiveKeyID != "" {
+		active, ok := keys[config.JWT.ActiveKeyID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, config.JWT.ActiveKeyID)
+		}
+		if active.privateKey == nil {
+			return nil, fmt.Errorf("jwt key %q has no private key", active.id)
+		}
+		manager.activeKey = active
+		// sau khi chuyển sang khóa bất đối xứng, ai có secret cũ cũng tự ký được token HS256,
+		// nên chỉ chấp nhận khi bật rõ ràng accept_legacy_hs256 trong lúc chờ token cũ hết hạn
+		manager.acceptHS256 = config.JWT.AcceptLegacyHS256 && manager.secretKey != ""
+	} else if manager.secretKey == "" {
+		return nil, errors.New("jwt secret or active_key_id is required")
+	} else {
+		manager.acceptHS256 = true
 	}
+
+	return manager, nil
 }
 
 // tạo JWT access token với JTI
-func (j *JWTManager) GenerateAccessToken(user *models.User, timeEx time.Duration) (*TokenInfo, error) {
+func (j *JWTManager) GenerateAccessToken(user *models.User, timeEx time.Duration, opts ...TokenOption) (*TokenInfo, error) {
+	return j.generateToken(user, TokenTypeAccess, timeEx, opts)
+}
+
+// tạo JWT refresh token, chỉ dùng được ở /users/refresh
+func (j *JWTManager) GenerateRefreshToken(user *models.User, opts ...TokenOption) (*TokenInfo, error) {
+	return j.generateToken(user, TokenTypeRefresh, j.refreshExpiry, opts)
+}
+
+func (j *JWTManager) generateToken(user *models.User, tokenType TokenType, timeEx time.Duration, opts []TokenOption) (*TokenInfo, error) {
 	now := time.Now()
 	expiresAt := now.Add(timeEx)
 	jti := uuid.New().String()
 
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:        user.ID,
+		Email:         user.Email,
+		Name:          user.Name,
+		Role:          user.Role,
+		EmailVerified: user.IsEmailVerified(),
+		TokenType:     tokenType,
+		IssuedAtMs:    now.UnixMilli(),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
@@ -42,11 +87,14 @@ func (j *JWTManager) GenerateAccessToken(user *models.User, timeEx time.Duration
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "user-service",
 			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{string(tokenType)},
 		},
 	}
+	for _, opt := range opts {
+		opt(claims)
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(j.secretKey))
+	tokenString, err := j.sign(claims)
 	if err != nil {
 		return nil, err
 	}
@@ -54,23 +102,31 @@ func (j *JWTManager) GenerateAccessToken(user *models.User, timeEx time.Duration
 	return &TokenInfo{
 		Token:     tokenString,
 		JTI:       jti,
-		ExpiresIn: int64(j.accessExpiry.Seconds()),
+		ExpiresIn: int64(timeEx.Seconds()),
 		ExpiresAt: expiresAt,
 	}, nil
 }
 
-func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return []byte(j.secretKey), nil
-	})
+// ValidateAccessToken chỉ chấp nhận access token, refresh token sẽ bị từ chối
+func (j *JWTManager) ValidateAccessToken(tokenString string) (*Claims, error) {
+	return j.validateToken(tokenString, TokenTypeAccess)
+}
+
+// ValidateRefreshToken chỉ chấp nhận refresh token
+func (j *JWTManager) ValidateRefreshToken(tokenString string) (*Claims, error) {
+	return j.validateToken(tokenString, TokenTypeRefresh)
+}
+
+func (j *JWTManager) validateToken(tokenString string, tokenType TokenType) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, j.keyFunc, jwt.WithAudience(string(tokenType)))
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, ErrExpiredToken
 		}
+		if errors.Is(err, jwt.ErrTokenInvalidAudience) {
+			return nil, ErrTokenType
+		}
 		return nil, ErrInvalidToken
 	}
 
@@ -79,5 +135,41 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
+	if claims.TokenType != tokenType {
+		return nil, ErrTokenType
+	}
+
 	return claims, nil
 }
+
+// sign ký bằng khóa đang hoạt động và gắn kid vào header, nếu không có thì dùng HS256
+func (j *JWTManager) sign(claims *Claims) (string, error) {
+	if j.activeKey == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(j.secretKey))
+	}
+
+	token := jwt.NewWithClaims(j.activeKey.method, claims)
+	token.Header["kid"] = j.activeKey.id
+	return token.SignedString(j.activeKey.privateKey)
+}
+
+// keyFunc chọn khóa xác thực theo kid, thuật toán phải khớp với loại khóa để tránh algorithm confusion
+func (j *JWTManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		// token HS256, chỉ chấp nhận khi đang ký HS256 hoặc bật accept_legacy_hs256
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || !j.acceptHS256 {
+			return nil, ErrInvalidToken
+		}
+		return []byte(j.secretKey), nil
+	}
+
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, ErrInvalidToken
+	}
+	return key.publicKey, nil
+}
@@ -0,0 +1,224 @@
+package jwtMg
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/config"
+	models "github.com/agris/user-service/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaPrivatePEM(t *testing.T) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func ed25519KeyPairPEM(t *testing.T) (string, string) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privDer, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubDer, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDer})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}))
+}
+
+func newConfig(activeKeyID string, keys ...config.JWTKeyConfig) *config.Config {
+	return &config.Config{
+		JWT: config.JWTConfig{
+			AccessExpiry:  time.Hour,
+			RefreshExpiry: 24 * time.Hour,
+			ActiveKeyID:   activeKeyID,
+			Keys:          keys,
+		},
+	}
+}
+
+func TestAsymmetricSigning(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "john@example.com", Role: models.RoleUser}
+	edPrivate, _ := ed25519KeyPairPEM(t)
+
+	tests := []struct {
+		name   string
+		key    config.JWTKeyConfig
+		method string
+		kty    string
+	}{
+		{
+			name:   "RS256",
+			key:    config.JWTKeyConfig{ID: "rsa-1", PrivateKey: rsaPrivatePEM(t)},
+			method: "RS256",
+			kty:    "RSA",
+		},
+		{
+			name:   "EdDSA",
+			key:    config.JWTKeyConfig{ID: "ed-1", PrivateKey: edPrivate},
+			method: "EdDSA",
+			kty:    "OKP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, err := NewJWTManager(newConfig(tt.key.ID, tt.key))
+			require.NoError(t, err)
+
+			info, err := manager.GenerateAccessToken(user, time.Hour)
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(info.Token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.method, parsed.Method.Alg())
+			assert.Equal(t, tt.key.ID, parsed.Header["kid"])
+
+			claims, err := manager.ValidateAccessToken(info.Token)
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, claims.UserID)
+
+			jwks := manager.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.key.ID, jwks.Keys[0].Kid)
+			assert.Equal(t, tt.kty, jwks.Keys[0].Kty)
+			assert.Equal(t, tt.method, jwks.Keys[0].Alg)
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "john@example.com", Role: models.RoleUser}
+	oldPrivate, oldPublic := ed25519KeyPairPEM(t)
+	newKey := config.JWTKeyConfig{ID: "new", PrivateKey: rsaPrivatePEM(t)}
+
+	// token được ký bằng khóa cũ trước khi xoay vòng
+	oldManager, err := NewJWTManager(newConfig("old", config.JWTKeyConfig{ID: "old", PrivateKey: oldPrivate}))
+	require.NoError(t, err)
+	oldToken, err := oldManager.GenerateAccessToken(user, time.Hour)
+	require.NoError(t, err)
+
+	// sau khi xoay vòng, khóa cũ chỉ còn public key
+	manager, err := NewJWTManager(newConfig("new", newKey, config.JWTKeyConfig{ID: "old", PublicKey: oldPublic}))
+	require.NoError(t, err)
+
+	_, err = manager.ValidateAccessToken(oldToken.Token)
+	assert.NoError(t, err)
+
+	newToken, err := manager.GenerateAccessToken(user, time.Hour)
+	require.NoError(t, err)
+	_, err = manager.ValidateAccessToken(newToken.Token)
+	assert.NoError(t, err)
+	assert.Len(t, manager.JWKS().Keys, 2)
+
+	// khóa cũ bị gỡ hẳn thì token cũ không còn hợp lệ
+	retired, err := NewJWTManager(newConfig("new", newKey))
+	require.NoError(t, err)
+	_, err = retired.ValidateAccessToken(oldToken.Token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestRejectsAlgorithmConfusion(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "john@example.com", Role: models.RoleUser}
+	_, edPublic := ed25519KeyPairPEM(t)
+
+	manager, err := NewJWTManager(&config.Config{
+		JWT: config.JWTConfig{
+			Secret:        "shared-secret",
+			AccessExpiry:  time.Hour,
+			RefreshExpiry: 24 * time.Hour,
+			Keys:          []config.JWTKeyConfig{{ID: "ed", PublicKey: edPublic}},
+		},
+	})
+	require.NoError(t, err)
+
+	// HS256 token giả mạo kid của khóa Ed25519
+	claims := &Claims{
+		UserID:    user.ID,
+		TokenType: TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			Audience:  jwt.ClaimStrings{string(TokenTypeAccess)},
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = "ed"
+	forged, err := token.SignedString([]byte("shared-secret"))
+	require.NoError(t, err)
+
+	_, err = manager.ValidateAccessToken(forged)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestLegacyHS256AfterMigration(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "john@example.com", Role: models.RoleUser}
+	key := config.JWTKeyConfig{ID: "rsa-1", PrivateKey: rsaPrivatePEM(t)}
+
+	legacyCfg := newConfig("")
+	legacyCfg.JWT.Secret = "shared-secret"
+	legacy, err := NewJWTManager(legacyCfg)
+	require.NoError(t, err)
+	legacyToken, err := legacy.GenerateAccessToken(user, time.Hour)
+	require.NoError(t, err)
+	_, err = legacy.ValidateAccessToken(legacyToken.Token)
+	require.NoError(t, err)
+
+	// đã ký bằng khóa bất đối xứng thì secret còn trong config cũng không dùng để xác thực
+	migratedCfg := newConfig(key.ID, key)
+	migratedCfg.JWT.Secret = "shared-secret"
+	migrated, err := NewJWTManager(migratedCfg)
+	require.NoError(t, err)
+	_, err = migrated.ValidateAccessToken(legacyToken.Token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	migratedCfg.JWT.AcceptLegacyHS256 = true
+	transition, err := NewJWTManager(migratedCfg)
+	require.NoError(t, err)
+	_, err = transition.ValidateAccessToken(legacyToken.Token)
+	assert.NoError(t, err)
+}
+
+func TestIssuedAtMillisecondPrecision(t *testing.T) {
+	manager, err := NewJWTManager(&config.Config{JWT: config.JWTConfig{Secret: "secret", AccessExpiry: time.Hour}})
+	require.NoError(t, err)
+	user := &models.User{ID: uuid.New(), Email: "john@example.com", Role: models.RoleUser}
+
+	// token cấp ngay sau mốc thu hồi, trong cùng một giây, phải có thời điểm cấp lớn hơn mốc
+	revokedAt := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	token, err := manager.GenerateAccessToken(user, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := manager.ValidateAccessToken(token.Token)
+	require.NoError(t, err)
+	assert.Greater(t, claims.IssuedAtTime().UnixMilli(), revokedAt.UnixMilli())
+	// iat chuẩn vẫn theo giây
+	assert.Zero(t, claims.IssuedAt.Time.Nanosecond())
+
+	// token cũ không có iat_ms dùng iat
+	legacy := &Claims{RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(revokedAt)}}
+	assert.Equal(t, revokedAt.Unix(), legacy.IssuedAtTime().Unix())
+}
+
+func TestNewJWTManagerErrors(t *testing.T) {
+	_, edPublic := ed25519KeyPairPEM(t)
+
+	_, err := NewJWTManager(newConfig("missing"))
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	_, err = NewJWTManager(newConfig("ed", config.JWTKeyConfig{ID: "ed", PublicKey: edPublic}))
+	assert.Error(t, err, "active key without private key must be rejected")
+
+	_, err = NewJWTManager(newConfig(""))
+	assert.Error(t, err, "secret or active key is required")
+}
@@ -0,0 +1,156 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/pkg/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRedirectURL = "http://localhost:8005/users/oidc/mock/callback"
+	testVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Server) {
+	server := oidctest.NewServer("agris-client", "agris-secret")
+	t.Cleanup(server.Close)
+	server.SetIdentity(oidctest.Identity{Subject: "sub-1", Email: "user@example.com", EmailVerified: true, Name: "Test User"})
+
+	provider := NewProvider(Config{
+		Issuer:       server.Issuer(),
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  testRedirectURL,
+	}, server.Client())
+	return provider, server
+}
+
+// login chạy cả luồng: tạo URL, provider chuyển hướng về callback, đổi code lấy token
+func login(t *testing.T, provider *Provider, server *oidctest.Server, nonce, verifier string) (*Token, error) {
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", nonce, S256Challenge(verifier))
+	require.NoError(t, err)
+
+	callback, err := server.Authorize(authURL)
+	require.NoError(t, err)
+	require.Equal(t, "state-1", callback.Query().Get("state"))
+
+	return provider.Exchange(context.Background(), callback.Query().Get("code"), verifier)
+}
+
+func TestProviderLogin(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		provider, server := newTestProvider(t)
+		token, err := login(t, provider, server, "nonce-1", testVerifier)
+		require.NoError(t, err)
+
+		idToken, err := provider.VerifyIDToken(context.Background(), token.IDToken, "nonce-1")
+		require.NoError(t, err)
+		assert.Equal(t, "sub-1", idToken.Subject)
+		assert.Equal(t, "user@example.com", idToken.Email)
+		assert.True(t, bool(idToken.EmailVerified))
+		assert.Equal(t, "Test User", idToken.Name)
+	})
+
+	t.Run("Error - PKCE Verifier Mismatch", func(t *testing.T) {
+		provider, server := newTestProvider(t)
+		authURL, err := provider.AuthCodeURL(context.Background(), "state-1", "nonce-1", S256Challenge("verifier-a"))
+		require.NoError(t, err)
+		callback, err := server.Authorize(authURL)
+		require.NoError(t, err)
+
+		_, err = provider.Exchange(context.Background(), callback.Query().Get("code"), "verifier-b")
+		assert.ErrorContains(t, err, "invalid_grant")
+	})
+
+	t.Run("Error - Nonce Mismatch", func(t *testing.T) {
+		provider, server := newTestProvider(t)
+		token, err := login(t, provider, server, "nonce-1", testVerifier)
+		require.NoError(t, err)
+
+		_, err = provider.VerifyIDToken(context.Background(), token.IDToken, "nonce-2")
+		assert.ErrorIs(t, err, ErrNonceMismatch)
+	})
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	provider, server := newTestProvider(t)
+	now := time.Now()
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   server.Issuer(),
+			"sub":   "sub-1",
+			"aud":   server.ClientID,
+			"exp":   now.Add(time.Hour).Unix(),
+			"iat":   now.Unix(),
+			"nonce": "nonce-1",
+		}
+	}
+
+	t.Run("Success - String Email Verified", func(t *testing.T) {
+		claims := validClaims()
+		claims["email_verified"] = "true"
+
+		idToken, err := provider.VerifyIDToken(context.Background(), server.SignIDToken(claims), "nonce-1")
+		require.NoError(t, err)
+		assert.True(t, bool(idToken.EmailVerified))
+	})
+
+	tests := []struct {
+		name   string
+		mutate func(jwt.MapClaims)
+	}{
+		{"Error - Wrong Audience", func(c jwt.MapClaims) { c["aud"] = "other-client" }},
+		{"Error - Wrong Issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"Error - Expired", func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Hour).Unix() }},
+		{"Error - Missing Expiry", func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"Error - Missing Subject", func(c jwt.MapClaims) { delete(c, "sub") }},
+		{"Error - Foreign Azp", func(c jwt.MapClaims) {
+			c["aud"] = []string{server.ClientID, "other-client"}
+			c["azp"] = "other-client"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.mutate(claims)
+
+			_, err := provider.VerifyIDToken(context.Background(), server.SignIDToken(claims), "nonce-1")
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("Success - Key Rotation Refetches JWKS", func(t *testing.T) {
+		_, err := provider.VerifyIDToken(context.Background(), server.SignIDToken(validClaims()), "nonce-1")
+		require.NoError(t, err)
+
+		server.RotateKey()
+		rotated := server.SignIDToken(validClaims())
+
+		// vừa tải JWKS nên kid lạ bị từ chối thay vì tải lại liên tục
+		_, err = provider.VerifyIDToken(context.Background(), rotated, "nonce-1")
+		assert.ErrorIs(t, err, ErrUnknownKey)
+
+		provider.keysFetchedAt = time.Now().Add(-jwksRefreshInterval)
+		_, err = provider.VerifyIDToken(context.Background(), rotated, "nonce-1")
+		assert.NoError(t, err)
+	})
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"issuer":"https://other.example.com","authorization_endpoint":"a","token_endpoint":"t","jwks_uri":"j"}`))
+	}))
+	defer server.Close()
+
+	provider := NewProvider(Config{Issuer: server.URL, ClientID: "agris-client", RedirectURL: testRedirectURL}, server.Client())
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	assert.ErrorIs(t, err, ErrIssuerMismatch)
+}
@@ -0,0 +1,208 @@
+// Package tlsreload tạo tls.Config đọc lại chứng chỉ từ file khi file thay đổi,
+// chứng chỉ được xoay vòng mà không cần khởi động lại service.
+// Hai service là hai module riêng nên package được giữ một bản giống hệt ở productservice/internal/grpc/tlsreload, sửa bên nào thì sửa cả bên kia.
+package tlsreload
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultCheckInterval = time.Minute
+
+var (
+	ErrNoCertificates = errors.New("tlsreload: CA file contains no certificates")
+	ErrNoKeyPair      = errors.New("tlsreload: server requires cert_file and key_file")
+	ErrNoServerName   = errors.New("tlsreload: server name is required to verify the server certificate")
+)
+
+// Files là đường dẫn PEM. CertFile/KeyFile là chứng chỉ của chính service,
+// CAFile là CA dùng để xác minh phía bên kia (client CA ở server, server CA ở client).
+type Files struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+// Reloader giữ chứng chỉ và CA đã nạp. Mỗi lần handshake, nếu đã qua interval kể từ lần kiểm tra trước
+// thì so sánh thời gian sửa file và nạp lại. Nạp lại lỗi (vd file mới ghi dở) thì tiếp tục dùng bản cũ.
+type Reloader struct {
+	files    Files
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	pool      *x509.CertPool
+	modTimes  [3]time.Time
+	checkedAt time.Time
+}
+
+// New nạp chứng chỉ lần đầu, lỗi ở bước này được trả về để service không khởi động với cấu hình sai
+func New(files Files, interval time.Duration) (*Reloader, error) {
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	r := &Reloader{files: files, interval: interval, now: time.Now}
+
+	modTimes, err := r.stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTimes); err != nil {
+		return nil, err
+	}
+	r.checkedAt = r.now()
+	return r, nil
+}
+
+// ServerConfig yêu cầu và xác minh chứng chỉ client khi có CAFile (mTLS)
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			if cert == nil {
+				return nil, ErrNoKeyPair
+			}
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				NextProtos:   []string{"h2"},
+			}
+			if pool != nil {
+				cfg.ClientCAs = pool
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// ClientConfig xác minh server theo CAFile và serverName, gửi chứng chỉ client khi có CertFile.
+// RootCAs cố định trong tls.Config không đổi được nên việc xác minh server được làm trong VerifyConnection.
+func (r *Reloader) ClientConfig(serverName string) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		// đã tự xác minh ở VerifyConnection với CA hiện tại
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			_, pool := r.current()
+			name := serverName
+			if name == "" {
+				name = state.ServerName
+			}
+			if name == "" {
+				return ErrNoServerName
+			}
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("tlsreload: server sent no certificate")
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         pool,
+				Intermediates: intermediates,
+				DNSName:       name,
+			})
+			return err
+		},
+	}
+	if r.files.CertFile != "" {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			return cert, nil
+		}
+	}
+	return cfg
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := r.now(); now.Sub(r.checkedAt) >= r.interval {
+		r.checkedAt = now
+		r.reloadIfChanged()
+	}
+	return r.cert, r.pool
+}
+
+func (r *Reloader) reloadIfChanged() {
+	modTimes, err := r.stat()
+	if err != nil {
+		log.Printf("tlsreload: keeping current certificates: %v", err)
+		return
+	}
+	if modTimes == r.modTimes {
+		return
+	}
+	if err := r.load(modTimes); err != nil {
+		log.Printf("tlsreload: keeping current certificates: %v", err)
+		return
+	}
+	log.Printf("tlsreload: reloaded certificates from %s", r.files.CertFile)
+}
+
+func (r *Reloader) stat() ([3]time.Time, error) {
+	var modTimes [3]time.Time
+	for i, path := range []string{r.files.CertFile, r.files.KeyFile, r.files.CAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return modTimes, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+func (r *Reloader) load(modTimes [3]time.Time) error {
+	var cert *tls.Certificate
+	if r.files.CertFile != "" {
+		pair, err := tls.LoadX509KeyPair(r.files.CertFile, r.files.KeyFile)
+		if err != nil {
+			return fmt.Errorf("tlsreload: load key pair: %w", err)
+		}
+		cert = &pair
+	}
+
+	var pool *x509.CertPool
+	if r.files.CAFile != "" {
+		data, err := os.ReadFile(r.files.CAFile)
+		if err != nil {
+			return fmt.Errorf("tlsreload: read CA file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return ErrNoCertificates
+		}
+	}
+
+	r.cert, r.pool, r.modTimes = cert, pool, modTimes
+	return nil
+}
+
+// Identities trả về các định danh của chứng chỉ theo thứ tự URI SAN, DNS SAN, Common Name
+func Identities(cert *x509.Certificate) []string {
+	var ids []string
+	for _, uri := range cert.URIs {
+		ids = append(ids, uri.String())
+	}
+	ids = append(ids, cert.DNSNames...)
+	if cert.Subject.CommonName != "" {
+		ids = append(ids, cert.Subject.CommonName)
+	}
+	return ids
+}
@@ -0,0 +1,170 @@
+package tlsreload
+
+import (
+	"crypto/tls"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/agris/user-service/pkg/tlsreload/tlstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPKI struct {
+	dir    string
+	ca     *tlstest.CA
+	caFile string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	ca, err := tlstest.NewCA("agris-test-ca")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	caFile, err := ca.WriteFile(dir, "ca")
+	require.NoError(t, err)
+	return &testPKI{dir: dir, ca: ca, caFile: caFile}
+}
+
+// issue cấp chứng chỉ và ghi ra file, trả về Files dùng CA của pki
+func (p *testPKI) issue(t *testing.T, commonName string) Files {
+	cert, err := p.ca.Issue(commonName)
+	require.NoError(t, err)
+	certFile, keyFile, err := cert.WriteFiles(p.dir, commonName)
+	require.NoError(t, err)
+	return Files{CertFile: certFile, KeyFile: keyFile, CAFile: p.caFile}
+}
+
+// handshake chạy TLS handshake qua loopback, trả về common name của chứng chỉ server mà client nhận được
+func handshake(t *testing.T, server, client *tls.Config) (string, error) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		// TLS 1.3 chỉ báo lỗi chứng chỉ client ở lần đọc đầu tiên
+		_, err = conn.Read(make([]byte, 1))
+		serverErr <- err
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), client)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte{1}); err != nil {
+		return "", err
+	}
+	if err := <-serverErr; err != nil {
+		return "", err
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestReloaderMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	server, err := New(pki.issue(t, "user-service"), time.Minute)
+	require.NoError(t, err)
+
+	t.Run("Success - Trusted Client", func(t *testing.T) {
+		client, err := New(pki.issue(t, "productservice"), time.Minute)
+		require.NoError(t, err)
+
+		name, err := handshake(t, server.ServerConfig(), client.ClientConfig("user-service"))
+		require.NoError(t, err)
+		assert.Equal(t, "user-service", name)
+	})
+
+	t.Run("Error - Client Without Certificate", func(t *testing.T) {
+		client, err := New(Files{CAFile: pki.caFile}, time.Minute)
+		require.NoError(t, err)
+
+		_, err = handshake(t, server.ServerConfig(), client.ClientConfig("user-service"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Error - Client From Other CA", func(t *testing.T) {
+		other := newTestPKI(t)
+		files := other.issue(t, "productservice")
+		files.CAFile = pki.caFile
+		client, err := New(files, time.Minute)
+		require.NoError(t, err)
+
+		_, err = handshake(t, server.ServerConfig(), client.ClientConfig("user-service"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Error - Wrong Server Name", func(t *testing.T) {
+		client, err := New(pki.issue(t, "productservice"), time.Minute)
+		require.NoError(t, err)
+
+		_, err = handshake(t, server.ServerConfig(), client.ClientConfig("other-service"))
+		assert.Error(t, err)
+	})
+}
+
+func TestReloaderRotation(t *testing.T) {
+	pki := newTestPKI(t)
+	files := pki.issue(t, "user-service")
+	server, err := New(files, time.Minute)
+	require.NoError(t, err)
+	now := time.Now()
+	server.now = func() time.Time { return now }
+	client, err := New(pki.issue(t, "productservice"), time.Minute)
+	require.NoError(t, err)
+
+	// CA mới ký cả chứng chỉ server lẫn client, ghi đè lên file cũ
+	rotated := newTestPKI(t)
+	cert, err := rotated.ca.Issue("user-service")
+	require.NoError(t, err)
+	_, _, err = cert.WriteFiles(pki.dir, "user-service")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(files.CAFile, rotated.ca.CertPEM, 0o600))
+	later := now.Add(time.Second)
+	for _, path := range []string{files.CertFile, files.KeyFile, files.CAFile} {
+		require.NoError(t, os.Chtimes(path, later, later))
+	}
+
+	// chưa tới interval nên vẫn dùng chứng chỉ cũ
+	_, err = handshake(t, server.ServerConfig(), client.ClientConfig("user-service"))
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	_, err = handshake(t, server.ServerConfig(), client.ClientConfig("user-service"))
+	// sau khi nạp lại, client có chứng chỉ của CA cũ bị từ chối
+	assert.Error(t, err)
+
+	newClient, err := New(rotated.issue(t, "productservice"), time.Minute)
+	require.NoError(t, err)
+	_, err = handshake(t, server.ServerConfig(), newClient.ClientConfig("user-service"))
+	assert.NoError(t, err)
+
+	t.Run("Error - Broken Files Keep Current Certificates", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(files.CertFile, []byte("partial"), 0o600))
+		now = now.Add(time.Minute)
+
+		_, err := handshake(t, server.ServerConfig(), newClient.ClientConfig("user-service"))
+		assert.NoError(t, err)
+	})
+}
+
+func TestNewInvalidFiles(t *testing.T) {
+	pki := newTestPKI(t)
+	files := pki.issue(t, "user-service")
+
+	_, err := New(Files{CertFile: files.CertFile, KeyFile: pki.caFile}, time.Minute)
+	assert.Error(t, err)
+
+	_, err = New(Files{CAFile: files.KeyFile}, time.Minute)
+	assert.ErrorIs(t, err, ErrNoCertificates)
+
+	_, err = New(Files{CAFile: pki.dir + "/missing.crt"}, time.Minute)
+	assert.Error(t, err)
+}
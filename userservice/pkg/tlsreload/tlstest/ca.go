@@ -0,0 +1,113 @@
+// Package tlstest tạo CA và chứng chỉ trong bộ nhớ để test TLS/mTLS mà không cần file chứng chỉ có sẵn
+package tlstest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CA là một CA tự ký, mỗi lần gọi NewCA tạo một khóa mới
+type CA struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	CertPEM []byte
+}
+
+func NewCA(name string) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{cert: cert, key: key, CertPEM: encodePEM("CERTIFICATE", der)}, nil
+}
+
+// Cert là chứng chỉ lá ở dạng PEM
+type Cert struct {
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// Issue cấp chứng chỉ dùng được cho cả server và client, commonName đồng thời là DNS SAN
+func (ca *CA) Issue(commonName string) (*Cert, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serialNumber(),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Cert{CertPEM: encodePEM("CERTIFICATE", der), KeyPEM: encodePEM("EC PRIVATE KEY", keyDER)}, nil
+}
+
+// Leaf trả về chứng chỉ đã parse, dùng khi cần giả lập peer đã xác minh
+func (c *Cert) Leaf() (*x509.Certificate, error) {
+	block, _ := pem.Decode(c.CertPEM)
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// WriteFiles ghi chứng chỉ và khóa vào dir/name.crt, dir/name.key rồi trả về hai đường dẫn
+func (c *Cert) WriteFiles(dir, name string) (string, string, error) {
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, c.CertPEM, 0o600); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(keyFile, c.KeyPEM, 0o600); err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
+
+// WriteFile ghi chứng chỉ CA vào dir/name.crt
+func (ca *CA) WriteFile(dir, name string) (string, error) {
+	path := filepath.Join(dir, name+".crt")
+	return path, os.WriteFile(path, ca.CertPEM, 0o600)
+}
+
+func serialNumber() *big.Int {
+	n, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	return n
+}
+
+func encodePEM(blockType string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
@@ -0,0 +1,82 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secret của bộ test vector SHA1 trong RFC 6238
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, code, "unix=%d", tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+
+	t.Run("Success - Current Step", func(t *testing.T) {
+		step, ok := Validate(secret, code, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, now.Unix()/Period, step)
+	})
+
+	t.Run("Success - Within Skew", func(t *testing.T) {
+		_, ok := Validate(secret, code, now.Add(Period*time.Second), 1)
+		assert.True(t, ok)
+	})
+
+	t.Run("Error - Outside Skew", func(t *testing.T) {
+		_, ok := Validate(secret, code, now.Add(2*Period*time.Second), 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("Error - Wrong Code", func(t *testing.T) {
+		_, ok := Validate(secret, "abcdef", now, 1)
+		assert.False(t, ok)
+		_, ok = Validate(secret, "12345", now, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("Error - Invalid Secret", func(t *testing.T) {
+		_, ok := Validate("not base32!", code, now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Agris", "john@example.com", "JBSWY3DPEHPK3PXP")
+
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Agris:john@example.com", u.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	assert.Equal(t, "Agris", u.Query().Get("issuer"))
+}